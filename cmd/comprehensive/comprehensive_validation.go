@@ -40,18 +40,18 @@ func testSample1Math() {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000.00, Quantity: 1.5},
-			{Price: 49950.00, Quantity: 2.0},
-			{Price: 49900.00, Quantity: 1.0},
-			{Price: 49850.00, Quantity: 3.0},
-			{Price: 49800.00, Quantity: 2.5},
+			{Price: types.NewDecimal(50000.00), Quantity: types.NewDecimal(1.5)},
+			{Price: types.NewDecimal(49950.00), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(49900.00), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49850.00), Quantity: types.NewDecimal(3.0)},
+			{Price: types.NewDecimal(49800.00), Quantity: types.NewDecimal(2.5)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100.00, Quantity: 1.2},
-			{Price: 50150.00, Quantity: 1.8},
-			{Price: 50200.00, Quantity: 2.0},
-			{Price: 50250.00, Quantity: 1.5},
-			{Price: 50300.00, Quantity: 2.2},
+			{Price: types.NewDecimal(50100.00), Quantity: types.NewDecimal(1.2)},
+			{Price: types.NewDecimal(50150.00), Quantity: types.NewDecimal(1.8)},
+			{Price: types.NewDecimal(50200.00), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(50250.00), Quantity: types.NewDecimal(1.5)},
+			{Price: types.NewDecimal(50300.00), Quantity: types.NewDecimal(2.2)},
 		},
 	}
 
@@ -61,34 +61,34 @@ func testSample1Math() {
 	bestBid, bidQty, _ := ob.GetBestBid()
 	bestAsk, askQty, _ := ob.GetBestAsk()
 
-	if bestBid != 50000.00 || bidQty != 1.5 {
+	if !bestBid.Equal(types.NewDecimal(50000.00)) || !bidQty.Equal(types.NewDecimal(1.5)) {
 		log.Fatalf("❌ Best bid incorrect: got %.2f@%.2f, expected 50000.00@1.5", bestBid, bidQty)
 	}
 
-	if bestAsk != 50100.00 || askQty != 1.2 {
+	if !bestAsk.Equal(types.NewDecimal(50100.00)) || !askQty.Equal(types.NewDecimal(1.2)) {
 		log.Fatalf("❌ Best ask incorrect: got %.2f@%.2f, expected 50100.00@1.2", bestAsk, askQty)
 	}
 
 	// Validate spread
 	spread, _ := ob.GetSpread()
-	expectedSpread := 50100.00 - 50000.00
-	if spread != expectedSpread {
+	expectedSpread := types.NewDecimal(50100.00).Sub(types.NewDecimal(50000.00))
+	if !spread.Equal(expectedSpread) {
 		log.Fatalf("❌ Spread incorrect: got %.2f, expected %.2f", spread, expectedSpread)
 	}
 
 	// Test market order execution for 1.0 unit buy
 	// Should fill 1.0 @ 50100.00 (taking from best ask)
-	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, 1.0)
-	if !canFill || fillPrice != 50100.00 {
+	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, types.NewDecimal(1.0))
+	if !canFill || !fillPrice.Equal(types.NewDecimal(50100.00)) {
 		log.Fatalf("❌ Market buy 1.0 incorrect: got %.2f, expected 50100.00", fillPrice)
 	}
 
 	// Test market order execution for 2.0 unit buy
 	// Should fill 1.2 @ 50100.00 + 0.8 @ 50150.00
 	// = (1.2 * 50100.00 + 0.8 * 50150.00) / 2.0 = (60120 + 40120) / 2.0 = 50120.00
-	fillPrice, canFill = ob.GetFillPrice(types.SideBuy, 2.0)
+	fillPrice, canFill = ob.GetFillPrice(types.SideBuy, types.NewDecimal(2.0))
 	expectedPrice := (1.2*50100.00 + 0.8*50150.00) / 2.0
-	if !canFill || math.Abs(fillPrice-expectedPrice) > 0.01 {
+	if !canFill || math.Abs(fillPrice.Float64()-expectedPrice) > 0.01 {
 		log.Fatalf("❌ Market buy 2.0 incorrect: got %.2f, expected %.2f", fillPrice, expectedPrice)
 	}
 
@@ -109,10 +109,10 @@ func testEdgeCasesMath() {
 		Symbol:    "TESTCOIN",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 1.0, Quantity: 0.1},
+			{Price: types.NewDecimal(1.0), Quantity: types.NewDecimal(0.1)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 1.1, Quantity: 0.1},
+			{Price: types.NewDecimal(1.1), Quantity: types.NewDecimal(0.1)},
 		},
 	}
 
@@ -122,22 +122,22 @@ func testEdgeCasesMath() {
 	bestBid, bidQty, _ := ob.GetBestBid()
 	bestAsk, askQty, _ := ob.GetBestAsk()
 
-	if bestBid != 1.0 || bidQty != 0.1 {
+	if !bestBid.Equal(types.NewDecimal(1.0)) || !bidQty.Equal(types.NewDecimal(0.1)) {
 		log.Fatalf("❌ Small order book bid incorrect")
 	}
 
-	if bestAsk != 1.1 || askQty != 0.1 {
+	if !bestAsk.Equal(types.NewDecimal(1.1)) || !askQty.Equal(types.NewDecimal(0.1)) {
 		log.Fatalf("❌ Small order book ask incorrect")
 	}
 
 	// Test exact fill
-	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, 0.1)
-	if !canFill || fillPrice != 1.1 {
+	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, types.NewDecimal(0.1))
+	if !canFill || !fillPrice.Equal(types.NewDecimal(1.1)) {
 		log.Fatalf("❌ Exact fill incorrect")
 	}
 
 	// Test overfill
-	_, canFill = ob.GetFillPrice(types.SideBuy, 0.2)
+	_, canFill = ob.GetFillPrice(types.SideBuy, types.NewDecimal(0.2))
 	if canFill {
 		log.Fatalf("❌ Overfill should not be possible")
 	}
@@ -156,28 +156,28 @@ func testDepthCalculations() {
 		Symbol:    "TESTCOIN",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 100.0, Quantity: 1.0},
-			{Price: 99.0, Quantity: 2.0},
-			{Price: 98.0, Quantity: 3.0},
+			{Price: types.NewDecimal(100.0), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(99.0), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(98.0), Quantity: types.NewDecimal(3.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 101.0, Quantity: 1.0},
-			{Price: 102.0, Quantity: 2.0},
-			{Price: 103.0, Quantity: 3.0},
+			{Price: types.NewDecimal(101.0), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(102.0), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(103.0), Quantity: types.NewDecimal(3.0)},
 		},
 	}
 
 	ob.Update(snapshot)
 
 	// Test bid depth at 99.0 (should include 100.0@1.0 + 99.0@2.0 = 3.0)
-	bidDepth := ob.GetCumulativeDepth(types.SideBuy, 99.0)
-	if bidDepth != 3.0 {
+	bidDepth := ob.GetCumulativeDepth(types.SideBuy, types.NewDecimal(99.0))
+	if !bidDepth.Equal(types.NewDecimal(3.0)) {
 		log.Fatalf("❌ Bid depth at 99.0 incorrect: got %.2f, expected 3.0", bidDepth)
 	}
 
 	// Test ask depth at 102.0 (should include 101.0@1.0 + 102.0@2.0 = 3.0)
-	askDepth := ob.GetCumulativeDepth(types.SideSell, 102.0)
-	if askDepth != 3.0 {
+	askDepth := ob.GetCumulativeDepth(types.SideSell, types.NewDecimal(102.0))
+	if !askDepth.Equal(types.NewDecimal(3.0)) {
 		log.Fatalf("❌ Ask depth at 102.0 incorrect: got %.2f, expected 3.0", askDepth)
 	}
 
@@ -187,7 +187,7 @@ func testDepthCalculations() {
 	// Bids >= 99.495: 100.0@1.0 = 1.0
 	// Asks <= 101.505: 101.0@1.0 = 1.0
 	bidLiq, askLiq := ob.GetLiquidity(0, 0.01)
-	if bidLiq != 1.0 || askLiq != 1.0 {
+	if !bidLiq.Equal(types.NewDecimal(1.0)) || !askLiq.Equal(types.NewDecimal(1.0)) {
 		log.Fatalf("❌ Liquidity calculation incorrect: got bid=%.2f ask=%.2f, expected bid=1.0 ask=1.0", bidLiq, askLiq)
 	}
 
@@ -17,14 +17,14 @@ func main() {
 		Symbol:    "TESTCOIN",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 100.00, Quantity: 1.0},
-			{Price: 99.50, Quantity: 2.0},
-			{Price: 99.00, Quantity: 1.5},
+			{Price: types.NewDecimal(100.00), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(99.50), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(99.00), Quantity: types.NewDecimal(1.5)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 101.00, Quantity: 1.0},
-			{Price: 101.50, Quantity: 2.0},
-			{Price: 102.00, Quantity: 1.5},
+			{Price: types.NewDecimal(101.00), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(101.50), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(102.00), Quantity: types.NewDecimal(1.5)},
 		},
 	}
 
@@ -48,7 +48,7 @@ func main() {
 	fmt.Println("  Total cost: 253.25")
 	fmt.Println("  Average price: 253.25 / 2.5 = 101.30")
 
-	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, 2.5)
+	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, types.NewDecimal(2.5))
 	fmt.Printf("  Actual result: %.2f, canFill: %v\n", fillPrice, canFill)
 
 	expectedPrice := (1.0*101.00 + 1.5*101.50) / 2.5
@@ -71,7 +71,7 @@ func main() {
 	fmt.Println("  Total proceeds: 249.25")
 	fmt.Println("  Average price: 249.25 / 2.5 = 99.70")
 
-	fillPrice, canFill = ob.GetFillPrice(types.SideSell, 2.5)
+	fillPrice, canFill = ob.GetFillPrice(types.SideSell, types.NewDecimal(2.5))
 	fmt.Printf("  Actual result: %.2f, canFill: %v\n", fillPrice, canFill)
 
 	expectedPrice = (1.0*100.00 + 1.5*99.50) / 2.5
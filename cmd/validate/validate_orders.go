@@ -36,16 +36,16 @@ func testBasicSorting() {
 		Symbol:    "TESTCOIN",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 100.00, Quantity: 1.0}, // Should be 3rd
-			{Price: 105.00, Quantity: 2.0}, // Should be 1st (highest bid)
-			{Price: 102.50, Quantity: 1.5}, // Should be 2nd
-			{Price: 95.00, Quantity: 3.0},  // Should be 4th (lowest bid)
+			{Price: types.NewDecimal(100.00), Quantity: types.NewDecimal(1.0)}, // Should be 3rd
+			{Price: types.NewDecimal(105.00), Quantity: types.NewDecimal(2.0)}, // Should be 1st (highest bid)
+			{Price: types.NewDecimal(102.50), Quantity: types.NewDecimal(1.5)}, // Should be 2nd
+			{Price: types.NewDecimal(95.00), Quantity: types.NewDecimal(3.0)},  // Should be 4th (lowest bid)
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 110.00, Quantity: 1.0}, // Should be 2nd
-			{Price: 108.00, Quantity: 2.0}, // Should be 1st (lowest ask)
-			{Price: 115.00, Quantity: 1.5}, // Should be 3rd
-			{Price: 120.00, Quantity: 3.0}, // Should be 4th (highest ask)
+			{Price: types.NewDecimal(110.00), Quantity: types.NewDecimal(1.0)}, // Should be 2nd
+			{Price: types.NewDecimal(108.00), Quantity: types.NewDecimal(2.0)}, // Should be 1st (lowest ask)
+			{Price: types.NewDecimal(115.00), Quantity: types.NewDecimal(1.5)}, // Should be 3rd
+			{Price: types.NewDecimal(120.00), Quantity: types.NewDecimal(3.0)}, // Should be 4th (highest ask)
 		},
 	}
 
@@ -53,26 +53,26 @@ func testBasicSorting() {
 
 	// Validate bid sorting (descending)
 	bidPrice, bidQty, exists := ob.GetBestBid()
-	if !exists || bidPrice != 105.00 || bidQty != 2.0 {
+	if !exists || !bidPrice.Equal(types.NewDecimal(105.00)) || !bidQty.Equal(types.NewDecimal(2.0)) {
 		log.Fatalf("❌ Best bid incorrect: got %.2f@%.2f, expected 105.00@2.0", bidPrice, bidQty)
 	}
 
 	// Validate ask sorting (ascending)
 	askPrice, askQty, exists := ob.GetBestAsk()
-	if !exists || askPrice != 108.00 || askQty != 2.0 {
+	if !exists || !askPrice.Equal(types.NewDecimal(108.00)) || !askQty.Equal(types.NewDecimal(2.0)) {
 		log.Fatalf("❌ Best ask incorrect: got %.2f@%.2f, expected 108.00@2.0", askPrice, askQty)
 	}
 
 	// Validate spread
 	spread, exists := ob.GetSpread()
-	if !exists || spread != 3.00 {
+	if !exists || !spread.Equal(types.NewDecimal(3.00)) {
 		log.Fatalf("❌ Spread incorrect: got %.2f, expected 3.00", spread)
 	}
 
 	// Validate mid price
 	midPrice, exists := ob.GetMidPrice()
-	expectedMid := (105.00 + 108.00) / 2.0
-	if !exists || midPrice != expectedMid {
+	expectedMid := types.NewDecimal((105.00 + 108.00) / 2.0)
+	if !exists || !midPrice.Equal(expectedMid) {
 		log.Fatalf("❌ Mid price incorrect: got %.2f, expected %.2f", midPrice, expectedMid)
 	}
 
@@ -91,14 +91,14 @@ func testMarketOrderExecution() {
 		Symbol:    "TESTCOIN",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 100.00, Quantity: 1.0},
-			{Price: 99.50, Quantity: 2.0},
-			{Price: 99.00, Quantity: 1.5},
+			{Price: types.NewDecimal(100.00), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(99.50), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(99.00), Quantity: types.NewDecimal(1.5)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 101.00, Quantity: 1.0},
-			{Price: 101.50, Quantity: 2.0},
-			{Price: 102.00, Quantity: 1.5},
+			{Price: types.NewDecimal(101.00), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(101.50), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(102.00), Quantity: types.NewDecimal(1.5)},
 		},
 	}
 
@@ -107,7 +107,7 @@ func testMarketOrderExecution() {
 	// Test market buy order (buying 2.5 units against asks)
 	// Should fill: 1.0 @ 101.00 + 1.5 @ 101.50 = total 2.5 units
 	// Expected average price: (1.0*101.00 + 1.5*101.50) / 2.5 = 253.25 / 2.5 = 101.30
-	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, 2.5)
+	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, types.NewDecimal(2.5))
 	expectedPrice := (1.0*101.00 + 1.5*101.50) / 2.5
 
 	if !canFill {
@@ -121,7 +121,7 @@ func testMarketOrderExecution() {
 	// Test market sell order (selling 2.5 units against bids)
 	// Should fill: 1.0 @ 100.00 + 1.5 @ 99.50 = total 2.5 units
 	// Expected average price: (1.0*100.00 + 1.5*99.50) / 2.5 = 249.25 / 2.5 = 99.70
-	fillPrice, canFill = ob.GetFillPrice(types.SideSell, 2.5)
+	fillPrice, canFill = ob.GetFillPrice(types.SideSell, types.NewDecimal(2.5))
 	expectedPrice = (1.0*100.00 + 1.5*99.50) / 2.5
 
 	if !canFill {
@@ -135,7 +135,7 @@ func testMarketOrderExecution() {
 	fmt.Printf("   ✅ Market Buy 2.5 units: %.2f (expected %.2f)\n", fillPrice, expectedPrice)
 
 	// Test re-calculate for sell
-	fillPrice, _ = ob.GetFillPrice(types.SideSell, 2.5)
+	fillPrice, _ = ob.GetFillPrice(types.SideSell, types.NewDecimal(2.5))
 	expectedPrice = (1.0*100.00 + 1.5*99.50) / 2.5
 	fmt.Printf("   ✅ Market Sell 2.5 units: %.2f (expected %.2f)\n", fillPrice, expectedPrice)
 }
@@ -149,37 +149,37 @@ func testLimitOrderValidation() {
 		Symbol:    "TESTCOIN",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 100.00, Quantity: 1.0},
-			{Price: 99.50, Quantity: 2.0},
+			{Price: types.NewDecimal(100.00), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(99.50), Quantity: types.NewDecimal(2.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 101.00, Quantity: 1.0},
-			{Price: 101.50, Quantity: 2.0},
+			{Price: types.NewDecimal(101.00), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(101.50), Quantity: types.NewDecimal(2.0)},
 		},
 	}
 
 	ob.Update(snapshot)
 
 	// Test limit buy at 101.00 (should fill against ask)
-	canFill := ob.CanFill(types.SideBuy, 101.00, 1.0)
+	canFill := ob.CanFill(types.SideBuy, types.NewDecimal(101.00), types.NewDecimal(1.0))
 	if !canFill {
 		log.Fatalf("❌ Limit buy at 101.00 should be fillable")
 	}
 
 	// Test limit buy at 100.50 (should NOT fill - price too low)
-	canFill = ob.CanFill(types.SideBuy, 100.50, 1.0)
+	canFill = ob.CanFill(types.SideBuy, types.NewDecimal(100.50), types.NewDecimal(1.0))
 	if canFill {
 		log.Fatalf("❌ Limit buy at 100.50 should NOT be fillable")
 	}
 
 	// Test limit sell at 100.00 (should fill against bid)
-	canFill = ob.CanFill(types.SideSell, 100.00, 1.0)
+	canFill = ob.CanFill(types.SideSell, types.NewDecimal(100.00), types.NewDecimal(1.0))
 	if !canFill {
 		log.Fatalf("❌ Limit sell at 100.00 should be fillable")
 	}
 
 	// Test limit sell at 101.50 (should NOT fill - price too high)
-	canFill = ob.CanFill(types.SideSell, 101.50, 1.0)
+	canFill = ob.CanFill(types.SideSell, types.NewDecimal(101.50), types.NewDecimal(1.0))
 	if canFill {
 		log.Fatalf("❌ Limit sell at 101.50 should NOT be fillable")
 	}
@@ -216,22 +216,22 @@ func testEdgeCases() {
 		Symbol:    "TESTCOIN",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 100.00, Quantity: 1.0},
+			{Price: types.NewDecimal(100.00), Quantity: types.NewDecimal(1.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 101.00, Quantity: 1.0},
+			{Price: types.NewDecimal(101.00), Quantity: types.NewDecimal(1.0)},
 		},
 	}
 
 	ob.Update(snapshot)
 
 	// Try to fill more than available
-	_, canFill := ob.GetFillPrice(types.SideBuy, 2.0)
+	_, canFill := ob.GetFillPrice(types.SideBuy, types.NewDecimal(2.0))
 	if canFill {
 		log.Fatalf("❌ Should not be able to fill 2.0 when only 1.0 available")
 	}
 
-	_, canFill = ob.GetFillPrice(types.SideSell, 2.0)
+	_, canFill = ob.GetFillPrice(types.SideSell, types.NewDecimal(2.0))
 	if canFill {
 		log.Fatalf("❌ Should not be able to fill 2.0 when only 1.0 available")
 	}
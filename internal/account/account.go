@@ -0,0 +1,136 @@
+package account
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"trading-engine/internal/types"
+)
+
+// quoteCurrencies are the currencies a symbol can be quoted in, longest
+// first so that "USDT" is matched before "USD"
+var quoteCurrencies = []string{"USDT", "USDC", "USD", "EUR", "GBP", "BTC", "ETH"}
+
+// SplitSymbol returns the base and quote currency of a symbol such as
+// "BTCUSD", or false when the symbol does not end in a known quote currency
+func SplitSymbol(symbol string) (base, quote string, ok bool) {
+	for _, currency := range quoteCurrencies {
+		if len(symbol) > len(currency) && strings.HasSuffix(symbol, currency) {
+			return strings.TrimSuffix(symbol, currency), currency, true
+		}
+	}
+	return "", "", false
+}
+
+// ParseBalances parses balances written as "USD=100000,BTC=2"
+func ParseBalances(s string) (map[string]types.Decimal, error) {
+	balances := make(map[string]types.Decimal)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		currency, amount, found := strings.Cut(entry, "=")
+		if !found || currency == "" {
+			return nil, fmt.Errorf("balance %q is not CURRENCY=AMOUNT", entry)
+		}
+		value, err := types.ParseDecimal(amount)
+		if err != nil {
+			return nil, fmt.Errorf("balance %q: %w", entry, err)
+		}
+		if value.IsNegative() {
+			return nil, fmt.Errorf("balance %q is negative", entry)
+		}
+		balances[strings.ToUpper(currency)] = value
+	}
+	return balances, nil
+}
+
+// Balance is the amount held in one currency
+type Balance struct {
+	Currency string
+	Amount   types.Decimal
+}
+
+// String formats the balance as "1.5 BTC"
+func (b Balance) String() string {
+	return b.Amount.String() + " " + b.Currency
+}
+
+// Account holds cash balances per currency. Fills debit and credit the base
+// and quote currency of their symbol.
+type Account struct {
+	mu       sync.Mutex
+	balances map[string]types.Decimal
+}
+
+// New creates an account with starting balances
+func New(balances map[string]types.Decimal) *Account {
+	a := &Account{balances: make(map[string]types.Decimal)}
+	for currency, amount := range balances {
+		a.balances[currency] = amount
+	}
+	return a
+}
+
+// Balance returns the amount held in a currency
+func (a *Account) Balance(currency string) types.Decimal {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.balances[currency]
+}
+
+// Balances returns every balance sorted by currency
+func (a *Account) Balances() []Balance {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	balances := make([]Balance, 0, len(a.balances))
+	for currency, amount := range a.balances {
+		balances = append(balances, Balance{Currency: currency, Amount: amount})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Currency < balances[j].Currency })
+	return balances
+}
+
+// Required returns the currency and amount an order spends: the quote
+// notional at price for a buy and the base quantity for a sell
+func Required(symbol string, side types.Side, quantity, price types.Decimal) (string, types.Decimal, error) {
+	base, quote, ok := SplitSymbol(symbol)
+	if !ok {
+		return "", types.Zero, fmt.Errorf("cannot derive currencies of symbol %s", symbol)
+	}
+	if side == types.SideBuy {
+		return quote, quantity.Mul(price), nil
+	}
+	return base, quantity, nil
+}
+
+// Apply debits and credits the fills of an execution report and charges
+// its fee to the quote currency. Reports without fills leave the balances
+// unchanged.
+func (a *Account) Apply(execution types.Execution) error {
+	if !execution.Quantity.IsPositive() {
+		return nil
+	}
+	base, quote, ok := SplitSymbol(execution.Symbol)
+	if !ok {
+		return fmt.Errorf("cannot derive currencies of symbol %s", execution.Symbol)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	notional := execution.Notional()
+	if execution.Side == types.SideBuy {
+		a.balances[base] = a.balances[base].Add(execution.Quantity)
+		a.balances[quote] = a.balances[quote].Sub(notional)
+	} else {
+		a.balances[base] = a.balances[base].Sub(execution.Quantity)
+		a.balances[quote] = a.balances[quote].Add(notional)
+	}
+	a.balances[quote] = a.balances[quote].Sub(execution.Fee)
+	return nil
+}
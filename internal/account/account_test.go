@@ -0,0 +1,71 @@
+package account
+
+import (
+	"testing"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSymbol(t *testing.T) {
+	tests := map[string][2]string{
+		"BTCUSD":  {"BTC", "USD"},
+		"ETHUSDT": {"ETH", "USDT"},
+		"ADAUSD":  {"ADA", "USD"},
+		"ETHBTC":  {"ETH", "BTC"},
+	}
+	for symbol, expected := range tests {
+		base, quote, ok := SplitSymbol(symbol)
+		require.True(t, ok, symbol)
+		assert.Equal(t, expected, [2]string{base, quote}, symbol)
+	}
+
+	for _, symbol := range []string{"USD", "XYZ", ""} {
+		_, _, ok := SplitSymbol(symbol)
+		assert.False(t, ok, symbol)
+	}
+}
+
+func TestParseBalances(t *testing.T) {
+	balances, err := ParseBalances("USD=100000, btc=1.5")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.Decimal{"USD": types.NewDecimal(100000), "BTC": types.NewDecimal(1.5)}, balances)
+
+	balances, err = ParseBalances("")
+	require.NoError(t, err)
+	assert.Empty(t, balances)
+
+	for _, input := range []string{"USD", "=5", "USD=abc", "USD=-1"} {
+		_, err := ParseBalances(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestApplyDebitsAndCredits(t *testing.T) {
+	acct := New(map[string]types.Decimal{"USD": types.NewDecimal(100000)})
+
+	require.NoError(t, acct.Apply(types.Execution{Symbol: "BTCUSD", Side: types.SideBuy,
+		Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.5)}))
+	assert.Equal(t, types.NewDecimal(25000), acct.Balance("USD"))
+	assert.Equal(t, types.NewDecimal(1.5), acct.Balance("BTC"))
+
+	require.NoError(t, acct.Apply(types.Execution{Symbol: "BTCUSD", Side: types.SideSell,
+		Price: types.NewDecimal(51000), Quantity: types.NewDecimal(0.5)}))
+	assert.Equal(t, types.NewDecimal(50500), acct.Balance("USD"))
+	assert.Equal(t, types.NewDecimal(1), acct.Balance("BTC"))
+
+	// Reports without fills change nothing
+	require.NoError(t, acct.Apply(types.Execution{Symbol: "BTCUSD", Side: types.SideBuy, Status: types.StatusRejected}))
+	assert.Equal(t, []Balance{{"BTC", types.NewDecimal(1)}, {"USD", types.NewDecimal(50500)}}, acct.Balances())
+
+	assert.Error(t, acct.Apply(types.Execution{Symbol: "XYZ", Side: types.SideBuy, Price: types.NewDecimal(1), Quantity: types.NewDecimal(1)}))
+}
+
+func TestApplyChargesFeeToQuote(t *testing.T) {
+	acct := New(map[string]types.Decimal{"USD": types.NewDecimal(1000)})
+	require.NoError(t, acct.Apply(types.Execution{Symbol: "ADAUSD", Side: types.SideBuy,
+		Price: types.NewDecimal(0.5), Quantity: types.NewDecimal(1000), Fee: types.NewDecimal(0.25)}))
+	assert.Equal(t, types.NewDecimal(499.75), acct.Balance("USD"))
+	assert.Equal(t, types.NewDecimal(1000), acct.Balance("ADA"))
+}
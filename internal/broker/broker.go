@@ -1,100 +1,743 @@
 package broker
 
 import (
+	"fmt"
 	"log"
+	"math/rand"
 	"time"
+	"trading-engine/internal/account"
+	"trading-engine/internal/fees"
 	"trading-engine/internal/orderbook"
 	"trading-engine/internal/types"
 )
 
 // Broker handles order execution and matching
 type Broker struct {
-	orderbook  *orderbook.OrderBook
-	signals    <-chan types.TradeSignal
-	executions chan<- types.Execution
+	books         *orderbook.Registry
+	signals       <-chan types.TradeSignal
+	bookUpdates   <-chan types.OrderBookSnapshot
+	executions    chan<- types.Execution
+	instruments   map[string]types.Instrument
+	impact        ImpactModel
+	execution     ExecutionModel
+	limitFallback bool
+	rng           *rand.Rand         // latency samples, seeded by the execution model
+	pending       []pendingSignal    // requests waiting out their latency, in arrival order
+	latencySkips  map[string]bool    // reasons requests were acted on without latency
+	account       *account.Account   // nil when orders are not limited by balances
+	fees          fees.Schedule      // charges every fill
+	working       []*workingOrder    // resting limit orders in arrival order
+	nextID        int                // counter for broker-assigned order IDs
+	orderIDs      map[string]bool    // every order ID used in the session
+	ocoWinners    map[string]string  // OCO group -> order ID that filled completely first
+	activations   []activation       // bracket fills waiting for their exits
+	reductions    []*types.Execution // OCO siblings resized by partial fills
+
+	// matching is a copy of the book an update is matched against when
+	// market impact is disabled, so the orders it fills share its liquidity
+	matching *orderbook.OrderBook
+}
+
+// workingOrder is the unfilled remainder of a limit order waiting for the
+// market to cross its price, or a conditional order waiting for its trigger
+type workingOrder struct {
+	signal    types.TradeSignal
+	remaining types.Decimal
+	stopPrice types.Decimal // current trigger level of a conditional order
+	mark      types.Decimal // best touch price seen by a trailing stop
+}
+
+// ImpactModel controls whether simulated executions remove liquidity from the book
+type ImpactModel struct {
+	Enabled bool
+	// HalfLife of the replenishment. Zero restores consumed liquidity with
+	// the next feed snapshot; a positive value keeps it depleted across
+	// snapshots, recovering exponentially on the feed clock.
+	HalfLife time.Duration
 }
 
 // New creates a new broker instance
-func New(ob *orderbook.OrderBook, signals <-chan types.TradeSignal, executions chan<- types.Execution) *Broker {
+func New(books *orderbook.Registry, signals <-chan types.TradeSignal, executions chan<- types.Execution) *Broker {
 	return &Broker{
-		orderbook:  ob,
-		signals:    signals,
-		executions: executions,
+		books:        books,
+		signals:      signals,
+		executions:   executions,
+		instruments:  make(map[string]types.Instrument),
+		fees:         fees.None{},
+		ocoWinners:   make(map[string]string),
+		orderIDs:     make(map[string]bool),
+		latencySkips: make(map[string]bool),
 	}
 }
 
-// Start begins processing trade signals
+// SetInstrument registers tick and lot size metadata for a symbol.
+// Orders for symbols without an instrument are not checked against a grid.
+func (b *Broker) SetInstrument(instrument types.Instrument) {
+	b.instruments[instrument.Symbol] = instrument
+}
+
+// SetBookUpdates connects the broker to the book updates published by the
+// engine. Resting limit orders are matched against the book after each update.
+func (b *Broker) SetBookUpdates(updates <-chan types.OrderBookSnapshot) {
+	b.bookUpdates = updates
+}
+
+// SetLimitFallback enables the legacy simulation mode in which a limit order
+// that cannot fill at its price executes at the best available price instead
+// of resting. Fills in this mode can be worse than the limit.
+func (b *Broker) SetLimitFallback(enabled bool) {
+	b.limitFallback = enabled
+}
+
+// Start begins processing trade signals and book updates
 func (b *Broker) Start() {
 	log.Println("Broker started")
 
-	for signal := range b.signals {
-		log.Printf("Broker received signal: %+v", signal)
+	signals := b.signals
+	updates := b.bookUpdates
+	for signals != nil {
+		select {
+		case signal, ok := <-signals:
+			if !ok {
+				signals = nil
+				continue
+			}
+			log.Printf("Broker received signal: %+v", signal)
+			if b.delay(signal, updates != nil) {
+				continue
+			}
+			b.process(signal)
 
-		execution := b.executeOrder(signal)
-		if execution != nil {
-			select {
-			case b.executions <- *execution:
-				log.Printf("Execution sent: %+v", *execution)
-			default:
-				log.Printf("Failed to send execution - channel full")
+		case snapshot, ok := <-updates:
+			if !ok {
+				// The feed clock has stopped, so queued requests arrive now
+				updates = nil
+				for _, signal := range b.arrived("", true) {
+					b.process(signal)
+				}
+				continue
+			}
+			for _, signal := range b.arrived(snapshot.Symbol, false) {
+				b.process(signal)
+			}
+			for _, execution := range b.matchWorking(snapshot.Symbol) {
+				b.send(execution)
+			}
+			for _, execution := range b.followUps() {
+				b.send(execution)
 			}
 		}
 	}
+	for _, signal := range b.arrived("", true) {
+		b.process(signal)
+	}
+
+	// Orders still working when the session ends expire
+	for _, order := range b.working {
+		log.Printf("Working order %s expired at shutdown: %s %s %.4f @ %.2f", order.signal.OrderID,
+			order.signal.Symbol, order.signal.Side, order.remaining, order.signal.Price)
+		b.send(&types.Execution{
+			OrderID:   order.signal.OrderID,
+			Status:    types.StatusExpired,
+			Reason:    "session ended",
+			Symbol:    order.signal.Symbol,
+			Side:      order.signal.Side,
+			Timestamp: b.now(order.signal.Symbol),
+			Cancelled: order.remaining,
+		})
+	}
+	b.working = nil
 
 	log.Println("Broker finished")
 	close(b.executions)
 }
 
-// executeOrder attempts to execute a trade signal
+// process acts on a request that has reached the broker and publishes the
+// reports it causes
+func (b *Broker) process(signal types.TradeSignal) {
+	b.send(b.handleSignal(signal))
+	for _, execution := range b.followUps() {
+		b.send(execution)
+	}
+}
+
+// send books an execution and publishes it. The executions channel is read
+// until the broker closes it, so send waits for room rather than drop a
+// report the account has already booked.
+func (b *Broker) send(execution *types.Execution) {
+	if execution == nil {
+		return
+	}
+	b.book(*execution)
+	b.executions <- *execution
+	log.Printf("Execution sent: %+v", *execution)
+}
+
+// handleSignal dispatches a request from the strategy and returns the
+// execution report answering it
+func (b *Broker) handleSignal(signal types.TradeSignal) *types.Execution {
+	switch signal.Action {
+	case "", types.ActionNew:
+		if signal.OrderID != "" && !b.useOrderID(signal.OrderID) {
+			return b.reject(signal, fmt.Sprintf("duplicate order ID %s", signal.OrderID))
+		}
+		return b.executeOrder(signal)
+	case types.ActionCancel:
+		return b.cancelOrder(signal)
+	case types.ActionReplace:
+		return b.replaceOrder(signal)
+	default:
+		return b.rejectRequest(signal, fmt.Sprintf("unknown action %q", signal.Action))
+	}
+}
+
+// useOrderID records an order ID for the session. It returns false if the
+// ID was used before, even by an order that has since finished, so reports
+// for two orders are never merged under one ID.
+func (b *Broker) useOrderID(id string) bool {
+	if b.orderIDs[id] {
+		return false
+	}
+	b.orderIDs[id] = true
+	return true
+}
+
+// executeOrder attempts to execute a new order and reports its state
 func (b *Broker) executeOrder(signal types.TradeSignal) *types.Execution {
-	// Determine execution price
-	var execPrice float64
-	var canFill bool
-
-	if signal.Price == 0 {
-		// Market order
-		execPrice, canFill = b.orderbook.GetFillPrice(signal.Side, signal.Quantity)
-		if !canFill {
-			log.Printf("Market order cannot be filled: insufficient liquidity")
-			return nil
-		}
-	} else {
-		// Limit order
-		execPrice = signal.Price
-		canFill = b.orderbook.CanFill(signal.Side, signal.Price, signal.Quantity)
-		if !canFill {
-			log.Printf("Limit order cannot be filled at %.2f", signal.Price)
-
-			// For simulation purposes, we'll still execute at best available price
-			if bestPrice, canFillAtBest := b.orderbook.GetFillPrice(signal.Side, signal.Quantity); canFillAtBest {
-				log.Printf("Executing at best available price: %.2f", bestPrice)
-				execPrice = bestPrice
-				canFill = true
-			} else {
-				return nil
-			}
+	signal = withDefaults(signal)
+	for signal.OrderID == "" {
+		b.nextID++
+		if id := fmt.Sprintf("B%d", b.nextID); b.useOrderID(id) {
+			signal.OrderID = id
 		}
 	}
+	if b.isLoser(signal) {
+		return b.reject(signal, fmt.Sprintf("OCO group %s already executed by %s",
+			signal.OCOGroup, b.ocoWinners[signal.OCOGroup]))
+	}
 
-	if !canFill {
-		log.Printf("Order cannot be executed: no liquidity")
-		return nil
+	ob, err := b.checkOrder(signal)
+	if err != nil {
+		return b.reject(signal, err.Error())
+	}
+
+	if signal.TimeInForce == types.TimeInForceGTD && !ob.LastUpdated().Before(signal.ExpireTime) {
+		return b.reject(signal, fmt.Sprintf("expire time %s has already passed",
+			signal.ExpireTime.Format("15:04:05.000")))
 	}
 
-	// Create execution
+	if signal.Type.IsConditional() {
+		return b.holdStop(ob, signal)
+	}
+
+	// Post-only orders must add liquidity
+	if signal.PostOnly && len(ob.GetFills(signal.Side, signal.Quantity, signal.Price)) > 0 {
+		return b.reject(signal, fmt.Sprintf("post-only order at %s would cross the book", signal.Price))
+	}
+
+	// Fill-or-kill trades only if the whole quantity is available now
+	if signal.TimeInForce == types.TimeInForceFOK && !canFillInFull(ob, signal) {
+		log.Printf("FOK order %s killed: cannot fill %.4f in full", signal.OrderID, signal.Quantity)
+		return &types.Execution{
+			OrderID:   signal.OrderID,
+			Status:    types.StatusCancelled,
+			Reason:    "FOK order cannot be filled in full",
+			Symbol:    signal.Symbol,
+			Side:      signal.Side,
+			Timestamp: b.now(signal.Symbol),
+			Cancelled: signal.Quantity,
+		}
+	}
+
+	// Market orders walk the whole book; limit orders stop at their price
+	walkLimit := types.Zero
+	if signal.Type == types.OrderTypeLimit {
+		walkLimit = signal.Price
+	}
+	if b.limitFallback && signal.Type == types.OrderTypeLimit && !ob.CanFill(signal.Side, signal.Price, signal.Quantity) {
+		log.Printf("Limit order cannot be filled at %.2f", signal.Price)
+
+		// Opt-in simulation mode: execute at best available price
+		log.Printf("Limit fallback enabled, executing at best available price")
+		walkLimit = types.Zero
+	}
+
+	fills := b.slip(ob, signal, b.match(ob, signal.Side, signal.Quantity, walkLimit))
+	execPrice, filled := averagePrice(fills)
+	if filled.IsPositive() {
+		b.filled(signal, filled, filled.Equal(signal.Quantity))
+	}
+
+	// GTC and GTD limit orders leave the unfilled quantity working until the
+	// market crosses the limit; everything else cancels it
+	rests := !walkLimit.IsZero() &&
+		(signal.TimeInForce == types.TimeInForceGTC || signal.TimeInForce == types.TimeInForceGTD)
+	var cancelled, leaves types.Decimal
+	cancelReason := "insufficient liquidity"
+	if signal.TimeInForce == types.TimeInForceIOC && signal.Type == types.OrderTypeLimit {
+		cancelReason = "unfilled IOC quantity cancelled"
+	}
+	if unfilled := signal.Quantity.Sub(filled); unfilled.IsPositive() {
+		switch {
+		case rests:
+			leaves = unfilled
+			b.working = append(b.working, &workingOrder{signal: signal, remaining: leaves})
+			log.Printf("Limit order %s resting: %s %.4f @ %.2f (%s)",
+				signal.OrderID, signal.Side, leaves, signal.Price, signal.TimeInForce)
+		case filled.IsPositive():
+			cancelled = unfilled
+			log.Printf("Partial fill: %.4f of %.4f filled, %.4f cancelled",
+				filled, signal.Quantity, cancelled)
+		default:
+			cancelled = unfilled
+			log.Printf("Order %s cannot be executed: %s", signal.OrderID, cancelReason)
+		}
+	}
+
+	// Create execution report
 	execution := &types.Execution{
+		OrderID:   signal.OrderID,
 		Symbol:    signal.Symbol,
 		Side:      signal.Side,
 		Price:     execPrice,
-		Quantity:  signal.Quantity,
-		Timestamp: time.Now(),
+		Quantity:  filled,
+		Timestamp: b.now(signal.Symbol),
+		Fills:     fills,
+		Cancelled: cancelled,
+		Leaves:    leaves,
+	}
+
+	switch {
+	case leaves.IsPositive() && filled.IsZero():
+		execution.Status = types.StatusAcked
+	case leaves.IsPositive():
+		execution.Status = types.StatusPartiallyFilled
+	case cancelled.IsPositive():
+		execution.Status = types.StatusCancelled
+		execution.Reason = cancelReason
+	default:
+		execution.Status = types.StatusFilled
+	}
+
+	if filled.IsZero() {
+		return execution
+	}
+	b.charge(execution, types.LiquidityTaker)
+
+	for i, fill := range fills {
+		log.Printf("  Level %d: %.4f @ %.2f", i+1, fill.Quantity, fill.Price)
+	}
+	log.Printf("Order %s executed: %s %.2f @ %.2f, fee %.2f", execution.OrderID,
+		string(execution.Side), execution.Quantity, execution.Price, execution.Fee)
+
+	return execution
+}
+
+// cancelOrder removes a working order and reports the unfilled quantity as cancelled
+func (b *Broker) cancelOrder(signal types.TradeSignal) *types.Execution {
+	order := b.removeWorking(signal.OrderID)
+	if order == nil {
+		return b.rejectRequest(signal, fmt.Sprintf("order %s is not working", signal.OrderID))
+	}
+
+	log.Printf("Order %s cancelled: %.4f unfilled", order.signal.OrderID, order.remaining)
+	return &types.Execution{
+		OrderID:   order.signal.OrderID,
+		Status:    types.StatusCancelled,
+		Reason:    "cancelled by request",
+		Symbol:    order.signal.Symbol,
+		Side:      order.signal.Side,
+		Timestamp: b.now(order.signal.Symbol),
+		Cancelled: order.remaining,
 	}
+}
+
+// replaceOrder amends the price and/or remaining quantity of a working order.
+// The amended order loses its place and is matched again as if it were new,
+// and its report is marked Replaced. A rejected amend leaves the original
+// order working in its place.
+func (b *Broker) replaceOrder(signal types.TradeSignal) *types.Execution {
+	index := -1
+	for i, order := range b.working {
+		if order.signal.OrderID == signal.OrderID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return b.rejectRequest(signal, fmt.Sprintf("order %s is not working", signal.OrderID))
+	}
+	order := b.working[index]
+
+	replacement := order.signal
+	replacement.Action = types.ActionNew
+	replacement.Timestamp = signal.Timestamp
+	replacement.Quantity = order.remaining
+	if !signal.Price.IsZero() {
+		replacement.Price = signal.Price
+	}
+	if !signal.Quantity.IsZero() {
+		replacement.Quantity = signal.Quantity
+	}
+	if !signal.StopPrice.IsZero() {
+		replacement.StopPrice = signal.StopPrice
+	}
+
+	b.working = append(b.working[:index], b.working[index+1:]...)
+	execution := b.executeOrder(replacement)
+	if execution.Status == types.StatusRejected {
+		// Put the original back in its place; it keeps working unchanged
+		b.working = append(b.working, nil)
+		copy(b.working[index+1:], b.working[index:])
+		b.working[index] = order
+		execution.Status = types.StatusRequestRejected
+		execution.Side = order.signal.Side
+		return execution
+	}
+	execution.Replaced = true
 
-	log.Printf("Order executed: %s %.2f @ %.2f",
-		string(execution.Side), execution.Quantity, execution.Price)
+	log.Printf("Order %s replaced: %.4f @ %.2f -> %.4f @ %.2f", order.signal.OrderID,
+		order.remaining, order.signal.Price, replacement.Quantity, replacement.Price)
+	return execution
+}
 
-	// In a real system, we would update the order book by removing the filled quantities
-	// For this simulation, we'll leave the order book unchanged
+// reject logs and reports a request the broker refused
+func (b *Broker) reject(signal types.TradeSignal, reason string) *types.Execution {
+	log.Printf("Order rejected: %s", reason)
+	return &types.Execution{
+		OrderID:   signal.OrderID,
+		Status:    types.StatusRejected,
+		Reason:    reason,
+		Symbol:    signal.Symbol,
+		Side:      signal.Side,
+		Timestamp: b.now(signal.Symbol),
+	}
+}
 
+// rejectRequest logs and reports a cancel or replace the broker refused. The
+// report does not change the state of the order it names.
+func (b *Broker) rejectRequest(signal types.TradeSignal, reason string) *types.Execution {
+	execution := b.reject(signal, reason)
+	execution.Status = types.StatusRequestRejected
 	return execution
 }
+
+// checkOrder returns the book an order would trade against, or an error if
+// the order cannot be accepted
+func (b *Broker) checkOrder(signal types.TradeSignal) (*orderbook.OrderBook, error) {
+	ob, exists := b.orderBook(signal.Symbol)
+	if !exists {
+		return nil, fmt.Errorf("no order book for symbol %s", signal.Symbol)
+	}
+	if ob.IsStale() {
+		return nil, fmt.Errorf("order book %s is stale, waiting for resync", signal.Symbol)
+	}
+	if err := checkTerms(signal); err != nil {
+		return nil, err
+	}
+	if err := b.validateOrder(signal); err != nil {
+		return nil, err
+	}
+	if err := b.checkBuyingPower(ob, signal); err != nil {
+		return nil, err
+	}
+	return ob, nil
+}
+
+// withDefaults fills in the order type and time in force a signal leaves empty
+func withDefaults(signal types.TradeSignal) types.TradeSignal {
+	if signal.Type == "" {
+		signal.Type = types.OrderTypeLimit
+		if signal.Price.IsZero() {
+			signal.Type = types.OrderTypeMarket
+		}
+	}
+	if signal.TimeInForce == "" {
+		signal.TimeInForce = types.TimeInForceGTC
+		if signal.Type == types.OrderTypeMarket {
+			signal.TimeInForce = types.TimeInForceIOC
+		}
+	}
+	return signal
+}
+
+// checkTerms returns an error if the order type, time in force and flags
+// of an order do not make sense together
+func checkTerms(signal types.TradeSignal) error {
+	switch signal.Type {
+	case types.OrderTypeMarket:
+		if !signal.Price.IsZero() {
+			return fmt.Errorf("market order must not have a price")
+		}
+		if signal.TimeInForce == types.TimeInForceGTC || signal.TimeInForce == types.TimeInForceGTD {
+			return fmt.Errorf("market order cannot be %s", signal.TimeInForce)
+		}
+	case types.OrderTypeLimit:
+		if signal.Price.IsZero() {
+			return fmt.Errorf("limit order requires a price")
+		}
+	case types.OrderTypeStop, types.OrderTypeStopLimit:
+		if !signal.StopPrice.IsPositive() {
+			return fmt.Errorf("%s order requires a stop price", signal.Type)
+		}
+		if signal.Type == types.OrderTypeStop && !signal.Price.IsZero() {
+			return fmt.Errorf("stop order must not have a limit price")
+		}
+		if signal.Type == types.OrderTypeStopLimit && signal.Price.IsZero() {
+			return fmt.Errorf("stop-limit order requires a limit price")
+		}
+	case types.OrderTypeTrailingStop:
+		if signal.TrailAmount.IsPositive() == signal.TrailPercent.IsPositive() {
+			return fmt.Errorf("trailing stop requires exactly one of trail amount or trail percent")
+		}
+		if signal.TrailAmount.IsNegative() || signal.TrailPercent.IsNegative() {
+			return fmt.Errorf("trailing stop trail must be positive")
+		}
+		if !signal.Price.IsZero() || !signal.StopPrice.IsZero() {
+			return fmt.Errorf("trailing stop must not have a limit or stop price")
+		}
+	default:
+		return fmt.Errorf("unknown order type %q", signal.Type)
+	}
+
+	switch signal.TimeInForce {
+	case types.TimeInForceGTC, types.TimeInForceIOC, types.TimeInForceFOK:
+	case types.TimeInForceGTD:
+		if signal.ExpireTime.IsZero() {
+			return fmt.Errorf("GTD order requires an expire time")
+		}
+	default:
+		return fmt.Errorf("unknown time in force %q", signal.TimeInForce)
+	}
+
+	if signal.Type.IsConditional() && signal.TimeInForce != types.TimeInForceGTC && signal.TimeInForce != types.TimeInForceGTD {
+		return fmt.Errorf("%s order cannot be %s", signal.Type, signal.TimeInForce)
+	}
+	if signal.TakeProfitPrice.IsNegative() || signal.StopLossPrice.IsNegative() {
+		return fmt.Errorf("bracket prices must be positive")
+	}
+	if !signal.TakeProfitPrice.IsZero() && !signal.StopLossPrice.IsZero() {
+		if signal.Side == types.SideBuy && !signal.StopLossPrice.LessThan(signal.TakeProfitPrice) ||
+			signal.Side == types.SideSell && !signal.StopLossPrice.GreaterThan(signal.TakeProfitPrice) {
+			return fmt.Errorf("bracket stop %s must be on the losing side of target %s",
+				signal.StopLossPrice, signal.TakeProfitPrice)
+		}
+	}
+	if signal.PostOnly && signal.Type != types.OrderTypeLimit {
+		return fmt.Errorf("post-only requires a limit order")
+	}
+	if signal.PostOnly && signal.TimeInForce != types.TimeInForceGTC && signal.TimeInForce != types.TimeInForceGTD {
+		return fmt.Errorf("post-only order cannot be %s", signal.TimeInForce)
+	}
+	return nil
+}
+
+// canFillInFull reports whether the book holds the whole order quantity
+// within the order's limit
+func canFillInFull(ob *orderbook.OrderBook, signal types.TradeSignal) bool {
+	if signal.Type == types.OrderTypeMarket {
+		_, ok := ob.GetFillPrice(signal.Side, signal.Quantity)
+		return ok
+	}
+	return ob.CanFill(signal.Side, signal.Price, signal.Quantity)
+}
+
+// findWorking returns the working order with a client order ID
+func (b *Broker) findWorking(id string) *workingOrder {
+	for _, order := range b.working {
+		if order.signal.OrderID == id {
+			return order
+		}
+	}
+	return nil
+}
+
+// removeWorking takes a working order out of the book, returning nil if
+// no order with the ID is working
+func (b *Broker) removeWorking(id string) *workingOrder {
+	for i, order := range b.working {
+		if order.signal.OrderID == id {
+			b.working = append(b.working[:i], b.working[i+1:]...)
+			return order
+		}
+	}
+	return nil
+}
+
+// matchWorking handles the working orders for a symbol after a book update:
+// GTD orders past their expire time expire, conditional orders whose stop
+// is reached are triggered, and resting limit orders the book crosses are
+// filled in arrival order. Complete orders are dropped.
+func (b *Broker) matchWorking(symbol string) []*types.Execution {
+	ob, exists := b.books.Get(symbol)
+	if !exists || ob.IsStale() || len(b.working) == 0 {
+		return nil
+	}
+
+	// Orders matched on this update share its displayed liquidity: without
+	// market impact they take it from a copy of the book that the next
+	// update replaces
+	if !b.impact.Enabled {
+		b.matching = orderbook.New()
+		b.matching.Update(ob.Snapshot())
+		defer func() { b.matching = nil }()
+	}
+
+	executions := b.expireWorking(symbol, ob.LastUpdated())
+	// Stop-limit remainders released by this update have already been
+	// matched against it
+	resting := make(map[*workingOrder]bool, len(b.working))
+	for _, order := range b.working {
+		resting[order] = true
+	}
+	executions = append(executions, b.triggerStops(ob, symbol)...)
+
+	active := b.working[:0]
+	for _, order := range b.working {
+		if order.signal.Symbol == symbol && resting[order] && !order.signal.Type.IsConditional() && !b.isLoser(order.signal) {
+			fills := b.match(b.matchBook(ob), order.signal.Side, order.remaining, order.signal.Price)
+			if execPrice, filled := averagePrice(fills); filled.IsPositive() {
+				order.remaining = order.remaining.Sub(filled)
+				b.filled(order.signal, filled, !order.remaining.IsPositive())
+				status := types.StatusPartiallyFilled
+				if !order.remaining.IsPositive() {
+					status = types.StatusFilled
+				}
+				execution := &types.Execution{
+					OrderID:   order.signal.OrderID,
+					Status:    status,
+					Symbol:    order.signal.Symbol,
+					Side:      order.signal.Side,
+					Price:     execPrice,
+					Quantity:  filled,
+					Timestamp: b.now(order.signal.Symbol),
+					Fills:     fills,
+					Leaves:    order.remaining,
+				}
+				b.charge(execution, types.LiquidityMaker)
+				executions = append(executions, execution)
+				log.Printf("Working order %s filled: %s %.4f @ %.2f (limit %.2f, %.4f leaves), fee %.2f",
+					order.signal.OrderID, order.signal.Side, filled, execPrice, order.signal.Price, order.remaining, execution.Fee)
+			}
+		}
+		if order.remaining.IsPositive() {
+			active = append(active, order)
+		}
+	}
+	b.working = active
+
+	return executions
+}
+
+// expireWorking removes the GTD orders for a symbol whose expire time the
+// feed clock has reached and reports them as expired
+func (b *Broker) expireWorking(symbol string, now time.Time) []*types.Execution {
+	var executions []*types.Execution
+	active := b.working[:0]
+	for _, order := range b.working {
+		if order.signal.Symbol == symbol && order.signal.TimeInForce == types.TimeInForceGTD &&
+			!now.Before(order.signal.ExpireTime) {
+			log.Printf("Working order %s expired at %s", order.signal.OrderID, now.Format("15:04:05.000"))
+			executions = append(executions, &types.Execution{
+				OrderID:   order.signal.OrderID,
+				Status:    types.StatusExpired,
+				Reason:    "GTD expire time reached",
+				Symbol:    order.signal.Symbol,
+				Side:      order.signal.Side,
+				Timestamp: b.now(order.signal.Symbol),
+				Cancelled: order.remaining,
+			})
+			continue
+		}
+		active = append(active, order)
+	}
+	b.working = active
+
+	return executions
+}
+
+// orderBook returns the book orders for a symbol are checked and filled
+// against: the copy being matched by the current update, if any, or the
+// symbol's book
+func (b *Broker) orderBook(symbol string) (*orderbook.OrderBook, bool) {
+	ob, exists := b.books.Get(symbol)
+	if !exists {
+		return nil, false
+	}
+	return b.matchBook(ob), true
+}
+
+// matchBook returns the copy of ob being matched by the current update, or
+// ob itself
+func (b *Broker) matchBook(ob *orderbook.OrderBook) *orderbook.OrderBook {
+	if b.matching != nil && b.matching.Symbol() == ob.Symbol() {
+		return b.matching
+	}
+	return ob
+}
+
+// match returns the fills for an order against the book, consuming the
+// matched liquidity when market impact is enabled. Without market impact,
+// fills only deplete the copy of the book being matched by an update.
+func (b *Broker) match(ob *orderbook.OrderBook, side types.Side, quantity, limit types.Decimal) []types.Fill {
+	if ob == b.matching {
+		return ob.Consume(side, quantity, limit, 0)
+	}
+	if !b.impact.Enabled {
+		return ob.GetFills(side, quantity, limit)
+	}
+
+	// Remove the matched liquidity so later orders see the depleted book
+	fills := ob.Consume(side, quantity, limit, b.impact.HalfLife)
+	log.Printf("Market impact: consumed %d price levels", len(fills))
+	return fills
+}
+
+// SetImpactModel configures market impact simulation for subsequent orders
+func (b *Broker) SetImpactModel(model ImpactModel) {
+	b.impact = model
+}
+
+// validateOrder checks the order quantity and limit price against the
+// instrument's lot and tick sizes
+func (b *Broker) validateOrder(signal types.TradeSignal) error {
+	instrument, exists := b.instruments[signal.Symbol]
+	if !exists {
+		instrument = types.Instrument{Symbol: signal.Symbol}
+	}
+
+	if err := instrument.ValidateQuantity(signal.Quantity); err != nil {
+		return err
+	}
+	if !signal.Price.IsZero() {
+		if err := instrument.ValidatePrice(signal.Price); err != nil {
+			return err
+		}
+	}
+	if !signal.StopPrice.IsZero() {
+		if err := instrument.ValidatePrice(signal.StopPrice); err != nil {
+			return fmt.Errorf("stop: %v", err)
+		}
+	}
+	for _, price := range []types.Decimal{signal.TakeProfitPrice, signal.StopLossPrice} {
+		if !price.IsZero() {
+			if err := instrument.ValidatePrice(price); err != nil {
+				return fmt.Errorf("bracket: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// averagePrice returns the volume-weighted price and total quantity of fills
+func averagePrice(fills []types.Fill) (types.Decimal, types.Decimal) {
+	var cost, quantity types.Decimal
+	for _, fill := range fills {
+		cost = cost.Add(fill.Price.Mul(fill.Quantity))
+		quantity = quantity.Add(fill.Quantity)
+	}
+	if quantity.IsZero() {
+		return types.Zero, types.Zero
+	}
+	return cost.Div(quantity), quantity
+}
@@ -10,72 +10,86 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func setupTestOrderBook() *orderbook.OrderBook {
-	ob := orderbook.New()
+func setupTestBooks() *orderbook.Registry {
+	books := orderbook.NewRegistry()
+	ob := books.GetOrCreate("BTCUSD")
 
 	snapshot := types.OrderBookSnapshot{
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0},
-			{Price: 49950, Quantity: 2.0},
-			{Price: 49900, Quantity: 1.5},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(1.5)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
-			{Price: 50150, Quantity: 2.0},
-			{Price: 50200, Quantity: 1.5},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(50200), Quantity: types.NewDecimal(1.5)},
 		},
 	}
 
 	ob.Update(snapshot)
-	return ob
+	return books
+}
+
+// moveBook replaces the BTCUSD book with one level of 5 a side, updated at
+// a feed time
+func moveBook(books *orderbook.Registry, bid, ask float64, at time.Time) {
+	btc, _ := books.Get("BTCUSD")
+	btc.Update(types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: at,
+		Bids:      []types.OrderBookEntry{{Price: types.NewDecimal(bid), Quantity: types.NewDecimal(5.0)}},
+		Asks:      []types.OrderBookEntry{{Price: types.NewDecimal(ask), Quantity: types.NewDecimal(5.0)}},
+	})
 }
 
 func TestMarketBuyOrder(t *testing.T) {
-	ob := setupTestOrderBook()
+	books := setupTestBooks()
 
 	signals := make(chan types.TradeSignal, 1)
 	executions := make(chan types.Execution, 1)
 
-	broker := New(ob, signals, executions)
+	broker := New(books, signals, executions)
 
 	// Send market buy signal
 	signal := types.TradeSignal{
 		Symbol:    "BTCUSD",
 		Side:      types.SideBuy,
-		Price:     0, // Market order
-		Quantity:  1.5,
+		Price:     types.NewDecimal(0), // Market order
+		Quantity:  types.NewDecimal(1.5),
 		Timestamp: time.Now(),
 	}
 
 	execution := broker.executeOrder(signal)
 
 	require.NotNil(t, execution)
+	assert.Equal(t, types.StatusFilled, execution.Status)
 	assert.Equal(t, types.SideBuy, execution.Side)
-	assert.Equal(t, 1.5, execution.Quantity)
+	assert.Equal(t, types.NewDecimal(1.5), execution.Quantity)
 	assert.Equal(t, "BTCUSD", execution.Symbol)
 
 	// Should execute at weighted average of asks
 	// 1.0 @ 50100 + 0.5 @ 50150 = (50100 + 25075) / 1.5 = 50116.67
-	expectedPrice := (1.0*50100 + 0.5*50150) / 1.5
-	assert.InDelta(t, expectedPrice, execution.Price, 0.01)
+	// Decimal division rounds to 8 places
+	assert.Equal(t, types.NewDecimal(50116.66666667), execution.Price)
 }
 
 func TestMarketSellOrder(t *testing.T) {
-	ob := setupTestOrderBook()
+	books := setupTestBooks()
 
 	signals := make(chan types.TradeSignal, 1)
 	executions := make(chan types.Execution, 1)
 
-	broker := New(ob, signals, executions)
+	broker := New(books, signals, executions)
 
 	// Send market sell signal
 	signal := types.TradeSignal{
 		Symbol:    "BTCUSD",
 		Side:      types.SideSell,
-		Price:     0, // Market order
-		Quantity:  1.5,
+		Price:     types.NewDecimal(0), // Market order
+		Quantity:  types.NewDecimal(1.5),
 		Timestamp: time.Now(),
 	}
 
@@ -83,84 +97,797 @@ func TestMarketSellOrder(t *testing.T) {
 
 	require.NotNil(t, execution)
 	assert.Equal(t, types.SideSell, execution.Side)
-	assert.Equal(t, 1.5, execution.Quantity)
+	assert.Equal(t, types.NewDecimal(1.5), execution.Quantity)
 
 	// Should execute at weighted average of bids
 	// 1.0 @ 50000 + 0.5 @ 49950 = (50000 + 24975) / 1.5 = 49983.33
-	expectedPrice := (1.0*50000 + 0.5*49950) / 1.5
-	assert.InDelta(t, expectedPrice, execution.Price, 0.01)
+	assert.Equal(t, types.NewDecimal(49983.33333333), execution.Price)
 }
 
 func TestLimitBuyOrder(t *testing.T) {
-	ob := setupTestOrderBook()
+	books := setupTestBooks()
 
 	signals := make(chan types.TradeSignal, 1)
 	executions := make(chan types.Execution, 1)
 
-	broker := New(ob, signals, executions)
+	broker := New(books, signals, executions)
 
 	// Send limit buy signal at ask price
 	signal := types.TradeSignal{
 		Symbol:    "BTCUSD",
 		Side:      types.SideBuy,
-		Price:     50150, // Can fill against asks
-		Quantity:  1.0,
+		Price:     types.NewDecimal(50150), // Can fill against asks
+		Quantity:  types.NewDecimal(1.0),
 		Timestamp: time.Now(),
 	}
 
 	execution := broker.executeOrder(signal)
 
+	// A marketable limit order fills at the best ask, not at its limit
 	require.NotNil(t, execution)
+	assert.Equal(t, types.StatusFilled, execution.Status)
 	assert.Equal(t, types.SideBuy, execution.Side)
-	assert.Equal(t, 1.0, execution.Quantity)
-	// Note: In our implementation, if limit order can't be filled exactly,
-	// it falls back to market price for simulation purposes
+	assert.Equal(t, types.NewDecimal(1.0), execution.Quantity)
+	assert.Equal(t, types.NewDecimal(50100), execution.Price)
+	assert.Equal(t, []types.Fill{{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)}}, execution.Fills)
+	assert.True(t, execution.Leaves.IsZero())
+	assert.Empty(t, broker.working)
+
+	// Beyond the depth up to its limit the rest works at the limit
+	signal.OrderID = "L2"
+	signal.Quantity = types.NewDecimal(4.0)
+	execution = broker.executeOrder(signal)
+
+	assert.Equal(t, types.StatusPartiallyFilled, execution.Status)
+	assert.Equal(t, types.NewDecimal(3.0), execution.Quantity)
+	assert.Equal(t, []types.Fill{{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)}, {Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)}}, execution.Fills)
+	assert.Equal(t, types.NewDecimal(1.0), execution.Leaves)
+	assert.True(t, execution.Cancelled.IsZero())
+	require.Len(t, broker.working, 1)
+	assert.Equal(t, "L2", broker.working[0].signal.OrderID)
+	assert.Equal(t, types.NewDecimal(1.0), broker.working[0].remaining)
 }
 
 func TestInsufficientLiquidity(t *testing.T) {
-	ob := setupTestOrderBook()
+	books := setupTestBooks()
 
 	signals := make(chan types.TradeSignal, 1)
 	executions := make(chan types.Execution, 1)
 
-	broker := New(ob, signals, executions)
+	broker := New(books, signals, executions)
 
 	// Send order larger than available liquidity
 	signal := types.TradeSignal{
 		Symbol:    "BTCUSD",
 		Side:      types.SideBuy,
-		Price:     0,    // Market order
-		Quantity:  10.0, // More than total ask quantity (4.5)
+		Price:     types.NewDecimal(0),    // Market order
+		Quantity:  types.NewDecimal(10.0), // More than total ask quantity (4.5)
 		Timestamp: time.Now(),
 	}
 
 	execution := broker.executeOrder(signal)
 
-	assert.Nil(t, execution)
+	// Fills whatever the book holds and cancels the remainder
+	require.NotNil(t, execution)
+	assert.Equal(t, types.StatusCancelled, execution.Status)
+	assert.Equal(t, types.NewDecimal(4.5), execution.Quantity)
+	assert.Equal(t, types.NewDecimal(5.5), execution.Cancelled)
+	assert.Len(t, execution.Fills, 3)
+}
+
+func TestEmptyBookCancelsMarketOrder(t *testing.T) {
+	books := orderbook.NewRegistry()
+	books.GetOrCreate("BTCUSD")
+
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	execution := broker.executeOrder(types.TradeSignal{
+		Symbol:   "BTCUSD",
+		Side:     types.SideBuy,
+		Quantity: types.NewDecimal(1.0),
+	})
+
+	require.NotNil(t, execution)
+	assert.Equal(t, types.StatusCancelled, execution.Status)
+	assert.True(t, execution.Quantity.IsZero())
+	assert.Equal(t, types.NewDecimal(1.0), execution.Cancelled)
+}
+
+func TestFillsByLevel(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Market sell walks three bid levels
+	execution := broker.executeOrder(types.TradeSignal{
+		Symbol:   "BTCUSD",
+		Side:     types.SideSell,
+		Quantity: types.NewDecimal(3.5),
+	})
+
+	require.NotNil(t, execution)
+	assert.Equal(t, []types.Fill{
+		{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+		{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},
+		{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(0.5)},
+	}, execution.Fills)
+	assert.Equal(t, types.NewDecimal(3.5), execution.Quantity)
+	assert.True(t, execution.Cancelled.IsZero())
+
+	// (50000 + 99900 + 24950) / 3.5
+	assert.Equal(t, types.NewDecimal(49957.14285714), execution.Price)
+
+	// Limit buy only takes levels at or below its price
+	execution = broker.executeOrder(types.TradeSignal{
+		Symbol:   "BTCUSD",
+		Side:     types.SideBuy,
+		Price:    types.NewDecimal(50150),
+		Quantity: types.NewDecimal(2.0),
+	})
+
+	require.NotNil(t, execution)
+	assert.Equal(t, []types.Fill{
+		{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+		{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(1.0)},
+	}, execution.Fills)
+	assert.Equal(t, types.NewDecimal(50125), execution.Price)
 }
 
 func TestLimitOrderCannotFill(t *testing.T) {
-	ob := setupTestOrderBook()
+	books := setupTestBooks()
 
 	signals := make(chan types.TradeSignal, 1)
 	executions := make(chan types.Execution, 1)
 
-	broker := New(ob, signals, executions)
+	broker := New(books, signals, executions)
 
 	// Send limit buy order below best ask (should not fill immediately)
 	signal := types.TradeSignal{
 		Symbol:    "BTCUSD",
 		Side:      types.SideBuy,
-		Price:     49000, // Below all asks
-		Quantity:  1.0,
+		Price:     types.NewDecimal(49000), // Below all asks
+		Quantity:  types.NewDecimal(1.0),
 		Timestamp: time.Now(),
 	}
 
 	execution := broker.executeOrder(signal)
 
-	// In our simulation, it falls back to market price if available
-	// In a real system, this would create a pending order
+	// Nothing trades; the order is acknowledged and rests at its limit
+	require.NotNil(t, execution)
+	assert.Equal(t, types.StatusAcked, execution.Status)
+	assert.True(t, execution.Quantity.IsZero())
+	assert.Equal(t, types.NewDecimal(1.0), execution.Leaves)
+	require.Len(t, broker.working, 1)
+	assert.Equal(t, types.NewDecimal(1.0), broker.working[0].remaining)
+}
+
+func TestLimitFallbackOptIn(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+	broker.SetLimitFallback(true)
+
+	execution := broker.executeOrder(types.TradeSignal{
+		Symbol:   "BTCUSD",
+		Side:     types.SideBuy,
+		Price:    types.NewDecimal(49000),
+		Quantity: types.NewDecimal(1.0),
+	})
+
+	// Executes at the best ask, worse than the limit
+	require.NotNil(t, execution)
+	assert.Equal(t, types.NewDecimal(50100), execution.Price)
+	assert.Empty(t, broker.working)
+}
+
+func TestMarketablePortionFillsRestRests(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Only the 1.0 @ 50100 level is at or below the limit
+	execution := broker.executeOrder(types.TradeSignal{
+		Symbol:   "BTCUSD",
+		Side:     types.SideBuy,
+		Price:    types.NewDecimal(50120),
+		Quantity: types.NewDecimal(2.5),
+	})
+
 	require.NotNil(t, execution)
-	// The execution price should be the market fill price, not the limit price
-	assert.NotEqual(t, 49000.0, execution.Price)
+	assert.Equal(t, types.StatusPartiallyFilled, execution.Status)
+	assert.Equal(t, types.NewDecimal(1.0), execution.Quantity)
+	assert.Equal(t, types.NewDecimal(50100), execution.Price)
+	assert.Equal(t, types.NewDecimal(1.5), execution.Leaves)
+	assert.True(t, execution.Cancelled.IsZero())
+	require.Len(t, broker.working, 1)
+	assert.Equal(t, types.NewDecimal(1.5), broker.working[0].remaining)
+}
+
+func TestWorkingOrderFillsWhenCrossed(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	ack := broker.executeOrder(types.TradeSignal{
+		OrderID:  "bid-1",
+		Symbol:   "BTCUSD",
+		Side:     types.SideBuy,
+		Price:    types.NewDecimal(49000),
+		Quantity: types.NewDecimal(1.0),
+	})
+	require.NotNil(t, ack)
+	assert.Equal(t, types.StatusAcked, ack.Status)
+
+	// Book still above the limit: nothing happens
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+
+	// Offers drop through the limit
+	btc, _ := books.Get("BTCUSD")
+	btc.Update(types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(48900), Quantity: types.NewDecimal(1.0)}},
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(48990), Quantity: types.NewDecimal(0.4)},
+			{Price: types.NewDecimal(49000), Quantity: types.NewDecimal(0.3)},
+			{Price: types.NewDecimal(49010), Quantity: types.NewDecimal(5.0)},
+		},
+	})
+
+	executions := broker.matchWorking("BTCUSD")
+	require.Len(t, executions, 1)
+	assert.Equal(t, "bid-1", executions[0].OrderID)
+	assert.Equal(t, types.StatusPartiallyFilled, executions[0].Status)
+	assert.Equal(t, []types.Fill{
+		{Price: types.NewDecimal(48990), Quantity: types.NewDecimal(0.4)},
+		{Price: types.NewDecimal(49000), Quantity: types.NewDecimal(0.3)},
+	}, executions[0].Fills)
+	assert.Equal(t, types.NewDecimal(0.7), executions[0].Quantity)
+	assert.Equal(t, types.NewDecimal(0.3), executions[0].Leaves)
+
+	// The remainder fills on a later update and the order is done
+	btc.Update(types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(48950), Quantity: types.NewDecimal(2.0)}},
+	})
+	executions = broker.matchWorking("BTCUSD")
+	require.Len(t, executions, 1)
+	assert.Equal(t, types.NewDecimal(0.3), executions[0].Quantity)
+	assert.Equal(t, types.NewDecimal(48950), executions[0].Price)
+	assert.Equal(t, types.StatusFilled, executions[0].Status)
+	assert.True(t, executions[0].Leaves.IsZero())
+	assert.Empty(t, broker.working)
+}
+
+func TestWorkingOrdersShareUpdateLiquidity(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+	for _, id := range []string{"bid-1", "bid-2"} {
+		broker.executeOrder(types.TradeSignal{OrderID: id, Symbol: "BTCUSD", Side: types.SideBuy,
+			Price: types.NewDecimal(49000), Quantity: types.NewDecimal(4.0)})
+	}
+
+	// 5 offered through both limits: the first order in arrival order takes
+	// 4 and the second the 1 left, although market impact is off
+	moveBook(books, 48900, 48950, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 2)
+	assert.Equal(t, "bid-1", reports[0].OrderID)
+	assert.Equal(t, types.StatusFilled, reports[0].Status)
+	assert.Equal(t, "bid-2", reports[1].OrderID)
+	assert.Equal(t, types.NewDecimal(1.0), reports[1].Quantity)
+	assert.Equal(t, types.NewDecimal(3.0), reports[1].Leaves)
+
+	// The displayed book itself is untouched, and the next update is matched
+	// in full again
+	_, askQty, _ := books.GetOrCreate("BTCUSD").GetBestAsk()
+	assert.Equal(t, types.NewDecimal(5.0), askQty)
+	moveBook(books, 48900, 48950, time.Now())
+	reports = broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 1)
+	assert.Equal(t, types.StatusFilled, reports[0].Status)
+	assert.Equal(t, types.NewDecimal(3.0), reports[0].Quantity)
+}
+
+func TestBrokerMatchesWorkingOrdersOnBookUpdates(t *testing.T) {
+	books := setupTestBooks()
+	// Unbuffered channels keep the signal ahead of the book update
+	signals := make(chan types.TradeSignal)
+	updates := make(chan types.OrderBookSnapshot)
+	executions := make(chan types.Execution, 10)
+
+	broker := New(books, signals, executions)
+	broker.SetBookUpdates(updates)
+	go broker.Start()
+
+	signals <- types.TradeSignal{
+		Symbol:   "BTCUSD",
+		Side:     types.SideSell,
+		Price:    types.NewDecimal(50500),
+		Quantity: types.NewDecimal(1.0),
+	}
+	ack := <-executions
+	assert.Equal(t, types.StatusAcked, ack.Status)
+
+	btc, _ := books.Get("BTCUSD")
+	btc.Update(types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(50600), Quantity: types.NewDecimal(3.0)}},
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(50700), Quantity: types.NewDecimal(3.0)}},
+	})
+	updates <- btc.Snapshot()
+
+	select {
+	case execution := <-executions:
+		assert.Equal(t, ack.OrderID, execution.OrderID)
+		assert.Equal(t, types.StatusFilled, execution.Status)
+		assert.Equal(t, types.SideSell, execution.Side)
+		assert.Equal(t, types.NewDecimal(50600), execution.Price)
+		assert.Equal(t, types.NewDecimal(1.0), execution.Quantity)
+	case <-time.After(time.Second):
+		t.Fatal("working order was not filled")
+	}
+
+	close(updates)
+	close(signals)
+	_, open := <-executions
+	assert.False(t, open)
+}
+
+func TestStaleBookRejectsOrders(t *testing.T) {
+	books := setupTestBooks()
+	btc, _ := books.Get("BTCUSD")
+	btc.MarkStale()
+
+	signals := make(chan types.TradeSignal, 1)
+	executions := make(chan types.Execution, 1)
+
+	broker := New(books, signals, executions)
+
+	signal := types.TradeSignal{
+		Symbol:    "BTCUSD",
+		Side:      types.SideBuy,
+		Price:     types.NewDecimal(0), // Market order
+		Quantity:  types.NewDecimal(1.0),
+		Timestamp: time.Now(),
+	}
+
+	execution := broker.executeOrder(signal)
+	require.NotNil(t, execution)
+	assert.Equal(t, types.StatusRejected, execution.Status)
+	assert.Contains(t, execution.Reason, "stale")
+}
+
+func TestUnknownSymbolRejected(t *testing.T) {
+	books := setupTestBooks()
+
+	signals := make(chan types.TradeSignal, 1)
+	executions := make(chan types.Execution, 1)
+
+	broker := New(books, signals, executions)
+
+	signal := types.TradeSignal{
+		Symbol:    "ETHUSD", // No book for this symbol
+		Side:      types.SideBuy,
+		Price:     types.NewDecimal(0), // Market order
+		Quantity:  types.NewDecimal(1.0),
+		Timestamp: time.Now(),
+	}
+
+	execution := broker.executeOrder(signal)
+	require.NotNil(t, execution)
+	assert.Equal(t, types.StatusRejected, execution.Status)
+	assert.Contains(t, execution.Reason, "no order book")
+}
+
+func TestMultipleSymbols(t *testing.T) {
+	books := setupTestBooks()
+	books.GetOrCreate("ETHUSD").Update(types.OrderBookSnapshot{
+		Symbol:    "ETHUSD",
+		Timestamp: time.Now(),
+		Bids:      []types.OrderBookEntry{{Price: types.NewDecimal(3000), Quantity: types.NewDecimal(5.0)}},
+		Asks:      []types.OrderBookEntry{{Price: types.NewDecimal(3005), Quantity: types.NewDecimal(4.5)}},
+	})
+
+	signals := make(chan types.TradeSignal, 1)
+	executions := make(chan types.Execution, 1)
+
+	broker := New(books, signals, executions)
+
+	btc := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0), Timestamp: time.Now()})
+	eth := broker.executeOrder(types.TradeSignal{Symbol: "ETHUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0), Timestamp: time.Now()})
+
+	require.NotNil(t, btc)
+	require.NotNil(t, eth)
+	assert.Equal(t, types.NewDecimal(50100.0), btc.Price)
+	assert.Equal(t, types.NewDecimal(3005.0), eth.Price)
+}
+
+func TestTickAndLotValidation(t *testing.T) {
+	books := setupTestBooks()
+
+	signals := make(chan types.TradeSignal, 1)
+	executions := make(chan types.Execution, 1)
+
+	broker := New(books, signals, executions)
+	broker.SetInstrument(types.Instrument{Symbol: "BTCUSD", TickSize: types.NewDecimal(0.5), LotSize: types.NewDecimal(0.1)})
+
+	// Off-tick limit price
+	offTick := types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(50150.25), Quantity: types.NewDecimal(1.0), Timestamp: time.Now()}
+	assert.Equal(t, types.StatusRejected, broker.executeOrder(offTick).Status)
+
+	// Off-lot quantity
+	offLot := types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.05), Timestamp: time.Now()}
+	assert.Equal(t, types.StatusRejected, broker.executeOrder(offLot).Status)
+
+	// Zero quantity
+	empty := types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Timestamp: time.Now()}
+	assert.Equal(t, types.StatusRejected, broker.executeOrder(empty).Status)
+
+	// On-grid order fills
+	valid := types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(50150.5), Quantity: types.NewDecimal(1.1), Timestamp: time.Now()}
+	assert.Equal(t, types.StatusFilled, broker.executeOrder(valid).Status)
+}
+
+func TestMarketImpactDepletesBook(t *testing.T) {
+	books := setupTestBooks()
+
+	signals := make(chan types.TradeSignal, 1)
+	executions := make(chan types.Execution, 1)
+
+	broker := New(books, signals, executions)
+
+	signal := types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0), Timestamp: time.Now()}
+
+	// Without impact both orders take the same top of book
+	first := broker.executeOrder(signal)
+	second := broker.executeOrder(signal)
+	require.NotNil(t, first)
+	require.NotNil(t, second)
+	assert.Equal(t, first.Price, second.Price)
+
+	broker.SetImpactModel(ImpactModel{Enabled: true})
+
+	first = broker.executeOrder(signal)
+	second = broker.executeOrder(signal)
+	require.NotNil(t, first)
+	require.NotNil(t, second)
+	assert.Equal(t, types.NewDecimal(50100), first.Price)
+	assert.Equal(t, types.NewDecimal(50150), second.Price)
+
+	// 2.0 @ 50150 was reduced to 1.0, leaving 1.0 @ 50150 and 1.5 @ 50200
+	ob, _ := books.Get("BTCUSD")
+	_, canFill := ob.GetFillPrice(types.SideBuy, types.NewDecimal(3.0))
+	assert.False(t, canFill)
+}
+
+func TestCancelWorkingOrder(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	broker.handleSignal(types.TradeSignal{
+		OrderID: "bid-1", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0),
+	})
+
+	cancel := broker.handleSignal(types.TradeSignal{OrderID: "bid-1", Action: types.ActionCancel})
+	require.NotNil(t, cancel)
+	assert.Equal(t, types.StatusCancelled, cancel.Status)
+	assert.Equal(t, types.NewDecimal(1.0), cancel.Cancelled)
+	assert.Equal(t, "BTCUSD", cancel.Symbol)
+	assert.Empty(t, broker.working)
+
+	// A second cancel finds nothing to cancel
+	again := broker.handleSignal(types.TradeSignal{OrderID: "bid-1", Action: types.ActionCancel})
+	assert.Equal(t, types.StatusRequestRejected, again.Status)
+	assert.Contains(t, again.Reason, "not working")
+}
+
+func TestReplaceWorkingOrder(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+	broker.SetInstrument(types.Instrument{Symbol: "BTCUSD", TickSize: types.NewDecimal(0.5), LotSize: types.NewDecimal(0.1)})
+
+	broker.handleSignal(types.TradeSignal{
+		OrderID: "bid-1", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(2.0),
+	})
+	broker.handleSignal(types.TradeSignal{
+		OrderID: "bid-2", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(48500), Quantity: types.NewDecimal(1.0),
+	})
+
+	// Off-tick amend is refused and the original keeps working in its place
+	bad := broker.handleSignal(types.TradeSignal{OrderID: "bid-1", Action: types.ActionReplace, Price: types.NewDecimal(49000.25)})
+	assert.Equal(t, types.StatusRequestRejected, bad.Status)
+	assert.False(t, bad.Replaced)
+	require.Len(t, broker.working, 2)
+	assert.Equal(t, "bid-1", broker.working[0].signal.OrderID)
+	assert.Equal(t, types.NewDecimal(49000), broker.working[0].signal.Price)
+
+	// Unknown orders cannot be amended
+	missing := broker.handleSignal(types.TradeSignal{OrderID: "bid-9", Action: types.ActionReplace, Quantity: types.NewDecimal(1.0)})
+	assert.Equal(t, types.StatusRequestRejected, missing.Status)
+
+	// Smaller size at the same price
+	smaller := broker.handleSignal(types.TradeSignal{OrderID: "bid-1", Action: types.ActionReplace, Quantity: types.NewDecimal(1.5)})
+	assert.Equal(t, types.StatusAcked, smaller.Status)
+	assert.True(t, smaller.Replaced)
+	assert.Equal(t, types.NewDecimal(1.5), smaller.Leaves)
+	broker.handleSignal(types.TradeSignal{OrderID: "bid-2", Action: types.ActionCancel}) // leave bid-1 alone
+
+	// Raising the price through the offer fills what is marketable
+	crossed := broker.handleSignal(types.TradeSignal{OrderID: "bid-1", Action: types.ActionReplace, Price: types.NewDecimal(50100)})
+	require.NotNil(t, crossed)
+	assert.Equal(t, "bid-1", crossed.OrderID)
+	assert.Equal(t, types.StatusPartiallyFilled, crossed.Status)
+	assert.True(t, crossed.Replaced)
+	assert.Equal(t, types.NewDecimal(1.0), crossed.Quantity)
+	assert.Equal(t, types.NewDecimal(0.5), crossed.Leaves)
+	require.Len(t, broker.working, 1)
+	assert.Equal(t, types.NewDecimal(50100), broker.working[0].signal.Price)
+}
+
+func TestDuplicateOrderIDRejected(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	order := types.TradeSignal{OrderID: "bid-1", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0)}
+	assert.Equal(t, types.StatusAcked, broker.handleSignal(order).Status)
+	assert.Equal(t, types.StatusRejected, broker.handleSignal(order).Status)
+
+	// Orders without an ID get one from the broker
+	unnamed := broker.handleSignal(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0)})
+	assert.NotEmpty(t, unnamed.OrderID)
+}
+
+func TestFinishedOrderIDsNotReused(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	filled := types.TradeSignal{OrderID: "A", Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(0.5)}
+	require.Equal(t, types.StatusFilled, broker.handleSignal(filled).Status)
+
+	resting := types.TradeSignal{OrderID: "B", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0)}
+	require.Equal(t, types.StatusAcked, broker.handleSignal(resting).Status)
+	require.Equal(t, types.StatusCancelled, broker.handleSignal(types.TradeSignal{OrderID: "B", Action: types.ActionCancel}).Status)
+
+	rejected := types.TradeSignal{OrderID: "C", Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(-1)}
+	require.Equal(t, types.StatusRejected, broker.handleSignal(rejected).Status)
+
+	for _, signal := range []types.TradeSignal{filled, resting, rejected} {
+		report := broker.handleSignal(signal)
+		assert.Equal(t, types.StatusRejected, report.Status, signal.OrderID)
+		assert.Contains(t, report.Reason, "duplicate order ID")
+	}
+
+	// A broker-assigned ID skips IDs the strategy already used
+	require.Equal(t, types.StatusAcked, broker.handleSignal(types.TradeSignal{OrderID: "B1", Symbol: "BTCUSD",
+		Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0)}).Status)
+	unnamed := broker.handleSignal(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0)})
+	assert.Equal(t, "B2", unnamed.OrderID)
+}
+
+func TestWorkingOrdersExpireAtShutdown(t *testing.T) {
+	books := setupTestBooks()
+	signals := make(chan types.TradeSignal, 1)
+	executions := make(chan types.Execution, 10)
+	broker := New(books, signals, executions)
+
+	signals <- types.TradeSignal{OrderID: "bid-1", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0)}
+	close(signals)
+	broker.Start()
+
+	var reports []types.Execution
+	for execution := range executions {
+		reports = append(reports, execution)
+	}
+	require.Len(t, reports, 2)
+	assert.Equal(t, types.StatusAcked, reports[0].Status)
+	assert.Equal(t, types.StatusExpired, reports[1].Status)
+	assert.Equal(t, types.NewDecimal(1.0), reports[1].Cancelled)
+}
+
+func TestImmediateOrCancel(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Only 1.0 is offered at or below 50100
+	execution := broker.executeOrder(types.TradeSignal{
+		Symbol:      "BTCUSD",
+		Side:        types.SideBuy,
+		Price:       types.NewDecimal(50100),
+		Quantity:    types.NewDecimal(2.5),
+		TimeInForce: types.TimeInForceIOC,
+	})
+
+	require.NotNil(t, execution)
+	assert.Equal(t, types.StatusCancelled, execution.Status)
+	assert.Equal(t, types.NewDecimal(1.0), execution.Quantity)
+	assert.Equal(t, types.NewDecimal(1.5), execution.Cancelled)
+	assert.True(t, execution.Leaves.IsZero())
+	assert.Empty(t, broker.working)
+}
+
+func TestFillOrKill(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// 3.0 available at or below 50150: fills in full
+	filled := broker.executeOrder(types.TradeSignal{
+		Symbol:      "BTCUSD",
+		Side:        types.SideBuy,
+		Price:       types.NewDecimal(50150),
+		Quantity:    types.NewDecimal(3.0),
+		TimeInForce: types.TimeInForceFOK,
+	})
+	assert.Equal(t, types.StatusFilled, filled.Status)
+	assert.Equal(t, types.NewDecimal(3.0), filled.Quantity)
+
+	// 3.1 is not: nothing trades
+	killed := broker.executeOrder(types.TradeSignal{
+		Symbol:      "BTCUSD",
+		Side:        types.SideBuy,
+		Price:       types.NewDecimal(50150),
+		Quantity:    types.NewDecimal(3.1),
+		TimeInForce: types.TimeInForceFOK,
+	})
+	assert.Equal(t, types.StatusCancelled, killed.Status)
+	assert.True(t, killed.Quantity.IsZero())
+	assert.Empty(t, killed.Fills)
+	assert.Equal(t, types.NewDecimal(3.1), killed.Cancelled)
+
+	// Market FOK larger than the whole side is killed too
+	market := broker.executeOrder(types.TradeSignal{
+		Symbol:      "BTCUSD",
+		Side:        types.SideSell,
+		Quantity:    types.NewDecimal(5.0),
+		TimeInForce: types.TimeInForceFOK,
+	})
+	assert.Equal(t, types.StatusCancelled, market.Status)
+	assert.True(t, market.Quantity.IsZero())
+	assert.Empty(t, broker.working)
+}
+
+func TestGoodTillCancel(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	execution := broker.executeOrder(types.TradeSignal{
+		Symbol:      "BTCUSD",
+		Side:        types.SideSell,
+		Price:       types.NewDecimal(50000),
+		Quantity:    types.NewDecimal(1.5),
+		TimeInForce: types.TimeInForceGTC,
+	})
+
+	// Takes the 50000 bid and rests the rest with no expiry
+	assert.Equal(t, types.StatusPartiallyFilled, execution.Status)
+	assert.Equal(t, types.NewDecimal(0.5), execution.Leaves)
+	require.Len(t, broker.working, 1)
+
+	btc, _ := books.Get("BTCUSD")
+	btc.Update(types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: time.Now().Add(time.Hour),
+		Bids:      []types.OrderBookEntry{{Price: types.NewDecimal(49990), Quantity: types.NewDecimal(1.0)}},
+		Asks:      []types.OrderBookEntry{{Price: types.NewDecimal(50010), Quantity: types.NewDecimal(1.0)}},
+	})
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+	assert.Len(t, broker.working, 1)
+}
+
+func TestGoodTillDate(t *testing.T) {
+	books := setupTestBooks()
+	btc, _ := books.Get("BTCUSD")
+	feedTime := btc.LastUpdated()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Already expired on the feed clock
+	late := broker.executeOrder(types.TradeSignal{
+		Symbol:      "BTCUSD",
+		Side:        types.SideBuy,
+		Price:       types.NewDecimal(49000),
+		Quantity:    types.NewDecimal(1.0),
+		TimeInForce: types.TimeInForceGTD,
+		ExpireTime:  feedTime,
+	})
+	assert.Equal(t, types.StatusRejected, late.Status)
+
+	// Missing expire time
+	missing := broker.executeOrder(types.TradeSignal{
+		Symbol:      "BTCUSD",
+		Side:        types.SideBuy,
+		Price:       types.NewDecimal(49000),
+		Quantity:    types.NewDecimal(1.0),
+		TimeInForce: types.TimeInForceGTD,
+	})
+	assert.Equal(t, types.StatusRejected, missing.Status)
+
+	ack := broker.executeOrder(types.TradeSignal{
+		OrderID:     "gtd-1",
+		Symbol:      "BTCUSD",
+		Side:        types.SideBuy,
+		Price:       types.NewDecimal(49000),
+		Quantity:    types.NewDecimal(1.0),
+		TimeInForce: types.TimeInForceGTD,
+		ExpireTime:  feedTime.Add(500 * time.Millisecond),
+	})
+	require.Equal(t, types.StatusAcked, ack.Status)
+
+	update := func(offset time.Duration) {
+		btc.Update(types.OrderBookSnapshot{
+			Symbol:    "BTCUSD",
+			Timestamp: feedTime.Add(offset),
+			Bids:      []types.OrderBookEntry{{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(1.0)}},
+			Asks:      []types.OrderBookEntry{{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)}},
+		})
+	}
+
+	// Still live before the expire time
+	update(400 * time.Millisecond)
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+	require.Len(t, broker.working, 1)
+
+	// Expires once the feed clock reaches it, even though wall time has not
+	update(500 * time.Millisecond)
+	executions := broker.matchWorking("BTCUSD")
+	require.Len(t, executions, 1)
+	assert.Equal(t, "gtd-1", executions[0].OrderID)
+	assert.Equal(t, types.StatusExpired, executions[0].Status)
+	assert.Equal(t, types.NewDecimal(1.0), executions[0].Cancelled)
+	assert.Empty(t, broker.working)
+}
+
+func TestPostOnly(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Would lift the 50100 offer
+	crossing := broker.executeOrder(types.TradeSignal{
+		Symbol:   "BTCUSD",
+		Side:     types.SideBuy,
+		Price:    types.NewDecimal(50100),
+		Quantity: types.NewDecimal(1.0),
+		PostOnly: true,
+	})
+	assert.Equal(t, types.StatusRejected, crossing.Status)
+	assert.Contains(t, crossing.Reason, "post-only")
+	assert.Empty(t, broker.working)
+
+	// Joins the bid without trading
+	passive := broker.executeOrder(types.TradeSignal{
+		Symbol:   "BTCUSD",
+		Side:     types.SideBuy,
+		Price:    types.NewDecimal(50050),
+		Quantity: types.NewDecimal(1.0),
+		PostOnly: true,
+	})
+	assert.Equal(t, types.StatusAcked, passive.Status)
+	assert.Len(t, broker.working, 1)
+
+	// Post-only cannot be combined with immediate time in force
+	ioc := broker.executeOrder(types.TradeSignal{
+		Symbol:      "BTCUSD",
+		Side:        types.SideBuy,
+		Price:       types.NewDecimal(50050),
+		Quantity:    types.NewDecimal(1.0),
+		PostOnly:    true,
+		TimeInForce: types.TimeInForceIOC,
+	})
+	assert.Equal(t, types.StatusRejected, ioc.Status)
+}
+
+func TestOrderTermsValidation(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	tests := []types.TradeSignal{
+		{Type: types.OrderTypeLimit},                                     // limit without price
+		{Type: types.OrderTypeMarket, Price: types.NewDecimal(50100)},    // market with price
+		{Type: types.OrderTypeMarket, TimeInForce: types.TimeInForceGTC}, // market cannot rest
+		{TimeInForce: "DAY", Price: types.NewDecimal(50100)},             // unknown TIF
+		{Type: "ICEBERG", Price: types.NewDecimal(50100)},                // unknown type
+		{Type: types.OrderTypeMarket, PostOnly: true},                    // post-only market
+	}
+	for _, signal := range tests {
+		signal.Symbol = "BTCUSD"
+		signal.Side = types.SideBuy
+		signal.Quantity = types.NewDecimal(1.0)
+		execution := broker.executeOrder(signal)
+		assert.Equal(t, types.StatusRejected, execution.Status, "%+v", signal)
+	}
+	assert.Empty(t, broker.working)
 }
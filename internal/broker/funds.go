@@ -0,0 +1,153 @@
+package broker
+
+import (
+	"fmt"
+	"log"
+	"trading-engine/internal/account"
+	"trading-engine/internal/fees"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// SetFeeSchedule sets the schedule that charges every fill. Fills are free
+// until a schedule is set.
+func (b *Broker) SetFeeSchedule(schedule fees.Schedule) {
+	if schedule == nil {
+		schedule = fees.None{}
+	}
+	b.fees = schedule
+}
+
+// charge flags a report's fills as making or taking liquidity, attaches
+// their fee and records them with the fee schedule
+func (b *Broker) charge(execution *types.Execution, liquidity types.Liquidity) {
+	trade := fees.Trade{
+		Symbol:    execution.Symbol,
+		Notional:  execution.Notional(),
+		Liquidity: liquidity,
+		Time:      execution.Timestamp,
+	}
+	execution.Liquidity = liquidity
+	execution.Fee = b.fees.Fee(trade)
+	b.fees.Record(trade)
+}
+
+// SetAccount makes the broker check new orders against the account's
+// buying power and book every fill it reports to the account. Without an
+// account orders are not limited by balances.
+func (b *Broker) SetAccount(acct *account.Account) {
+	b.account = acct
+}
+
+// checkBuyingPower rejects an order that would spend more than the account
+// has left after the orders already working. Buys spend the quote currency
+// plus the taker fee and sells the base currency. Members of one OCO group
+// can execute at most once, so the group needs only its largest member's
+// funds.
+func (b *Broker) checkBuyingPower(ob *orderbook.OrderBook, signal types.TradeSignal) error {
+	if b.account == nil {
+		return nil
+	}
+
+	base, quote, ok := account.SplitSymbol(signal.Symbol)
+	if !ok {
+		return fmt.Errorf("cannot derive currencies of symbol %s", signal.Symbol)
+	}
+	quantity, notional := b.spend(ob, signal)
+	currency, required := base, quantity
+	if signal.Side == types.SideBuy {
+		fee := b.fees.Fee(fees.Trade{Symbol: signal.Symbol, Notional: notional,
+			Liquidity: types.LiquidityTaker, Time: b.now(signal.Symbol)})
+		currency, required = quote, notional.Add(types.MaxDecimal(fee, types.Zero))
+	}
+	committed, group := b.committed(currency, signal.OCOGroup)
+	if group.GreaterThan(required) {
+		required = group
+	}
+
+	available := b.account.Balance(currency).Sub(committed)
+	if required.GreaterThan(available) {
+		return fmt.Errorf("insufficient buying power: need %s %s, %s available", required, currency, available)
+	}
+	return nil
+}
+
+// committed returns the funds in a currency held by working orders outside
+// an OCO group, and separately the funds held by the group
+func (b *Broker) committed(currency, ocoGroup string) (types.Decimal, types.Decimal) {
+	var total types.Decimal
+	groups := make(map[string]types.Decimal)
+	for _, order := range b.working {
+		if b.isLoser(order.signal) {
+			continue
+		}
+		price := order.signal.Price
+		if order.signal.Type != types.OrderTypeLimit && order.signal.Type != types.OrderTypeStopLimit {
+			price = order.stopPrice
+		}
+		orderCurrency, amount, err := account.Required(order.signal.Symbol, order.signal.Side, order.remaining, price)
+		if err != nil || orderCurrency != currency {
+			continue
+		}
+		if order.signal.OCOGroup == "" {
+			total = total.Add(amount)
+			continue
+		}
+		groups[order.signal.OCOGroup] = types.MaxDecimal(groups[order.signal.OCOGroup], amount)
+	}
+
+	for name, amount := range groups {
+		if name != ocoGroup {
+			total = total.Add(amount)
+		}
+	}
+	return total, groups[ocoGroup]
+}
+
+// spend returns the quantity an order is expected to trade and its
+// notional. Orders that can trade now count the fills they would take from
+// the book, plus any remainder that rests at the limit; quantity that would
+// be cancelled spends nothing. Conditional orders count in full at their
+// limit, their stop for stop-market orders or the touch for trailing stops.
+func (b *Broker) spend(ob *orderbook.OrderBook, signal types.TradeSignal) (types.Decimal, types.Decimal) {
+	price := types.Zero
+	switch signal.Type {
+	case types.OrderTypeStopLimit:
+		price = signal.Price
+	case types.OrderTypeStop:
+		price = signal.StopPrice
+	case types.OrderTypeTrailingStop:
+		price, _ = stopTouch(ob, signal.Side)
+	}
+	if signal.Type.IsConditional() {
+		return signal.Quantity, signal.Quantity.Mul(price)
+	}
+
+	walkLimit := types.Zero
+	if signal.Type == types.OrderTypeLimit && (!b.limitFallback || ob.CanFill(signal.Side, signal.Price, signal.Quantity)) {
+		walkLimit = signal.Price
+	}
+	var quantity, notional types.Decimal
+	for _, fill := range ob.GetFills(signal.Side, signal.Quantity, walkLimit) {
+		quantity = quantity.Add(fill.Quantity)
+		notional = notional.Add(fill.Price.Mul(fill.Quantity))
+	}
+
+	rests := !walkLimit.IsZero() &&
+		(signal.TimeInForce == types.TimeInForceGTC || signal.TimeInForce == types.TimeInForceGTD)
+	if unfilled := signal.Quantity.Sub(quantity); rests && unfilled.IsPositive() {
+		quantity = signal.Quantity
+		notional = notional.Add(unfilled.Mul(signal.Price))
+	}
+	return quantity, notional
+}
+
+// book applies the fills of a report to the account
+func (b *Broker) book(execution types.Execution) {
+	if b.account == nil {
+		return
+	}
+	if err := b.account.Apply(execution); err != nil {
+		log.Printf("Account not updated for order %s: %v", execution.OrderID, err)
+	}
+}
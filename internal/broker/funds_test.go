@@ -0,0 +1,153 @@
+package broker
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/account"
+	"trading-engine/internal/fees"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarketBuyNeedsQuoteBalance(t *testing.T) {
+	executions := make(chan types.Execution, 10)
+	broker := New(setupTestBooks(), make(chan types.TradeSignal), executions)
+	acct := account.New(map[string]types.Decimal{"USD": types.NewDecimal(100000)})
+	broker.SetAccount(acct)
+
+	// Walking two levels costs 50100 + 50150
+	report := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(2.0)})
+	assert.Equal(t, types.StatusRejected, report.Status)
+	assert.Contains(t, report.Reason, "need 100250 USD, 100000 available")
+
+	report = broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0)})
+	require.Equal(t, types.StatusFilled, report.Status)
+	broker.send(report)
+	assert.Equal(t, types.NewDecimal(49900), acct.Balance("USD"))
+	assert.Equal(t, types.NewDecimal(1.0), acct.Balance("BTC"))
+
+	// Selling spends the base currency
+	report = broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideSell, Quantity: types.NewDecimal(1.5)})
+	assert.Equal(t, types.StatusRejected, report.Status)
+	assert.Contains(t, report.Reason, "need 1.5 BTC, 1 available")
+}
+
+func TestWorkingOrdersCommitFunds(t *testing.T) {
+	broker := New(setupTestBooks(), make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetAccount(account.New(map[string]types.Decimal{"USD": types.NewDecimal(100000)}))
+
+	report := broker.executeOrder(types.TradeSignal{OrderID: "A", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0)})
+	require.Equal(t, types.StatusAcked, report.Status)
+
+	report = broker.executeOrder(types.TradeSignal{OrderID: "B", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(2.0)})
+	assert.Equal(t, types.StatusRejected, report.Status, "A holds 49000 of the 100000")
+
+	report = broker.executeOrder(types.TradeSignal{OrderID: "C", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0)})
+	assert.Equal(t, types.StatusAcked, report.Status)
+
+	// Cancelling releases the funds
+	broker.cancelOrder(types.TradeSignal{OrderID: "A", Action: types.ActionCancel})
+	report = broker.executeOrder(types.TradeSignal{OrderID: "D", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(1.0)})
+	assert.Equal(t, types.StatusAcked, report.Status)
+}
+
+func TestOCOGroupCommitsOnce(t *testing.T) {
+	broker := New(setupTestBooks(), make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetAccount(account.New(map[string]types.Decimal{"BTC": types.NewDecimal(1.0)}))
+
+	report := broker.executeOrder(types.TradeSignal{
+		OrderID: "stop", Symbol: "BTCUSD", Side: types.SideSell, Type: types.OrderTypeStop,
+		StopPrice: types.NewDecimal(49800), Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+	require.Equal(t, types.StatusAcked, report.Status)
+	report = broker.executeOrder(types.TradeSignal{
+		OrderID: "target", Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50300),
+		Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+	assert.Equal(t, types.StatusAcked, report.Status, "siblings share the same BTC")
+
+	report = broker.executeOrder(types.TradeSignal{OrderID: "other", Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50400), Quantity: types.NewDecimal(0.5)})
+	assert.Equal(t, types.StatusRejected, report.Status)
+}
+
+func TestUnknownCurrenciesRejected(t *testing.T) {
+	books := setupTestBooks()
+	books.GetOrCreate("XYZ").Update(types.OrderBookSnapshot{
+		Symbol: "XYZ",
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(1), Quantity: types.NewDecimal(5)}},
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(2), Quantity: types.NewDecimal(5)}},
+	})
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetAccount(account.New(nil))
+
+	report := broker.executeOrder(types.TradeSignal{Symbol: "XYZ", Side: types.SideBuy, Quantity: types.NewDecimal(1)})
+	assert.Equal(t, types.StatusRejected, report.Status)
+	assert.Contains(t, report.Reason, "cannot derive currencies")
+}
+
+func TestFeesAndLiquidityFlags(t *testing.T) {
+	books := setupTestBooks()
+	executions := make(chan types.Execution, 10)
+	broker := New(books, make(chan types.TradeSignal), executions)
+	acct := account.New(map[string]types.Decimal{"USD": types.NewDecimal(100000)})
+	broker.SetAccount(acct)
+	broker.SetFeeSchedule(fees.MakerTaker{MakerBps: -1, TakerBps: 5})
+
+	// Crossing the book takes liquidity
+	taker := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0)})
+	require.Equal(t, types.StatusFilled, taker.Status)
+	assert.Equal(t, types.LiquidityTaker, taker.Liquidity)
+	assert.Equal(t, types.NewDecimal(25.05), taker.Fee)
+	broker.send(taker)
+	assert.Equal(t, types.NewDecimal(100000-50100-25.05), acct.Balance("USD"))
+
+	// A resting order filled later makes liquidity and earns the rebate
+	broker.executeOrder(types.TradeSignal{OrderID: "rest", Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50300), Quantity: types.NewDecimal(1.0)})
+	moveBook(books, 50300, 50350, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 1)
+	assert.Equal(t, types.LiquidityMaker, reports[0].Liquidity)
+	assert.Equal(t, types.NewDecimal(-5.03), reports[0].Fee)
+}
+
+func TestBuyingPowerIncludesTakerFee(t *testing.T) {
+	broker := New(setupTestBooks(), make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetAccount(account.New(map[string]types.Decimal{"USD": types.NewDecimal(50100)}))
+	broker.SetFeeSchedule(fees.Flat{PerTrade: types.NewDecimal(1)})
+
+	report := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0)})
+	assert.Equal(t, types.StatusRejected, report.Status)
+	assert.Contains(t, report.Reason, "need 50101 USD")
+}
+
+func TestMarketBuyNeedsOnlyWhatTheBookFills(t *testing.T) {
+	broker := New(setupTestBooks(), make(chan types.TradeSignal), make(chan types.Execution, 10))
+	acct := account.New(map[string]types.Decimal{"USD": types.NewDecimal(230000)})
+	broker.SetAccount(acct)
+
+	// The book holds 4.5 BTC for 225700; the other 5.5 would be cancelled
+	report := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(10.0)})
+	require.Equal(t, types.StatusCancelled, report.Status, report.Reason)
+	assert.Equal(t, types.NewDecimal(4.5), report.Quantity)
+	assert.Equal(t, types.NewDecimal(5.5), report.Cancelled)
+	broker.send(report)
+	assert.Equal(t, types.NewDecimal(4300), acct.Balance("USD"))
+}
+
+func TestRestingRemainderNeedsLimitFunds(t *testing.T) {
+	broker := New(setupTestBooks(), make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetAccount(account.New(map[string]types.Decimal{"USD": types.NewDecimal(100000)}))
+
+	// 1 fills at 50100 and 1 rests at 50100: 100200 in all
+	report := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(50100), Quantity: types.NewDecimal(2.0)})
+	assert.Equal(t, types.StatusRejected, report.Status)
+	assert.Contains(t, report.Reason, "need 100200 USD")
+
+	// As IOC the unfilled part is cancelled, so only 50100 is spent
+	report = broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(50100),
+		Quantity: types.NewDecimal(2.0), TimeInForce: types.TimeInForceIOC})
+	assert.Equal(t, types.StatusCancelled, report.Status, report.Reason)
+	assert.Equal(t, types.NewDecimal(1.0), report.Quantity)
+}
@@ -0,0 +1,203 @@
+package broker
+
+import (
+	"fmt"
+	"log"
+	"trading-engine/internal/types"
+)
+
+// activation is a bracket parent fill whose exits have not been attached yet
+type activation struct {
+	parent   types.TradeSignal
+	quantity types.Decimal
+}
+
+// filled records that an order executed some quantity and queues bracket
+// exits for it. An order that is now complete claims its OCO group; a
+// partial fill instead reduces the working siblings by the filled quantity,
+// so a stop keeps covering what a partly filled target leaves open.
+func (b *Broker) filled(signal types.TradeSignal, quantity types.Decimal, complete bool) {
+	if complete {
+		b.claim(signal)
+	} else {
+		b.reduceSiblings(signal, quantity)
+	}
+	if !signal.TakeProfitPrice.IsZero() || !signal.StopLossPrice.IsZero() {
+		b.activations = append(b.activations, activation{parent: signal, quantity: quantity})
+	}
+}
+
+// reduceSiblings takes a partial fill off the other working members of the
+// order's OCO group. A sibling the fill would use up entirely makes the
+// order claim the group instead, so the sibling is cancelled.
+func (b *Broker) reduceSiblings(signal types.TradeSignal, quantity types.Decimal) {
+	if signal.OCOGroup == "" || b.isLoser(signal) {
+		return
+	}
+	for _, order := range b.working {
+		if order.signal.OCOGroup != signal.OCOGroup || order.signal.OrderID == signal.OrderID {
+			continue
+		}
+		if !order.remaining.GreaterThan(quantity) {
+			b.claim(signal)
+			return
+		}
+	}
+
+	for _, order := range b.working {
+		if order.signal.OCOGroup != signal.OCOGroup || order.signal.OrderID == signal.OrderID {
+			continue
+		}
+		order.remaining = order.remaining.Sub(quantity)
+		log.Printf("OCO sibling %s reduced to %.4f: %s partially filled", order.signal.OrderID,
+			order.remaining, signal.OrderID)
+		b.reductions = append(b.reductions, &types.Execution{
+			OrderID:   order.signal.OrderID,
+			Status:    types.StatusAcked,
+			Reason:    fmt.Sprintf("OCO sibling %s partially filled", signal.OrderID),
+			Symbol:    order.signal.Symbol,
+			Side:      order.signal.Side,
+			Timestamp: b.now(order.signal.Symbol),
+			Leaves:    order.remaining,
+		})
+	}
+}
+
+// claim makes an order the completed member of its OCO group. It returns
+// false if another member completed first.
+func (b *Broker) claim(signal types.TradeSignal) bool {
+	if signal.OCOGroup == "" {
+		return true
+	}
+	if winner, exists := b.ocoWinners[signal.OCOGroup]; exists {
+		return winner == signal.OrderID
+	}
+	b.ocoWinners[signal.OCOGroup] = signal.OrderID
+	log.Printf("OCO group %s executed by %s", signal.OCOGroup, signal.OrderID)
+	return true
+}
+
+// isLoser reports whether another member of the order's OCO group has executed
+func (b *Broker) isLoser(signal types.TradeSignal) bool {
+	if signal.OCOGroup == "" {
+		return false
+	}
+	winner, exists := b.ocoWinners[signal.OCOGroup]
+	return exists && winner != signal.OrderID
+}
+
+// followUps returns the reports caused by earlier executions: OCO siblings
+// resized by partial fills, bracket exits attached for new parent fills and
+// OCO siblings cancelled because another member of their group completed
+func (b *Broker) followUps() []*types.Execution {
+	reports := b.reductions
+	b.reductions = nil
+
+	activations := b.activations
+	b.activations = nil
+	for _, a := range activations {
+		reports = append(reports, b.activateBracket(a)...)
+	}
+
+	return append(reports, b.sweepLosers()...)
+}
+
+// activateBracket attaches the exits of a bracket parent for a fill. The
+// first fill places a protective stop and a target limit order in an OCO
+// group; later fills add to their quantity.
+func (b *Broker) activateBracket(a activation) []*types.Execution {
+	parent := a.parent
+	group := types.BracketGroup(parent.OrderID)
+	stopID, targetID := types.BracketExitIDs(parent.OrderID)
+
+	var reports []*types.Execution
+	for _, id := range []string{stopID, targetID} {
+		if order := b.findWorking(id); order != nil && !b.isLoser(order.signal) {
+			order.remaining = order.remaining.Add(a.quantity)
+			log.Printf("Bracket exit %s increased to %.4f", id, order.remaining)
+			reports = append(reports, &types.Execution{
+				OrderID:   id,
+				Status:    types.StatusAcked,
+				Reason:    "bracket parent filled further",
+				Symbol:    order.signal.Symbol,
+				Side:      order.signal.Side,
+				Timestamp: b.now(order.signal.Symbol),
+				Leaves:    order.remaining,
+			})
+		}
+	}
+	if len(reports) > 0 {
+		return reports
+	}
+	if winner, done := b.ocoWinners[group]; done {
+		log.Printf("Bracket %s already closed by %s; %.4f filled later is unprotected",
+			parent.OrderID, winner, a.quantity)
+		return nil
+	}
+
+	exitSide := types.SideSell
+	if parent.Side == types.SideSell {
+		exitSide = types.SideBuy
+	}
+	log.Printf("Bracket %s filled %.4f: attaching stop %.2f and target %.2f",
+		parent.OrderID, a.quantity, parent.StopLossPrice, parent.TakeProfitPrice)
+
+	b.useOrderID(stopID)
+	b.useOrderID(targetID)
+
+	// Place the stop first so a target that fills immediately cancels it
+	if !parent.StopLossPrice.IsZero() {
+		reports = append(reports, b.executeOrder(types.TradeSignal{
+			OrderID:   stopID,
+			Symbol:    parent.Symbol,
+			Side:      exitSide,
+			Type:      types.OrderTypeStop,
+			StopPrice: parent.StopLossPrice,
+			Quantity:  a.quantity,
+			OCOGroup:  group,
+			Timestamp: b.now(parent.Symbol),
+		}))
+	}
+	if !parent.TakeProfitPrice.IsZero() {
+		reports = append(reports, b.executeOrder(types.TradeSignal{
+			OrderID:   targetID,
+			Symbol:    parent.Symbol,
+			Side:      exitSide,
+			Type:      types.OrderTypeLimit,
+			Price:     parent.TakeProfitPrice,
+			Quantity:  a.quantity,
+			OCOGroup:  group,
+			Timestamp: b.now(parent.Symbol),
+		}))
+	}
+	return reports
+}
+
+// sweepLosers cancels working orders whose OCO group was executed by
+// another member
+func (b *Broker) sweepLosers() []*types.Execution {
+	var reports []*types.Execution
+	active := b.working[:0]
+	for _, order := range b.working {
+		if !b.isLoser(order.signal) {
+			active = append(active, order)
+			continue
+		}
+
+		winner := b.ocoWinners[order.signal.OCOGroup]
+		log.Printf("Order %s cancelled: OCO group %s executed by %s",
+			order.signal.OrderID, order.signal.OCOGroup, winner)
+		reports = append(reports, &types.Execution{
+			OrderID:   order.signal.OrderID,
+			Status:    types.StatusCancelled,
+			Reason:    fmt.Sprintf("OCO group %s executed by %s", order.signal.OCOGroup, winner),
+			Symbol:    order.signal.Symbol,
+			Side:      order.signal.Side,
+			Timestamp: b.now(order.signal.Symbol),
+			Cancelled: order.remaining,
+		})
+	}
+	b.working = active
+
+	return reports
+}
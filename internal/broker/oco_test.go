@@ -0,0 +1,244 @@
+package broker
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statuses maps order IDs to the status of their last report
+func statuses(reports []*types.Execution) map[string]types.OrderStatus {
+	result := make(map[string]types.OrderStatus)
+	for _, report := range reports {
+		result[report.OrderID] = report.Status
+	}
+	return result
+}
+
+func TestOCOTargetFillCancelsStop(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	broker.executeOrder(types.TradeSignal{
+		OrderID: "stop", Symbol: "BTCUSD", Side: types.SideSell, Type: types.OrderTypeStop,
+		StopPrice: types.NewDecimal(49800), Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+	broker.executeOrder(types.TradeSignal{
+		OrderID: "target", Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50300),
+		Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+	require.Len(t, broker.working, 2)
+
+	moveBook(books, 50300, 50350, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 1)
+	assert.Equal(t, types.StatusFilled, reports[0].Status)
+
+	cancels := broker.followUps()
+	require.Len(t, cancels, 1)
+	assert.Equal(t, "stop", cancels[0].OrderID)
+	assert.Equal(t, types.StatusCancelled, cancels[0].Status)
+	assert.Contains(t, cancels[0].Reason, "target")
+	assert.Empty(t, broker.working)
+
+	// A crash through the old stop does nothing
+	moveBook(books, 49000, 49100, time.Now())
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+}
+
+func TestOCOStopTriggerCancelsTarget(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	broker.executeOrder(types.TradeSignal{
+		OrderID: "target", Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50300),
+		Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+	broker.executeOrder(types.TradeSignal{
+		OrderID: "stop", Symbol: "BTCUSD", Side: types.SideSell, Type: types.OrderTypeStop,
+		StopPrice: types.NewDecimal(49800), Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+
+	moveBook(books, 49700, 49750, time.Now())
+	reports := append(broker.matchWorking("BTCUSD"), broker.followUps()...)
+	assert.Equal(t, map[string]types.OrderStatus{
+		"stop":   types.StatusFilled,
+		"target": types.StatusCancelled,
+	}, statuses(reports))
+	assert.Empty(t, broker.working)
+}
+
+func TestOCOPartialTargetFillResizesStop(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	broker.executeOrder(types.TradeSignal{
+		OrderID: "stop", Symbol: "BTCUSD", Side: types.SideSell, Type: types.OrderTypeStop,
+		StopPrice: types.NewDecimal(49800), Quantity: types.NewDecimal(2.0), OCOGroup: "exit",
+	})
+	broker.executeOrder(types.TradeSignal{
+		OrderID: "target", Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50300),
+		Quantity: types.NewDecimal(2.0), OCOGroup: "exit",
+	})
+
+	// Only 0.5 is bid at the target
+	btc, _ := books.Get("BTCUSD")
+	btc.Update(types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: time.Now(),
+		Bids:      []types.OrderBookEntry{{Price: types.NewDecimal(50300), Quantity: types.NewDecimal(0.5)}},
+		Asks:      []types.OrderBookEntry{{Price: types.NewDecimal(50350), Quantity: types.NewDecimal(5.0)}},
+	})
+	reports := append(broker.matchWorking("BTCUSD"), broker.followUps()...)
+	require.Len(t, reports, 2)
+	assert.Equal(t, types.StatusPartiallyFilled, reports[0].Status)
+	assert.Equal(t, "stop", reports[1].OrderID)
+	assert.Equal(t, types.StatusAcked, reports[1].Status)
+	assert.Equal(t, types.NewDecimal(1.5), reports[1].Leaves)
+
+	stop := broker.findWorking("stop")
+	require.NotNil(t, stop, "the rest of the position keeps its stop")
+	assert.Equal(t, types.NewDecimal(1.5), stop.remaining)
+
+	// The stop sells only what is left and then cancels the target
+	moveBook(books, 49700, 49750, time.Now())
+	reports = append(broker.matchWorking("BTCUSD"), broker.followUps()...)
+	assert.Equal(t, map[string]types.OrderStatus{
+		"stop":   types.StatusFilled,
+		"target": types.StatusCancelled,
+	}, statuses(reports))
+	for _, report := range reports {
+		if report.OrderID == "stop" && report.Status == types.StatusFilled {
+			assert.Equal(t, types.NewDecimal(1.5), report.Quantity)
+		}
+	}
+	assert.Empty(t, broker.working)
+}
+
+func TestOCOTriggerWithoutFillKeepsSibling(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// The stop-limit triggers at 49800 but cannot sell at 49790 or better
+	broker.executeOrder(types.TradeSignal{
+		OrderID: "stop", Symbol: "BTCUSD", Side: types.SideSell, Type: types.OrderTypeStopLimit,
+		StopPrice: types.NewDecimal(49800), Price: types.NewDecimal(49790), Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+	broker.executeOrder(types.TradeSignal{
+		OrderID: "target", Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50300),
+		Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+
+	moveBook(books, 49700, 49750, time.Now())
+	reports := append(broker.matchWorking("BTCUSD"), broker.followUps()...)
+	assert.Equal(t, map[string]types.OrderStatus{"stop": types.StatusAcked}, statuses(reports))
+	assert.NotNil(t, broker.findWorking("target"), "nothing executed, so the target keeps working")
+	assert.Empty(t, broker.ocoWinners)
+}
+
+func TestOCOGroupExecutesOnce(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Two exits race for the same position; only the first one trades
+	first := broker.executeOrder(types.TradeSignal{
+		OrderID: "exit-1", Symbol: "BTCUSD", Side: types.SideSell, Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+	second := broker.executeOrder(types.TradeSignal{
+		OrderID: "exit-2", Symbol: "BTCUSD", Side: types.SideSell, Quantity: types.NewDecimal(1.0), OCOGroup: "exit",
+	})
+
+	assert.Equal(t, types.StatusFilled, first.Status)
+	assert.Equal(t, types.StatusRejected, second.Status)
+	assert.Contains(t, second.Reason, "exit-1")
+	assert.True(t, second.Quantity.IsZero())
+}
+
+func TestBracketOrder(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	entry := broker.executeOrder(types.TradeSignal{
+		OrderID:         "entry",
+		Symbol:          "BTCUSD",
+		Side:            types.SideBuy,
+		Price:           types.NewDecimal(50100),
+		Quantity:        types.NewDecimal(1.0),
+		TakeProfitPrice: types.NewDecimal(50500),
+		StopLossPrice:   types.NewDecimal(49800),
+	})
+	require.Equal(t, types.StatusFilled, entry.Status)
+
+	// Exits activate on the parent fill
+	exits := broker.followUps()
+	assert.Equal(t, map[string]types.OrderStatus{
+		"entry-SL": types.StatusAcked,
+		"entry-TP": types.StatusAcked,
+	}, statuses(exits))
+	require.Len(t, broker.working, 2)
+	for _, order := range broker.working {
+		assert.Equal(t, types.SideSell, order.signal.Side)
+		assert.Equal(t, types.NewDecimal(1.0), order.remaining)
+	}
+
+	// Stop fires, target is cancelled
+	moveBook(books, 49750, 49800, time.Now())
+	reports := append(broker.matchWorking("BTCUSD"), broker.followUps()...)
+	assert.Equal(t, map[string]types.OrderStatus{
+		"entry-SL": types.StatusFilled,
+		"entry-TP": types.StatusCancelled,
+	}, statuses(reports))
+	assert.Empty(t, broker.working)
+}
+
+func TestBracketExitsFollowPartialFills(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Only 1.0 of 2.0 fills at 50100; the rest rests
+	entry := broker.executeOrder(types.TradeSignal{
+		OrderID:         "entry",
+		Symbol:          "BTCUSD",
+		Side:            types.SideBuy,
+		Price:           types.NewDecimal(50100),
+		Quantity:        types.NewDecimal(2.0),
+		TakeProfitPrice: types.NewDecimal(50500),
+		StopLossPrice:   types.NewDecimal(49800),
+	})
+	require.Equal(t, types.StatusPartiallyFilled, entry.Status)
+	broker.followUps()
+
+	stop := broker.findWorking("entry-SL")
+	require.NotNil(t, stop)
+	assert.Equal(t, types.NewDecimal(1.0), stop.remaining)
+
+	// The rest of the parent fills and the exits grow with it
+	moveBook(books, 49990, 50050, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 1)
+	assert.Equal(t, "entry", reports[0].OrderID)
+	assert.Equal(t, types.StatusFilled, reports[0].Status)
+
+	broker.followUps()
+	assert.Equal(t, types.NewDecimal(2.0), broker.findWorking("entry-SL").remaining)
+	assert.Equal(t, types.NewDecimal(2.0), broker.findWorking("entry-TP").remaining)
+}
+
+func TestBracketValidation(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Stop above target on a buy bracket
+	execution := broker.executeOrder(types.TradeSignal{
+		Symbol:          "BTCUSD",
+		Side:            types.SideBuy,
+		Quantity:        types.NewDecimal(1.0),
+		TakeProfitPrice: types.NewDecimal(49800),
+		StopLossPrice:   types.NewDecimal(50500),
+	})
+	assert.Equal(t, types.StatusRejected, execution.Status)
+	assert.Empty(t, broker.followUps())
+}
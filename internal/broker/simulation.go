@@ -0,0 +1,306 @@
+package broker
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// depthBand is the band around mid whose depth DepthSlippage measures orders
+// against
+const depthBand = 0.01
+
+// ExecutionModel simulates the delay between a strategy sending a request
+// and the broker acting on it, and slippage beyond the displayed book. The
+// zero value acts on requests immediately without extra slippage.
+type ExecutionModel struct {
+	Latency  LatencyModel  // nil for no order-entry latency
+	Slippage SlippageModel // nil for no extra slippage
+	Seed     int64         // seeds the random latency samples
+}
+
+// LatencyModel draws the order-entry latency of a request
+type LatencyModel interface {
+	Sample(rng *rand.Rand) time.Duration
+}
+
+// FixedLatency delays every request by the same amount
+type FixedLatency struct {
+	Delay time.Duration
+}
+
+// Sample implements LatencyModel
+func (l FixedLatency) Sample(*rand.Rand) time.Duration { return l.Delay }
+
+// UniformLatency delays requests by a duration drawn uniformly from
+// [Min, Max]
+type UniformLatency struct {
+	Min, Max time.Duration
+}
+
+// Sample implements LatencyModel
+func (l UniformLatency) Sample(rng *rand.Rand) time.Duration {
+	if l.Max <= l.Min {
+		return l.Min
+	}
+	return l.Min + time.Duration(rng.Int63n(int64(l.Max-l.Min)+1))
+}
+
+// SampledLatency delays requests by one of a set of observed latencies,
+// each equally likely
+type SampledLatency struct {
+	Samples []time.Duration
+}
+
+// Sample implements LatencyModel
+func (l SampledLatency) Sample(rng *rand.Rand) time.Duration {
+	if len(l.Samples) == 0 {
+		return 0
+	}
+	return l.Samples[rng.Intn(len(l.Samples))]
+}
+
+// SlippageModel returns the fraction of price an order that takes liquidity
+// loses beyond the prices it walks in the book
+type SlippageModel interface {
+	Slippage(ob *orderbook.OrderBook, side types.Side, quantity types.Decimal) float64
+}
+
+// FixedSlippage costs every taking order the same number of basis points
+type FixedSlippage struct {
+	Bps float64
+}
+
+// Slippage implements SlippageModel
+func (s FixedSlippage) Slippage(*orderbook.OrderBook, types.Side, types.Decimal) float64 {
+	return s.Bps / 10000
+}
+
+// DepthSlippage costs an order Bps basis points for taking all of the depth
+// within 1% of mid on its side, and proportionally less for smaller orders
+type DepthSlippage struct {
+	Bps float64
+}
+
+// Slippage implements SlippageModel
+func (s DepthSlippage) Slippage(ob *orderbook.OrderBook, side types.Side, quantity types.Decimal) float64 {
+	bidDepth, askDepth := ob.GetLiquidity(0, depthBand)
+	depth := askDepth
+	if side == types.SideSell {
+		depth = bidDepth
+	}
+	if !depth.IsPositive() {
+		return s.Bps / 10000
+	}
+	return s.Bps / 10000 * quantity.Float64() / depth.Float64()
+}
+
+// ParseLatency builds a latency model from a specification:
+//
+//	none                    no latency
+//	fixed=50ms              every request 50ms late
+//	uniform=10ms/100ms      uniformly between 10ms and 100ms
+//	sampled=5ms,8ms,120ms   one of the listed latencies
+func ParseLatency(spec string) (LatencyModel, error) {
+	kind, params, _ := strings.Cut(strings.TrimSpace(spec), "=")
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "fixed":
+		delay, err := time.ParseDuration(params)
+		if err != nil {
+			return nil, fmt.Errorf("fixed latency: %w", err)
+		}
+		return FixedLatency{Delay: delay}, nil
+	case "uniform":
+		low, high, found := strings.Cut(params, "/")
+		if !found {
+			return nil, fmt.Errorf("uniform latency %q is not MIN/MAX", params)
+		}
+		min, err := time.ParseDuration(low)
+		if err != nil {
+			return nil, fmt.Errorf("uniform latency: %w", err)
+		}
+		max, err := time.ParseDuration(high)
+		if err != nil {
+			return nil, fmt.Errorf("uniform latency: %w", err)
+		}
+		if max < min {
+			return nil, fmt.Errorf("uniform latency maximum %v is below minimum %v", max, min)
+		}
+		return UniformLatency{Min: min, Max: max}, nil
+	case "sampled":
+		var samples []time.Duration
+		for _, text := range strings.Split(params, ",") {
+			sample, err := time.ParseDuration(strings.TrimSpace(text))
+			if err != nil {
+				return nil, fmt.Errorf("sampled latency: %w", err)
+			}
+			samples = append(samples, sample)
+		}
+		return SampledLatency{Samples: samples}, nil
+	}
+	return nil, fmt.Errorf("unknown latency model %q (want none, fixed, uniform or sampled)", kind)
+}
+
+// ParseSlippage builds a slippage model from a specification:
+//
+//	none       no extra slippage
+//	bps=2      2 basis points on every taking order
+//	depth=50   50 basis points for taking all depth within 1% of mid
+func ParseSlippage(spec string) (SlippageModel, error) {
+	kind, params, _ := strings.Cut(strings.TrimSpace(spec), "=")
+	if kind == "" || kind == "none" {
+		return nil, nil
+	}
+	rate, err := strconv.ParseFloat(params, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s slippage: %w", kind, err)
+	}
+	switch kind {
+	case "bps":
+		return FixedSlippage{Bps: rate}, nil
+	case "depth":
+		return DepthSlippage{Bps: rate}, nil
+	}
+	return nil, fmt.Errorf("unknown slippage model %q (want none, bps or depth)", kind)
+}
+
+// pendingSignal is a request still travelling to the broker
+type pendingSignal struct {
+	signal  types.TradeSignal
+	arrival time.Time // feed time at which the broker acts on it
+}
+
+// SetExecutionModel configures latency and slippage for subsequent requests
+func (b *Broker) SetExecutionModel(model ExecutionModel) {
+	b.execution = model
+	b.rng = rand.New(rand.NewSource(model.Seed))
+}
+
+// now returns the feed clock: the time of the last update applied to a
+// symbol's book, or the wall clock when the book has not been updated
+func (b *Broker) now(symbol string) time.Time {
+	if ob, exists := b.books.Get(symbol); exists {
+		if updated := ob.LastUpdated(); !updated.IsZero() {
+			return updated
+		}
+	}
+	return time.Now()
+}
+
+// delay queues a request until its latency has elapsed on the feed clock.
+// It returns false when the request should be handled now: without a
+// latency model, or without a feed clock to measure it on because the
+// broker has no book updates, the feed has ended or the symbol has no book.
+// Each reason latency is skipped for is logged once.
+func (b *Broker) delay(signal types.TradeSignal, feedRunning bool) bool {
+	if b.execution.Latency == nil {
+		return false
+	}
+	if _, exists := b.books.Get(signal.Symbol); !feedRunning || !exists {
+		switch {
+		case b.bookUpdates == nil:
+			b.skipLatency("the broker has no book updates")
+		case !feedRunning:
+			b.skipLatency("the feed has ended")
+		default:
+			b.skipLatency("there is no order book for symbol " + signal.Symbol)
+		}
+		return false
+	}
+
+	latency := b.execution.Latency.Sample(b.rng)
+	arrival := b.now(signal.Symbol).Add(latency)
+	// Requests for one order arrive in the order they were sent, so a cancel
+	// or replace cannot overtake the order it refers to
+	if signal.OrderID != "" {
+		for _, p := range b.pending {
+			if p.signal.OrderID == signal.OrderID && p.arrival.After(arrival) {
+				arrival = p.arrival
+			}
+		}
+	}
+	// Keep the queue in arrival order; equal arrivals stay in send order
+	i := sort.Search(len(b.pending), func(i int) bool { return b.pending[i].arrival.After(arrival) })
+	b.pending = append(b.pending, pendingSignal{})
+	copy(b.pending[i+1:], b.pending[i:])
+	b.pending[i] = pendingSignal{signal: signal, arrival: arrival}
+
+	log.Printf("Request %s %s delayed %v until %s", signal.Action, signal.OrderID, latency, arrival.Format("15:04:05.000"))
+	return true
+}
+
+// skipLatency logs the first request acted on without latency for a reason
+func (b *Broker) skipLatency(reason string) {
+	if b.latencySkips[reason] {
+		return
+	}
+	b.latencySkips[reason] = true
+	log.Printf("Order-entry latency not applied: %s", reason)
+}
+
+// arrived removes and returns the queued requests for a symbol whose
+// latency has elapsed by the feed time of its book, or every queued request
+// when all is set
+func (b *Broker) arrived(symbol string, all bool) []types.TradeSignal {
+	var ready []types.TradeSignal
+	now := b.now(symbol)
+	waiting := b.pending[:0]
+	for _, p := range b.pending {
+		if all || p.signal.Symbol == symbol && !p.arrival.After(now) {
+			ready = append(ready, p.signal)
+			continue
+		}
+		waiting = append(waiting, p)
+	}
+	b.pending = waiting
+	return ready
+}
+
+// slip moves the prices of fills taken from the book against the order by
+// the slippage model, rounding them away from the order to the tick size. A
+// limit order never fills beyond its limit.
+func (b *Broker) slip(ob *orderbook.OrderBook, signal types.TradeSignal, fills []types.Fill) []types.Fill {
+	if b.execution.Slippage == nil || len(fills) == 0 {
+		return fills
+	}
+
+	fraction := b.execution.Slippage.Slippage(ob, signal.Side, signal.Quantity)
+	if fraction <= 0 {
+		return fills
+	}
+	factor := types.NewDecimal(1 + fraction)
+	if signal.Side == types.SideSell {
+		factor = types.NewDecimal(1 - fraction)
+	}
+	tick := b.instruments[signal.Symbol].TickSize
+
+	slipped := make([]types.Fill, len(fills))
+	for i, fill := range fills {
+		price := fill.Price.Mul(factor)
+		if tick.IsPositive() {
+			rounded := price.Truncate(tick)
+			if signal.Side == types.SideBuy && !rounded.Equal(price) {
+				rounded = rounded.Add(tick)
+			}
+			price = rounded
+		}
+		if signal.Type == types.OrderTypeLimit && !b.limitFallback {
+			if signal.Side == types.SideBuy {
+				price = types.MinDecimal(price, signal.Price)
+			} else {
+				price = types.MaxDecimal(price, signal.Price)
+			}
+		}
+		slipped[i] = types.Fill{Price: price, Quantity: fill.Quantity}
+	}
+	log.Printf("Slippage of %.2fbp applied to %s %s", fraction*10000, signal.OrderID, signal.Side)
+	return slipped
+}
@@ -0,0 +1,176 @@
+package broker
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLatency(t *testing.T) {
+	model, err := ParseLatency("none")
+	require.NoError(t, err)
+	assert.Nil(t, model)
+
+	model, err = ParseLatency("fixed=50ms")
+	require.NoError(t, err)
+	assert.Equal(t, FixedLatency{Delay: 50 * time.Millisecond}, model)
+
+	model, err = ParseLatency("uniform=10ms/100ms")
+	require.NoError(t, err)
+	assert.Equal(t, UniformLatency{Min: 10 * time.Millisecond, Max: 100 * time.Millisecond}, model)
+
+	model, err = ParseLatency("sampled=5ms, 8ms,120ms")
+	require.NoError(t, err)
+	assert.Equal(t, SampledLatency{Samples: []time.Duration{5 * time.Millisecond, 8 * time.Millisecond, 120 * time.Millisecond}}, model)
+
+	for _, spec := range []string{"normal=5ms", "fixed=abc", "uniform=10ms", "uniform=100ms/10ms", "sampled=1ms,x"} {
+		_, err := ParseLatency(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestParseSlippage(t *testing.T) {
+	model, err := ParseSlippage("bps=2")
+	require.NoError(t, err)
+	assert.Equal(t, FixedSlippage{Bps: 2}, model)
+
+	model, err = ParseSlippage("depth=50")
+	require.NoError(t, err)
+	assert.Equal(t, DepthSlippage{Bps: 50}, model)
+
+	for _, spec := range []string{"pct=1", "bps=", "depth=abc"} {
+		_, err := ParseSlippage(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestLatencySamplesAreSeeded(t *testing.T) {
+	model := UniformLatency{Min: 10 * time.Millisecond, Max: 100 * time.Millisecond}
+	first, second := rand.New(rand.NewSource(7)), rand.New(rand.NewSource(7))
+	for i := 0; i < 100; i++ {
+		sample := model.Sample(first)
+		assert.Equal(t, sample, model.Sample(second))
+		assert.GreaterOrEqual(t, sample, model.Min)
+		assert.LessOrEqual(t, sample, model.Max)
+	}
+}
+
+func TestLatencyFillsAgainstLaterBook(t *testing.T) {
+	books := orderbook.NewRegistry()
+	books.GetOrCreate("BTCUSD")
+	start := time.Date(2025, 8, 30, 10, 0, 0, 0, time.UTC)
+	moveBook(books, 50000, 50100, start)
+
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetExecutionModel(ExecutionModel{Latency: FixedLatency{Delay: 150 * time.Millisecond}})
+
+	signal := types.TradeSignal{OrderID: "S1", Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0)}
+	require.True(t, broker.delay(signal, true))
+
+	// 100ms later the order is still on its way
+	moveBook(books, 50050, 50150, start.Add(100*time.Millisecond))
+	assert.Empty(t, broker.arrived("BTCUSD", false))
+
+	// By the next update it has arrived and fills against that book
+	moveBook(books, 50100, 50200, start.Add(200*time.Millisecond))
+	ready := broker.arrived("BTCUSD", false)
+	require.Len(t, ready, 1)
+	report := broker.handleSignal(ready[0])
+	assert.Equal(t, types.StatusFilled, report.Status)
+	assert.Equal(t, types.NewDecimal(50200), report.Price)
+	assert.Equal(t, start.Add(200*time.Millisecond), report.Timestamp, "reports carry the feed clock")
+
+	// Once the feed has stopped requests are handled at once
+	assert.False(t, broker.delay(signal, false))
+}
+
+func TestPendingRequestsKeepArrivalOrder(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetExecutionModel(ExecutionModel{Latency: SampledLatency{Samples: []time.Duration{time.Second}}})
+	broker.delay(types.TradeSignal{OrderID: "slow", Symbol: "BTCUSD"}, true)
+
+	broker.SetExecutionModel(ExecutionModel{Latency: FixedLatency{}})
+	broker.delay(types.TradeSignal{OrderID: "fast", Symbol: "BTCUSD"}, true)
+
+	ready := broker.arrived("", true)
+	require.Len(t, ready, 2)
+	assert.Equal(t, "fast", ready[0].OrderID)
+	assert.Equal(t, "slow", ready[1].OrderID)
+	assert.Empty(t, broker.pending)
+}
+
+func TestPendingRequestsForAnOrderStayInSendOrder(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetExecutionModel(ExecutionModel{Latency: FixedLatency{Delay: time.Second}})
+	broker.delay(types.TradeSignal{OrderID: "o1", Action: types.ActionNew, Symbol: "BTCUSD"}, true)
+
+	// A faster cancel of o1 still arrives after o1, while other orders may
+	// overtake it
+	broker.SetExecutionModel(ExecutionModel{Latency: FixedLatency{Delay: time.Millisecond}})
+	broker.delay(types.TradeSignal{OrderID: "o1", Action: types.ActionCancel, Symbol: "BTCUSD"}, true)
+	broker.delay(types.TradeSignal{OrderID: "o2", Action: types.ActionNew, Symbol: "BTCUSD"}, true)
+
+	ready := broker.arrived("", true)
+	require.Len(t, ready, 3)
+	assert.Equal(t, "o2", ready[0].OrderID)
+	assert.Equal(t, types.ActionNew, ready[1].Action)
+	assert.Equal(t, "o1", ready[1].OrderID)
+	assert.Equal(t, types.ActionCancel, ready[2].Action)
+}
+
+func TestLatencySkippedWithoutFeedClock(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetExecutionModel(ExecutionModel{Latency: FixedLatency{Delay: time.Second}})
+	signal := types.TradeSignal{OrderID: "S1", Symbol: "BTCUSD"}
+
+	// Without book updates there is no clock to measure latency on
+	assert.False(t, broker.delay(signal, false))
+	assert.False(t, broker.delay(signal, false))
+	assert.Equal(t, map[string]bool{"the broker has no book updates": true}, broker.latencySkips)
+
+	broker.SetBookUpdates(make(chan types.OrderBookSnapshot))
+	assert.False(t, broker.delay(types.TradeSignal{OrderID: "S2", Symbol: "XYZ"}, true))
+	assert.True(t, broker.latencySkips["there is no order book for symbol XYZ"])
+	assert.False(t, broker.delay(signal, false))
+	assert.True(t, broker.latencySkips["the feed has ended"])
+	assert.Empty(t, broker.pending)
+
+	assert.True(t, broker.delay(signal, true))
+	assert.Len(t, broker.pending, 1)
+}
+
+func TestSlippage(t *testing.T) {
+	broker := New(setupTestBooks(), make(chan types.TradeSignal), make(chan types.Execution, 10))
+	broker.SetInstrument(types.Instrument{Symbol: "BTCUSD", TickSize: types.NewDecimal(1)})
+	broker.SetExecutionModel(ExecutionModel{Slippage: FixedSlippage{Bps: 10}})
+
+	// 50100 * 1.001 = 50150.1, rounded up to the tick
+	buy := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1.0)})
+	assert.Equal(t, types.NewDecimal(50151), buy.Price)
+
+	// 50000 * 0.999 = 49950
+	sell := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideSell, Quantity: types.NewDecimal(1.0)})
+	assert.Equal(t, types.NewDecimal(49950), sell.Price)
+
+	// A limit order never slips beyond its limit
+	limit := broker.executeOrder(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(50120), Quantity: types.NewDecimal(1.0)})
+	assert.Equal(t, types.NewDecimal(50120), limit.Price)
+}
+
+func TestDepthSlippageScalesWithSize(t *testing.T) {
+	books := setupTestBooks()
+	ob, _ := books.Get("BTCUSD")
+	model := DepthSlippage{Bps: 45}
+
+	// 4.5 BTC of asks lie within 1% of mid
+	assert.InDelta(t, 0.001, model.Slippage(ob, types.SideBuy, types.NewDecimal(1.0)), 1e-12)
+	assert.InDelta(t, 0.0045, model.Slippage(ob, types.SideBuy, types.NewDecimal(4.5)), 1e-12)
+}
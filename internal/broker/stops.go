@@ -0,0 +1,136 @@
+package broker
+
+import (
+	"log"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// holdStop accepts a conditional order and keeps it in the working-order
+// book until a book update triggers it
+func (b *Broker) holdStop(ob *orderbook.OrderBook, signal types.TradeSignal) *types.Execution {
+	order := &workingOrder{signal: signal, remaining: signal.Quantity, stopPrice: signal.StopPrice}
+	if signal.Type == types.OrderTypeTrailingStop {
+		if touch, ok := stopTouch(ob, signal.Side); ok {
+			order.trail(touch)
+		}
+	}
+	b.working = append(b.working, order)
+
+	log.Printf("%s order %s held: %s %.4f, stop %.2f", signal.Type, signal.OrderID,
+		signal.Side, signal.Quantity, order.stopPrice)
+	return &types.Execution{
+		OrderID:   signal.OrderID,
+		Status:    types.StatusAcked,
+		Symbol:    signal.Symbol,
+		Side:      signal.Side,
+		Timestamp: b.now(signal.Symbol),
+		Leaves:    signal.Quantity,
+	}
+}
+
+// triggerStops moves trailing stops with the book, releases conditional
+// orders whose stop price has been reached and executes them. Each
+// triggered order reports a trigger event followed by the report of the
+// resulting market or limit order.
+func (b *Broker) triggerStops(ob *orderbook.OrderBook, symbol string) []*types.Execution {
+	var triggered []*workingOrder
+	for _, order := range b.working {
+		if order.signal.Symbol != symbol || !order.signal.Type.IsConditional() || b.isLoser(order.signal) {
+			continue
+		}
+
+		touch, ok := stopTouch(ob, order.signal.Side)
+		if !ok {
+			continue
+		}
+		if order.signal.Type == types.OrderTypeTrailingStop {
+			order.trail(touch)
+		}
+		if order.reached(touch) {
+			triggered = append(triggered, order)
+		}
+	}
+
+	// Triggered orders execute one at a time and only a fill claims the OCO
+	// group, so a sibling that triggered on the same update is left for the
+	// sweep once an earlier one completes, and is resized by a partial fill
+	var executions []*types.Execution
+	for _, order := range triggered {
+		if b.isLoser(order.signal) {
+			continue
+		}
+		b.removeWorking(order.signal.OrderID)
+		log.Printf("%s order %s triggered: stop %.2f reached", order.signal.Type,
+			order.signal.OrderID, order.stopPrice)
+		executions = append(executions, &types.Execution{
+			OrderID:   order.signal.OrderID,
+			Status:    types.StatusTriggered,
+			Symbol:    order.signal.Symbol,
+			Side:      order.signal.Side,
+			Price:     order.stopPrice,
+			Timestamp: b.now(order.signal.Symbol),
+			Leaves:    order.remaining,
+		})
+		executions = append(executions, b.executeOrder(order.released()))
+	}
+	return executions
+}
+
+// stopTouch returns the price a stop on side watches: the best bid for
+// sell stops and the best ask for buy stops
+func stopTouch(ob *orderbook.OrderBook, side types.Side) (types.Decimal, bool) {
+	if side == types.SideSell {
+		price, _, ok := ob.GetBestBid()
+		return price, ok
+	}
+	price, _, ok := ob.GetBestAsk()
+	return price, ok
+}
+
+// trail moves a trailing stop's mark to a better touch price and resets the
+// stop price behind it. The stop never moves against the order.
+func (o *workingOrder) trail(touch types.Decimal) {
+	sell := o.signal.Side == types.SideSell
+	if !o.mark.IsZero() && (sell && touch.LessThanOrEqual(o.mark) || !sell && touch.GreaterThanOrEqual(o.mark)) {
+		return
+	}
+	o.mark = touch
+
+	distance := o.signal.TrailAmount
+	if distance.IsZero() {
+		distance = touch.Mul(o.signal.TrailPercent)
+	}
+	if sell {
+		o.stopPrice = touch.Sub(distance)
+	} else {
+		o.stopPrice = touch.Add(distance)
+	}
+}
+
+// reached reports whether the touch price has traded through the stop
+func (o *workingOrder) reached(touch types.Decimal) bool {
+	if o.signal.Side == types.SideSell {
+		return touch.LessThanOrEqual(o.stopPrice)
+	}
+	return touch.GreaterThanOrEqual(o.stopPrice)
+}
+
+// released returns the order a triggered stop becomes: a limit order at
+// Price for stop-limits and an immediate market order otherwise
+func (o *workingOrder) released() types.TradeSignal {
+	signal := o.signal
+	signal.Quantity = o.remaining
+	signal.StopPrice = types.Zero
+	signal.TrailAmount = types.Zero
+	signal.TrailPercent = types.Zero
+
+	if signal.Type == types.OrderTypeStopLimit {
+		signal.Type = types.OrderTypeLimit
+		return signal
+	}
+	signal.Type = types.OrderTypeMarket
+	signal.Price = types.Zero
+	signal.TimeInForce = types.TimeInForceIOC
+	return signal
+}
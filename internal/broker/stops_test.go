@@ -0,0 +1,213 @@
+package broker
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopMarketOrder(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	ack := broker.executeOrder(types.TradeSignal{
+		OrderID:   "stop-1",
+		Symbol:    "BTCUSD",
+		Side:      types.SideSell,
+		Type:      types.OrderTypeStop,
+		StopPrice: types.NewDecimal(49800),
+		Quantity:  types.NewDecimal(1.0),
+	})
+	require.Equal(t, types.StatusAcked, ack.Status)
+	assert.Equal(t, types.NewDecimal(1.0), ack.Leaves)
+
+	// Bid above the stop: held
+	moveBook(books, 49850, 49900, time.Now())
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+	require.Len(t, broker.working, 1)
+
+	// Bid trades through the stop: trigger event, then a market fill
+	moveBook(books, 49750, 49800, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 2)
+	assert.Equal(t, types.StatusTriggered, reports[0].Status)
+	assert.Equal(t, "stop-1", reports[0].OrderID)
+	assert.Equal(t, types.NewDecimal(49800), reports[0].Price)
+	assert.True(t, reports[0].Quantity.IsZero())
+
+	assert.Equal(t, types.StatusFilled, reports[1].Status)
+	assert.Equal(t, "stop-1", reports[1].OrderID)
+	assert.Equal(t, types.NewDecimal(49750), reports[1].Price)
+	assert.Equal(t, types.NewDecimal(1.0), reports[1].Quantity)
+	assert.Empty(t, broker.working)
+}
+
+func TestStopLimitOrder(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Buy stop above the market with a limit just above the stop
+	broker.executeOrder(types.TradeSignal{
+		OrderID:   "stop-1",
+		Symbol:    "BTCUSD",
+		Side:      types.SideBuy,
+		Type:      types.OrderTypeStopLimit,
+		StopPrice: types.NewDecimal(50200),
+		Price:     types.NewDecimal(50250),
+		Quantity:  types.NewDecimal(1.0),
+	})
+
+	// Ask gaps through the limit: triggers and rests as a limit order
+	moveBook(books, 50250, 50300, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 2)
+	assert.Equal(t, types.StatusTriggered, reports[0].Status)
+	assert.Equal(t, types.StatusAcked, reports[1].Status)
+	require.Len(t, broker.working, 1)
+	assert.Equal(t, types.OrderTypeLimit, broker.working[0].signal.Type)
+
+	// Market comes back to the limit and the order fills there
+	moveBook(books, 50200, 50240, time.Now())
+	reports = broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 1)
+	assert.Equal(t, types.StatusFilled, reports[0].Status)
+	assert.Equal(t, types.NewDecimal(50240), reports[0].Price)
+	assert.Empty(t, broker.working)
+}
+
+func TestTriggeredStopLimitMatchesOncePerUpdate(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	broker.executeOrder(types.TradeSignal{
+		OrderID:   "stop-1",
+		Symbol:    "BTCUSD",
+		Side:      types.SideSell,
+		Type:      types.OrderTypeStopLimit,
+		StopPrice: types.NewDecimal(49800),
+		Price:     types.NewDecimal(49700),
+		Quantity:  types.NewDecimal(8.0),
+	})
+
+	// Only 5 are bid above the limit: the rest works for a later update
+	moveBook(books, 49750, 49800, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 2)
+	assert.Equal(t, types.StatusTriggered, reports[0].Status)
+	assert.Equal(t, types.StatusPartiallyFilled, reports[1].Status)
+	assert.Equal(t, types.NewDecimal(5.0), reports[1].Quantity)
+	assert.Equal(t, types.NewDecimal(3.0), reports[1].Leaves)
+	require.Len(t, broker.working, 1)
+	assert.Equal(t, types.NewDecimal(3.0), broker.working[0].remaining)
+
+	moveBook(books, 49750, 49800, time.Now())
+	reports = broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 1)
+	assert.Equal(t, types.StatusFilled, reports[0].Status)
+	assert.Equal(t, types.NewDecimal(3.0), reports[0].Quantity)
+}
+
+func TestTrailingStopAmount(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Sell stop trailing the 50000 bid by 100
+	broker.executeOrder(types.TradeSignal{
+		OrderID:     "trail-1",
+		Symbol:      "BTCUSD",
+		Side:        types.SideSell,
+		Type:        types.OrderTypeTrailingStop,
+		TrailAmount: types.NewDecimal(100),
+		Quantity:    types.NewDecimal(1.0),
+	})
+	require.Len(t, broker.working, 1)
+	assert.Equal(t, types.NewDecimal(49900), broker.working[0].stopPrice)
+
+	// Rally raises the stop; a pullback does not lower it
+	moveBook(books, 50500, 50550, time.Now())
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+	assert.Equal(t, types.NewDecimal(50400), broker.working[0].stopPrice)
+
+	moveBook(books, 50450, 50500, time.Now())
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+	assert.Equal(t, types.NewDecimal(50400), broker.working[0].stopPrice)
+
+	moveBook(books, 50400, 50450, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 2)
+	assert.Equal(t, types.StatusTriggered, reports[0].Status)
+	assert.Equal(t, types.NewDecimal(50400), reports[0].Price)
+	assert.Equal(t, types.StatusFilled, reports[1].Status)
+	assert.Equal(t, types.NewDecimal(50400), reports[1].Price)
+}
+
+func TestTrailingStopPercent(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	// Buy stop trailing the 50100 ask by 1%
+	broker.executeOrder(types.TradeSignal{
+		Symbol:       "BTCUSD",
+		Side:         types.SideBuy,
+		Type:         types.OrderTypeTrailingStop,
+		TrailPercent: types.NewDecimal(0.01),
+		Quantity:     types.NewDecimal(1.0),
+	})
+	require.Len(t, broker.working, 1)
+	assert.Equal(t, types.NewDecimal(50601), broker.working[0].stopPrice)
+
+	// Falling ask drags the stop down
+	moveBook(books, 49900, 50000, time.Now())
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+	assert.Equal(t, types.NewDecimal(50500), broker.working[0].stopPrice)
+
+	moveBook(books, 50450, 50500, time.Now())
+	reports := broker.matchWorking("BTCUSD")
+	require.Len(t, reports, 2)
+	assert.Equal(t, types.StatusTriggered, reports[0].Status)
+	assert.Equal(t, types.StatusFilled, reports[1].Status)
+	assert.Equal(t, types.NewDecimal(50500), reports[1].Price)
+}
+
+func TestStopOrderCancel(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	broker.executeOrder(types.TradeSignal{
+		OrderID:   "stop-1",
+		Symbol:    "BTCUSD",
+		Side:      types.SideSell,
+		Type:      types.OrderTypeStop,
+		StopPrice: types.NewDecimal(49800),
+		Quantity:  types.NewDecimal(1.0),
+	})
+	cancel := broker.handleSignal(types.TradeSignal{OrderID: "stop-1", Action: types.ActionCancel})
+	assert.Equal(t, types.StatusCancelled, cancel.Status)
+
+	moveBook(books, 49000, 49100, time.Now())
+	assert.Empty(t, broker.matchWorking("BTCUSD"))
+}
+
+func TestStopOrderValidation(t *testing.T) {
+	books := setupTestBooks()
+	broker := New(books, make(chan types.TradeSignal), make(chan types.Execution))
+
+	tests := []types.TradeSignal{
+		{Type: types.OrderTypeStop}, // no stop price
+		{Type: types.OrderTypeStop, StopPrice: types.NewDecimal(49800), Price: types.NewDecimal(49700)}, // stop with limit
+		{Type: types.OrderTypeStopLimit, StopPrice: types.NewDecimal(49800)},                            // stop-limit without limit
+		{Type: types.OrderTypeTrailingStop},                                                             // no trail
+		{Type: types.OrderTypeTrailingStop, TrailAmount: types.NewDecimal(1), TrailPercent: types.NewDecimal(0.01)},
+		{Type: types.OrderTypeStop, StopPrice: types.NewDecimal(49800), TimeInForce: types.TimeInForceIOC},
+	}
+	for _, signal := range tests {
+		signal.Symbol = "BTCUSD"
+		signal.Side = types.SideSell
+		signal.Quantity = types.NewDecimal(1.0)
+		assert.Equal(t, types.StatusRejected, broker.executeOrder(signal).Status, "%+v", signal)
+	}
+	assert.Empty(t, broker.working)
+}
@@ -2,23 +2,42 @@ package engine
 
 import (
 	"log"
+	"sync"
 	"trading-engine/internal/orderbook"
 	"trading-engine/internal/types"
 )
 
+// SequenceStats tracks sequence number checking for a single symbol
+type SequenceStats struct {
+	LastSequence uint64 // sequence of the last applied message
+	Gaps         int    // messages skipped ahead of the expected sequence
+	Duplicates   int    // messages repeating the last applied sequence
+	OutOfOrder   int    // messages older than the last applied sequence
+	Discarded    int    // deltas dropped while waiting for a snapshot
+	Invalid      int    // malformed deltas rejected by the book
+	Resyncs      int    // snapshots that brought a stale book back in sync
+	Stale        bool   // book is invalid until the next full snapshot
+	synced       bool   // a snapshot has been applied at least once
+}
+
 // Engine processes order book updates and maintains the current state
 type Engine struct {
-	orderbook *orderbook.OrderBook
-	updates   <-chan types.OrderBookSnapshot
-	done      chan<- bool
+	books   *orderbook.Registry
+	updates <-chan types.MarketData
+	done    chan<- bool
+
+	mu          sync.RWMutex
+	stats       map[string]*SequenceStats
+	subscribers []chan types.OrderBookSnapshot
 }
 
 // New creates a new engine instance
-func New(ob *orderbook.OrderBook, updates <-chan types.OrderBookSnapshot, done chan<- bool) *Engine {
+func New(books *orderbook.Registry, updates <-chan types.MarketData, done chan<- bool) *Engine {
 	return &Engine{
-		orderbook: ob,
-		updates:   updates,
-		done:      done,
+		books:   books,
+		updates: updates,
+		done:    done,
+		stats:   make(map[string]*SequenceStats),
 	}
 }
 
@@ -28,26 +47,167 @@ func (e *Engine) Start() {
 
 	updateCount := 0
 
-	for snapshot := range e.updates {
+	for msg := range e.updates {
+		if !e.process(msg) {
+			continue
+		}
 		updateCount++
-
-		// Update the order book
-		e.orderbook.Update(snapshot)
+		e.publish(msg.Symbol(), msg.Sequence())
 
 		// Log periodic updates
 		if updateCount%10 == 0 {
 			log.Printf("Processed %d order book updates", updateCount)
 
-			if bid, bidQty, bidExists := e.orderbook.GetBestBid(); bidExists {
-				if ask, askQty, askExists := e.orderbook.GetBestAsk(); askExists {
-					spread, _ := e.orderbook.GetSpread()
-					log.Printf("Best: %.2f(%.2f) - %.2f(%.2f), Spread: %.2f",
-						bid, bidQty, ask, askQty, spread)
+			ob := e.books.GetOrCreate(msg.Symbol())
+			if bid, bidQty, bidExists := ob.GetBestBid(); bidExists {
+				if ask, askQty, askExists := ob.GetBestAsk(); askExists {
+					spread, _ := ob.GetSpread()
+					log.Printf("%s best: %.2f(%.2f) - %.2f(%.2f), Spread: %.2f",
+						msg.Symbol(), bid, bidQty, ask, askQty, spread)
 				}
 			}
 		}
 	}
 
-	log.Printf("Engine finished processing %d total updates", updateCount)
+	log.Printf("Engine finished processing %d total updates (%d sequence gaps)", updateCount, e.GapCount())
+	for _, subscriber := range e.subscribers {
+		close(subscriber)
+	}
 	e.done <- true
 }
+
+// Subscribe returns a channel that receives a snapshot of a symbol's book
+// after every applied update. Updates are dropped rather than blocking the
+// engine when the subscriber falls behind. The channel is closed when the
+// engine finishes. Subscribe must be called before Start.
+func (e *Engine) Subscribe(buffer int) <-chan types.OrderBookSnapshot {
+	subscriber := make(chan types.OrderBookSnapshot, buffer)
+	e.subscribers = append(e.subscribers, subscriber)
+	return subscriber
+}
+
+// publish sends the current state of a symbol's book to all subscribers
+func (e *Engine) publish(symbol string, seq uint64) {
+	if len(e.subscribers) == 0 {
+		return
+	}
+
+	snapshot := e.books.GetOrCreate(symbol).Snapshot()
+	snapshot.Sequence = seq
+	for _, subscriber := range e.subscribers {
+		select {
+		case subscriber <- snapshot:
+		default:
+			log.Printf("Subscriber channel full, dropping %s book update seq=%d", symbol, seq)
+		}
+	}
+}
+
+// process checks the sequence number of a message and applies it to the
+// order book for its symbol. It returns false when the message was discarded.
+func (e *Engine) process(msg types.MarketData) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	symbol := msg.Symbol()
+	seq := msg.Sequence()
+	ob := e.books.GetOrCreate(symbol)
+	stats, exists := e.stats[symbol]
+	if !exists {
+		stats = &SequenceStats{}
+		e.stats[symbol] = stats
+	}
+
+	// Reject duplicates and stale messages for sequenced feeds. A stale book
+	// takes any snapshot: LastSequence only covers applied messages, and the
+	// deltas discarded since the gap may have run past the snapshot.
+	resync := msg.Snapshot != nil && stats.Stale
+	if seq != 0 && stats.synced && !resync && seq <= stats.LastSequence {
+		if seq == stats.LastSequence {
+			stats.Duplicates++
+			log.Printf("Duplicate %s message seq=%d ignored", symbol, seq)
+		} else {
+			stats.OutOfOrder++
+			log.Printf("Out-of-order %s message seq=%d ignored (last %d)", symbol, seq, stats.LastSequence)
+		}
+		return false
+	}
+
+	if msg.Snapshot != nil {
+		if seq != 0 && stats.synced && !resync && seq > stats.LastSequence+1 {
+			stats.Gaps++
+			log.Printf("Sequence gap on %s: expected %d, got snapshot %d", symbol, stats.LastSequence+1, seq)
+		}
+		if stats.Stale {
+			stats.Resyncs++
+			log.Printf("Order book %s resynced at seq=%d", symbol, seq)
+		}
+
+		ob.Update(*msg.Snapshot)
+		stats.LastSequence = seq
+		stats.Stale = false
+		stats.synced = true
+		return true
+	}
+
+	if msg.Delta == nil {
+		return false
+	}
+
+	if !stats.synced || stats.Stale {
+		// Deltas are meaningless until a snapshot gives us a base
+		stats.Discarded++
+		return false
+	}
+
+	if seq != 0 && seq > stats.LastSequence+1 {
+		log.Printf("Sequence gap on %s: expected %d, got delta %d - book stale until next snapshot",
+			symbol, stats.LastSequence+1, seq)
+		stats.Gaps++
+		stats.Stale = true
+		stats.Discarded++
+		ob.MarkStale()
+		return false
+	}
+
+	if err := ob.ApplyDelta(*msg.Delta); err != nil {
+		// The book has missed a change, so it waits for a snapshot like after a gap
+		log.Printf("Invalid %s delta seq=%d - book stale until next snapshot: %v", symbol, seq, err)
+		stats.Invalid++
+		stats.Stale = true
+		ob.MarkStale()
+		return false
+	}
+	if seq != 0 {
+		stats.LastSequence = seq
+	}
+	return true
+}
+
+// Stats returns the sequence statistics for a symbol
+func (e *Engine) Stats(symbol string) SequenceStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if stats, exists := e.stats[symbol]; exists {
+		return *stats
+	}
+	return SequenceStats{}
+}
+
+// GapCount returns the total number of sequence gaps seen across all symbols
+func (e *Engine) GapCount() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	gaps := 0
+	for _, stats := range e.stats {
+		gaps += stats.Gaps
+	}
+	return gaps
+}
+
+// IsStale reports whether the book for a symbol is waiting for a resync
+func (e *Engine) IsStale(symbol string) bool {
+	return e.Stats(symbol).Stale
+}
@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func snapshotMsg(seq uint64, bid, ask float64) types.MarketData {
+	return types.MarketData{Snapshot: &types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Sequence:  seq,
+		Timestamp: time.Now(),
+		Bids:      []types.OrderBookEntry{{Price: types.NewDecimal(bid), Quantity: types.NewDecimal(1.0)}},
+		Asks:      []types.OrderBookEntry{{Price: types.NewDecimal(ask), Quantity: types.NewDecimal(1.0)}},
+	}}
+}
+
+func deltaMsg(seq uint64, side types.Side, price, qty float64) types.MarketData {
+	return types.MarketData{Delta: &types.OrderBookDelta{
+		Symbol:    "BTCUSD",
+		Sequence:  seq,
+		Timestamp: time.Now(),
+		Updates:   []types.PriceLevelUpdate{{Side: side, Price: types.NewDecimal(price), Quantity: types.NewDecimal(qty)}},
+	}}
+}
+
+func runEngine(t *testing.T, books *orderbook.Registry, msgs ...types.MarketData) *Engine {
+	updates := make(chan types.MarketData, len(msgs))
+	done := make(chan bool, 1)
+	for _, msg := range msgs {
+		updates <- msg
+	}
+	close(updates)
+
+	e := New(books, updates, done)
+	e.Start()
+	require.True(t, <-done)
+	return e
+}
+
+func TestEngineAppliesSequencedDeltas(t *testing.T) {
+	books := orderbook.NewRegistry()
+	e := runEngine(t, books,
+		snapshotMsg(1, 50000, 50100),
+		deltaMsg(2, types.SideBuy, 50050, 2.0),
+	)
+
+	ob, _ := books.Get("BTCUSD")
+	bid, qty, exists := ob.GetBestBid()
+	require.True(t, exists)
+	assert.Equal(t, types.NewDecimal(50050.0), bid)
+	assert.Equal(t, types.NewDecimal(2.0), qty)
+
+	stats := e.Stats("BTCUSD")
+	assert.Equal(t, uint64(2), stats.LastSequence)
+	assert.Equal(t, 0, stats.Gaps)
+	assert.False(t, stats.Stale)
+}
+
+func TestEngineDetectsGapAndResyncs(t *testing.T) {
+	books := orderbook.NewRegistry()
+	updates := make(chan types.MarketData, 10)
+	done := make(chan bool, 1)
+	e := New(books, updates, done)
+
+	assert.True(t, e.process(snapshotMsg(1, 50000, 50100)))
+	ob, _ := books.Get("BTCUSD")
+
+	// Sequence 2 is missing: the book becomes stale
+	assert.False(t, e.process(deltaMsg(3, types.SideBuy, 50050, 2.0)))
+	assert.True(t, ob.IsStale())
+	assert.True(t, e.IsStale("BTCUSD"))
+
+	// Further deltas are discarded until a snapshot arrives
+	assert.False(t, e.process(deltaMsg(4, types.SideBuy, 50060, 2.0)))
+	bid, _, _ := ob.GetBestBid()
+	assert.Equal(t, types.NewDecimal(50000.0), bid)
+
+	assert.True(t, e.process(snapshotMsg(5, 50010, 50110)))
+	assert.False(t, ob.IsStale())
+
+	stats := e.Stats("BTCUSD")
+	assert.Equal(t, 1, stats.Gaps)
+	assert.Equal(t, 2, stats.Discarded)
+	assert.Equal(t, 1, stats.Resyncs)
+	assert.False(t, stats.Stale)
+	assert.Equal(t, 1, e.GapCount())
+}
+
+func TestEngineResyncsFromSnapshotBehindDiscardedDeltas(t *testing.T) {
+	books := orderbook.NewRegistry()
+	updates := make(chan types.MarketData, 10)
+	done := make(chan bool, 1)
+	e := New(books, updates, done)
+
+	assert.True(t, e.process(snapshotMsg(1, 50000, 50100)))
+
+	// Sequence 2 is lost and deltas keep arriving while the venue builds
+	// the resync snapshot
+	assert.False(t, e.process(deltaMsg(3, types.SideBuy, 50050, 2.0)))
+	assert.False(t, e.process(deltaMsg(4, types.SideBuy, 50060, 2.0)))
+	assert.False(t, e.process(deltaMsg(5, types.SideBuy, 50070, 2.0)))
+	assert.Equal(t, uint64(1), e.Stats("BTCUSD").LastSequence, "discarded deltas are not applied")
+
+	// The snapshot was taken at sequence 4, behind the last discarded delta
+	assert.True(t, e.process(snapshotMsg(4, 50010, 50110)))
+	ob, _ := books.Get("BTCUSD")
+	assert.False(t, ob.IsStale())
+	bid, _, _ := ob.GetBestBid()
+	assert.Equal(t, types.NewDecimal(50010.0), bid)
+
+	// The feed carries on from the snapshot
+	assert.True(t, e.process(deltaMsg(5, types.SideBuy, 50020, 1.0)))
+
+	stats := e.Stats("BTCUSD")
+	assert.Equal(t, uint64(5), stats.LastSequence)
+	assert.Equal(t, 1, stats.Gaps)
+	assert.Equal(t, 3, stats.Discarded)
+	assert.Equal(t, 1, stats.Resyncs)
+	assert.Equal(t, 0, stats.OutOfOrder)
+}
+
+func TestEngineRejectsInvalidDelta(t *testing.T) {
+	books := orderbook.NewRegistry()
+	e := New(books, make(chan types.MarketData, 10), make(chan bool, 1))
+
+	assert.True(t, e.process(snapshotMsg(1, 50000, 50100)))
+	assert.False(t, e.process(deltaMsg(2, "bid", 50050, 2.0)))
+	ob, _ := books.Get("BTCUSD")
+	assert.True(t, ob.IsStale(), "the book missed the change")
+	ask, _, _ := ob.GetBestAsk()
+	assert.Equal(t, types.NewDecimal(50100.0), ask)
+
+	stats := e.Stats("BTCUSD")
+	assert.Equal(t, 1, stats.Invalid)
+	assert.Equal(t, uint64(1), stats.LastSequence)
+
+	assert.True(t, e.process(snapshotMsg(3, 50010, 50110)))
+	assert.False(t, ob.IsStale())
+}
+
+func TestEngineIgnoresDuplicateAndOutOfOrder(t *testing.T) {
+	books := orderbook.NewRegistry()
+	e := runEngine(t, books,
+		snapshotMsg(1, 50000, 50100),
+		deltaMsg(2, types.SideBuy, 50050, 2.0),
+		deltaMsg(2, types.SideBuy, 50050, 9.0), // duplicate
+		snapshotMsg(1, 49000, 49100),           // stale snapshot
+	)
+
+	ob, _ := books.Get("BTCUSD")
+	bid, qty, _ := ob.GetBestBid()
+	assert.Equal(t, types.NewDecimal(50050.0), bid)
+	assert.Equal(t, types.NewDecimal(2.0), qty)
+
+	stats := e.Stats("BTCUSD")
+	assert.Equal(t, 1, stats.Duplicates)
+	assert.Equal(t, 1, stats.OutOfOrder)
+	assert.Equal(t, 0, stats.Gaps)
+}
+
+func TestEngineRoutesBySymbol(t *testing.T) {
+	books := orderbook.NewRegistry()
+	eth := snapshotMsg(1, 3000, 3005)
+	eth.Snapshot.Symbol = "ETHUSD"
+
+	e := runEngine(t, books,
+		snapshotMsg(1, 50000, 50100),
+		eth,
+		deltaMsg(2, types.SideBuy, 50050, 2.0),
+	)
+
+	assert.Equal(t, []string{"BTCUSD", "ETHUSD"}, books.Symbols())
+
+	btcBook, _ := books.Get("BTCUSD")
+	bid, _, _ := btcBook.GetBestBid()
+	assert.Equal(t, types.NewDecimal(50050.0), bid)
+
+	ethBook, _ := books.Get("ETHUSD")
+	bid, _, _ = ethBook.GetBestBid()
+	assert.Equal(t, types.NewDecimal(3000.0), bid)
+	assert.Equal(t, "ETHUSD", ethBook.Symbol())
+
+	// Sequence numbers are tracked independently per symbol
+	assert.Equal(t, 0, e.GapCount())
+}
+
+func TestSubscribePublishesAppliedUpdates(t *testing.T) {
+	books := orderbook.NewRegistry()
+	updates := make(chan types.MarketData, 3)
+	done := make(chan bool, 1)
+
+	updates <- snapshotMsg(1, 50000, 50100)
+	updates <- deltaMsg(3, types.SideBuy, 50050, 2.0) // gap, discarded
+	updates <- snapshotMsg(4, 50010, 50090)
+	close(updates)
+
+	e := New(books, updates, done)
+	published := e.Subscribe(10)
+	e.Start()
+	require.True(t, <-done)
+
+	var got []types.OrderBookSnapshot
+	for snapshot := range published {
+		got = append(got, snapshot)
+	}
+
+	// Only applied updates are published, and the channel is closed at the end
+	require.Len(t, got, 2)
+	assert.Equal(t, uint64(1), got[0].Sequence)
+	assert.Equal(t, uint64(4), got[1].Sequence)
+	assert.Equal(t, types.NewDecimal(50010), got[1].Bids[0].Price)
+	assert.Equal(t, "BTCUSD", got[1].Symbol)
+}
@@ -5,18 +5,52 @@ import (
 	"io/ioutil"
 	"log"
 	"time"
+	"trading-engine/internal/orderbook"
 	"trading-engine/internal/types"
 )
 
+// Message types accepted in feed files. Entries without a type are snapshots.
+// Order-by-order entries use the types.OrderEventType values.
+const (
+	messageSnapshot = "snapshot"
+	messageDelta    = "delta"
+)
+
+// message is the on-disk representation of a single feed entry
+type message struct {
+	Type      string                   `json:"type"`
+	Symbol    string                   `json:"symbol"`
+	Sequence  uint64                   `json:"sequence"`
+	Timestamp time.Time                `json:"timestamp"`
+	Bids      []types.OrderBookEntry   `json:"bids"`
+	Asks      []types.OrderBookEntry   `json:"asks"`
+	Updates   []types.PriceLevelUpdate `json:"updates"`
+
+	// Order-by-order (L3) fields
+	OrderID  string        `json:"order_id"`
+	Side     types.Side    `json:"side"`
+	Price    types.Decimal `json:"price"`
+	Quantity types.Decimal `json:"quantity"`
+}
+
+// isOrderEvent reports whether the entry is an order-by-order event
+func (m message) isOrderEvent() bool {
+	switch types.OrderEventType(m.Type) {
+	case types.OrderEventAdd, types.OrderEventModify, types.OrderEventCancel, types.OrderEventExecute:
+		return true
+	}
+	return false
+}
+
 // Feed reads order book data from a JSON file and publishes updates
 type Feed struct {
 	filename string
-	updates  chan<- types.OrderBookSnapshot
-	data     []types.OrderBookSnapshot
+	updates  chan<- types.MarketData
+	data     []types.MarketData
 }
 
 // New creates a new feed instance
-func New(filename string, updates chan<- types.OrderBookSnapshot) *Feed {
+func New(filename string, updates chan<- types.MarketData) *Feed {
 	return &Feed{
 		filename: filename,
 		updates:  updates,
@@ -33,20 +67,30 @@ func (f *Feed) Start() {
 		return
 	}
 
-	log.Printf("Feed loaded %d snapshots from %s", len(f.data), f.filename)
+	log.Printf("Feed loaded %d messages from %s", len(f.data), f.filename)
 
-	// Publish snapshots with timing to simulate real-time feed
+	// Publish messages with timing to simulate real-time feed
 	baseTime := time.Now()
 
-	for i, snapshot := range f.data {
+	for i, msg := range f.data {
 		// Adjust timestamp to simulate real-time progression
-		snapshot.Timestamp = baseTime.Add(time.Duration(i) * 100 * time.Millisecond)
+		timestamp := baseTime.Add(time.Duration(i) * 100 * time.Millisecond)
+		if msg.Snapshot != nil {
+			snapshot := *msg.Snapshot
+			snapshot.Timestamp = timestamp
+			msg.Snapshot = &snapshot
+		} else {
+			delta := *msg.Delta
+			delta.Timestamp = timestamp
+			msg.Delta = &delta
+		}
 
 		select {
-		case f.updates <- snapshot:
-			log.Printf("Published snapshot %d: %s @ %v", i+1, snapshot.Symbol, snapshot.Timestamp.Format("15:04:05.000"))
+		case f.updates <- msg:
+			log.Printf("Published message %d: %s seq=%d @ %v", i+1, msg.Symbol(), msg.Sequence(), timestamp.Format("15:04:05.000"))
 		default:
-			log.Printf("Channel full, dropping snapshot %d", i+1)
+			// The engine detects the resulting sequence gap and resyncs
+			log.Printf("Channel full, dropping %s seq=%d", msg.Symbol(), msg.Sequence())
 		}
 
 		// Simulate real-time delay
@@ -56,7 +100,7 @@ func (f *Feed) Start() {
 	log.Println("Feed completed")
 }
 
-// loadData loads order book snapshots from JSON file
+// loadData loads order book messages from JSON file
 func (f *Feed) loadData() error {
 	data, err := ioutil.ReadFile(f.filename)
 	if err != nil {
@@ -64,16 +108,84 @@ func (f *Feed) loadData() error {
 	}
 
 	// Try to parse as array first
-	var snapshots []types.OrderBookSnapshot
-	if err := json.Unmarshal(data, &snapshots); err != nil {
-		// If that fails, try as single snapshot
-		var snapshot types.OrderBookSnapshot
-		if err := json.Unmarshal(data, &snapshot); err != nil {
+	var messages []message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		// If that fails, try as single message
+		var single message
+		if err := json.Unmarshal(data, &single); err != nil {
 			return err
 		}
-		snapshots = []types.OrderBookSnapshot{snapshot}
+		messages = []message{single}
 	}
 
-	f.data = snapshots
+	f.data = sequence(messages)
 	return nil
 }
+
+// sequence converts file entries into market data messages, assigning
+// per-symbol sequence numbers to entries that do not carry one. Order-by-order
+// events are replayed through an L3 book per symbol and published as the
+// aggregated L2 snapshot after each event.
+func sequence(messages []message) []types.MarketData {
+	lastSeq := make(map[string]uint64)
+	l3Books := make(map[string]*orderbook.L3Book)
+	result := make([]types.MarketData, 0, len(messages))
+
+	for _, m := range messages {
+		if m.Sequence == 0 {
+			m.Sequence = lastSeq[m.Symbol] + 1
+		}
+		lastSeq[m.Symbol] = m.Sequence
+
+		if m.isOrderEvent() {
+			book, exists := l3Books[m.Symbol]
+			if !exists {
+				book = orderbook.NewL3(m.Symbol)
+				l3Books[m.Symbol] = book
+			}
+
+			event := types.OrderEvent{
+				Type:      types.OrderEventType(m.Type),
+				Symbol:    m.Symbol,
+				Sequence:  m.Sequence,
+				Timestamp: m.Timestamp,
+				OrderID:   m.OrderID,
+				Side:      m.Side,
+				Price:     m.Price,
+				Quantity:  m.Quantity,
+			}
+			if err := book.Apply(event); err != nil {
+				log.Printf("Feed %s seq=%d: %v", m.Symbol, m.Sequence, err)
+			}
+
+			snapshot := book.Snapshot()
+			snapshot.Sequence = m.Sequence
+			snapshot.Timestamp = m.Timestamp
+			result = append(result, types.MarketData{Snapshot: &snapshot})
+			continue
+		}
+
+		if m.Type == messageDelta {
+			result = append(result, types.MarketData{Delta: &types.OrderBookDelta{
+				Symbol:    m.Symbol,
+				Sequence:  m.Sequence,
+				Timestamp: m.Timestamp,
+				Updates:   m.Updates,
+			}})
+			continue
+		}
+
+		if m.Type != "" && m.Type != messageSnapshot {
+			log.Printf("Unknown feed message type %q treated as snapshot", m.Type)
+		}
+		result = append(result, types.MarketData{Snapshot: &types.OrderBookSnapshot{
+			Symbol:    m.Symbol,
+			Sequence:  m.Sequence,
+			Timestamp: m.Timestamp,
+			Bids:      m.Bids,
+			Asks:      m.Asks,
+		}})
+	}
+
+	return result
+}
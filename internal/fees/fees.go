@@ -0,0 +1,221 @@
+package fees
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"trading-engine/internal/types"
+)
+
+// Window is the trailing period a Tiered schedule sums volume over
+const Window = 30 * 24 * time.Hour
+
+// Trade is what a schedule needs to know about a fill to charge it
+type Trade struct {
+	Symbol    string
+	Notional  types.Decimal // quote amount of the fills
+	Liquidity types.Liquidity
+	Time      time.Time
+}
+
+// Schedule computes the fee for a trade in the quote currency. Negative
+// fees are rebates.
+type Schedule interface {
+	// Fee returns the fee a trade would be charged now
+	Fee(trade Trade) types.Decimal
+	// Record adds a charged trade to any volume history the schedule keeps
+	Record(trade Trade)
+}
+
+// bps converts basis points to a fraction
+func bps(value float64) types.Decimal {
+	return types.NewDecimal(value / 10000)
+}
+
+// None charges nothing
+type None struct{}
+
+// Fee implements Schedule
+func (None) Fee(Trade) types.Decimal { return types.Zero }
+
+// Record implements Schedule
+func (None) Record(Trade) {}
+
+// Flat charges the same amount for every trade
+type Flat struct {
+	PerTrade types.Decimal
+}
+
+// Fee implements Schedule
+func (f Flat) Fee(Trade) types.Decimal { return f.PerTrade }
+
+// Record implements Schedule
+func (Flat) Record(Trade) {}
+
+// BasisPoints charges a fraction of the notional
+type BasisPoints struct {
+	Bps float64
+}
+
+// Fee implements Schedule
+func (b BasisPoints) Fee(trade Trade) types.Decimal {
+	return trade.Notional.Mul(bps(b.Bps))
+}
+
+// Record implements Schedule
+func (BasisPoints) Record(Trade) {}
+
+// MakerTaker charges makers and takers different rates. A negative maker
+// rate pays a rebate.
+type MakerTaker struct {
+	MakerBps float64
+	TakerBps float64
+}
+
+// Fee implements Schedule
+func (m MakerTaker) Fee(trade Trade) types.Decimal {
+	if trade.Liquidity == types.LiquidityMaker {
+		return trade.Notional.Mul(bps(m.MakerBps))
+	}
+	return trade.Notional.Mul(bps(m.TakerBps))
+}
+
+// Record implements Schedule
+func (MakerTaker) Record(Trade) {}
+
+// Tier is the maker and taker rate from a 30-day volume upwards
+type Tier struct {
+	MinVolume types.Decimal
+	MakerBps  float64
+	TakerBps  float64
+}
+
+// Tiered charges maker/taker rates that fall as the quote volume traded
+// over the trailing Window grows. PriorVolume is the volume traded in the
+// window before the session started.
+type Tiered struct {
+	Tiers       []Tier // sorted by MinVolume
+	PriorVolume types.Decimal
+	history     []Trade
+}
+
+// Fee implements Schedule using the tier of the volume in the window
+// ending at the trade
+func (t *Tiered) Fee(trade Trade) types.Decimal {
+	tier, ok := t.tier(t.Volume(trade.Time))
+	if !ok {
+		return types.Zero
+	}
+	return MakerTaker{MakerBps: tier.MakerBps, TakerBps: tier.TakerBps}.Fee(trade)
+}
+
+// Record implements Schedule
+func (t *Tiered) Record(trade Trade) {
+	t.history = append(t.history, trade)
+}
+
+// Volume returns the quote volume traded in the Window ending at now
+func (t *Tiered) Volume(now time.Time) types.Decimal {
+	// Trades that have left the window are dropped for good
+	start := 0
+	for start < len(t.history) && now.Sub(t.history[start].Time) >= Window {
+		start++
+	}
+	t.history = t.history[start:]
+
+	volume := t.PriorVolume
+	for _, trade := range t.history {
+		volume = volume.Add(trade.Notional)
+	}
+	return volume
+}
+
+// tier returns the highest tier the volume reaches
+func (t *Tiered) tier(volume types.Decimal) (Tier, bool) {
+	var current Tier
+	found := false
+	for _, tier := range t.Tiers {
+		if volume.GreaterThanOrEqual(tier.MinVolume) {
+			current, found = tier, true
+		}
+	}
+	return current, found
+}
+
+// Parse builds a schedule from a specification:
+//
+//	none                       no fees
+//	flat=1.5                   1.5 per trade
+//	bps=10                     10 basis points of notional
+//	maker-taker=-1/5           maker rebate of 1bp, taker fee of 5bp
+//	tiered=0:2/5,1000000:1/3   maker/taker bps by 30-day volume
+func Parse(spec string) (Schedule, error) {
+	kind, params, _ := strings.Cut(strings.TrimSpace(spec), "=")
+	switch kind {
+	case "", "none":
+		return None{}, nil
+
+	case "flat":
+		amount, err := types.ParseDecimal(params)
+		if err != nil {
+			return nil, fmt.Errorf("flat fee: %w", err)
+		}
+		return Flat{PerTrade: amount}, nil
+
+	case "bps":
+		rate, err := strconv.ParseFloat(params, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bps fee: %w", err)
+		}
+		return BasisPoints{Bps: rate}, nil
+
+	case "maker-taker":
+		maker, taker, err := parseRates(params)
+		if err != nil {
+			return nil, fmt.Errorf("maker-taker fee: %w", err)
+		}
+		return MakerTaker{MakerBps: maker, TakerBps: taker}, nil
+
+	case "tiered":
+		schedule := &Tiered{}
+		for _, entry := range strings.Split(params, ",") {
+			volume, rates, found := strings.Cut(entry, ":")
+			if !found {
+				return nil, fmt.Errorf("tiered fee: tier %q is not VOLUME:MAKER/TAKER", entry)
+			}
+			minVolume, err := types.ParseDecimal(volume)
+			if err != nil {
+				return nil, fmt.Errorf("tiered fee: %w", err)
+			}
+			maker, taker, err := parseRates(rates)
+			if err != nil {
+				return nil, fmt.Errorf("tiered fee: %w", err)
+			}
+			schedule.Tiers = append(schedule.Tiers, Tier{MinVolume: minVolume, MakerBps: maker, TakerBps: taker})
+		}
+		sort.Slice(schedule.Tiers, func(i, j int) bool {
+			return schedule.Tiers[i].MinVolume.LessThan(schedule.Tiers[j].MinVolume)
+		})
+		return schedule, nil
+	}
+	return nil, fmt.Errorf("unknown fee schedule %q (want none, flat, bps, maker-taker or tiered)", kind)
+}
+
+// parseRates parses "MAKER/TAKER" basis points
+func parseRates(s string) (float64, float64, error) {
+	makerText, takerText, found := strings.Cut(s, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("rates %q are not MAKER/TAKER", s)
+	}
+	maker, err := strconv.ParseFloat(makerText, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	taker, err := strconv.ParseFloat(takerText, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return maker, taker, nil
+}
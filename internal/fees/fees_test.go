@@ -0,0 +1,62 @@
+package fees
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trade returns a trade of notional at a time
+func trade(notional float64, liquidity types.Liquidity, at time.Time) Trade {
+	return Trade{Symbol: "BTCUSD", Notional: types.NewDecimal(notional), Liquidity: liquidity, Time: at}
+}
+
+func TestSchedules(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		spec  string
+		maker float64
+		taker float64
+	}{
+		{"none", 0, 0},
+		{"", 0, 0},
+		{"flat=1.5", 1.5, 1.5},
+		{"bps=10", 10, 10},
+		{"maker-taker=-1/5", -1, 5},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, types.NewDecimal(tt.maker), schedule.Fee(trade(10000, types.LiquidityMaker, now)), tt.spec)
+		assert.Equal(t, types.NewDecimal(tt.taker), schedule.Fee(trade(10000, types.LiquidityTaker, now)), tt.spec)
+	}
+
+	for _, spec := range []string{"percent=1", "flat=abc", "bps=", "maker-taker=5", "tiered=0:1", "tiered=abc:1/2"} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestTieredFollowsThirtyDayVolume(t *testing.T) {
+	schedule, err := Parse("tiered=1000000:1/3,0:2/5")
+	require.NoError(t, err)
+	tiered := schedule.(*Tiered)
+	tiered.PriorVolume = types.NewDecimal(900000)
+
+	start := time.Now()
+	first := trade(100000, types.LiquidityTaker, start)
+	assert.Equal(t, types.NewDecimal(50), tiered.Fee(first), "5bp below 1M")
+	tiered.Record(first)
+
+	second := trade(100000, types.LiquidityTaker, start.Add(time.Hour))
+	assert.Equal(t, types.NewDecimal(30), tiered.Fee(second), "3bp once the window reaches 1M")
+	assert.Equal(t, types.NewDecimal(10), tiered.Fee(trade(100000, types.LiquidityMaker, start.Add(time.Hour))))
+
+	// The first trade leaves the window after 30 days
+	later := trade(100000, types.LiquidityTaker, start.Add(Window))
+	assert.Equal(t, types.NewDecimal(900000), tiered.Volume(later.Time))
+	assert.Equal(t, types.NewDecimal(50), tiered.Fee(later))
+}
@@ -0,0 +1,69 @@
+package indicators
+
+import (
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// DefaultBandWidth is the number of standard deviations of the Bollinger
+// bands a Book tracks
+const DefaultBandWidth = 2
+
+// Book tracks the standard indicators over the book updates of one symbol.
+// Prices are mids; VWAP weights each mid by the quantity at the touch.
+type Book struct {
+	SMA        *SMA
+	EMA        *EMA
+	VWAP       *VWAP
+	RSI        *RSI
+	Bollinger  *Bollinger
+	Volatility *Volatility
+	Spread     *SMA // rolling average spread
+
+	Mid        float64 // mid of the last two-sided update
+	LastSpread float64 // spread of the last two-sided update
+	Updates    int     // two-sided updates seen
+}
+
+// NewBook creates indicators that all look back over period updates
+func NewBook(period int) *Book {
+	return &Book{
+		SMA:        NewSMA(period),
+		EMA:        NewEMA(period),
+		VWAP:       NewVWAP(period),
+		RSI:        NewRSI(period),
+		Bollinger:  NewBollinger(period, DefaultBandWidth),
+		Volatility: NewVolatility(period),
+		Spread:     NewSMA(period),
+	}
+}
+
+// Update feeds the mid and spread of a book update to every indicator. It
+// returns false, leaving the indicators unchanged, when either side of the
+// book is empty.
+func (b *Book) Update(snapshot types.OrderBookSnapshot) bool {
+	ob := orderbook.New()
+	ob.Update(snapshot)
+
+	bid, bidQty, bidExists := ob.GetBestBid()
+	ask, askQty, askExists := ob.GetBestAsk()
+	if !bidExists || !askExists {
+		return false
+	}
+
+	mid := bid.Add(ask).Float64() / 2
+	spread := ask.Sub(bid).Float64()
+
+	b.SMA.Update(mid)
+	b.EMA.Update(mid)
+	b.VWAP.Update(mid, bidQty.Add(askQty).Float64())
+	b.RSI.Update(mid)
+	b.Bollinger.Update(mid)
+	b.Volatility.Update(mid)
+	b.Spread.Update(spread)
+
+	b.Mid = mid
+	b.LastSpread = spread
+	b.Updates++
+	return true
+}
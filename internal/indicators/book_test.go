@@ -0,0 +1,43 @@
+package indicators
+
+import (
+	"testing"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookFeedsMidAndSpread(t *testing.T) {
+	book := NewBook(2)
+
+	snapshot := func(bid, bidQty, ask, askQty float64) types.OrderBookSnapshot {
+		return types.OrderBookSnapshot{
+			Symbol: "BTCUSD",
+			Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(bid), Quantity: types.NewDecimal(bidQty)}},
+			Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(ask), Quantity: types.NewDecimal(askQty)}},
+		}
+	}
+
+	require.True(t, book.Update(snapshot(49950, 1, 50050, 1)))
+	require.True(t, book.Update(snapshot(50000, 2, 50200, 2)))
+	// One-sided books are skipped
+	assert.False(t, book.Update(types.OrderBookSnapshot{Symbol: "BTCUSD", Bids: []types.OrderBookEntry{{Price: types.NewDecimal(1), Quantity: types.NewDecimal(1)}}}))
+
+	assert.Equal(t, 2, book.Updates)
+	assert.Equal(t, 50100.0, book.Mid)
+	assert.Equal(t, 200.0, book.LastSpread)
+
+	sma, ok := book.SMA.Value()
+	require.True(t, ok)
+	assert.Equal(t, 50050.0, sma)
+
+	spread, ok := book.Spread.Value()
+	require.True(t, ok)
+	assert.Equal(t, 150.0, spread)
+
+	// The second mid carries twice the touch quantity
+	vwap, ok := book.VWAP.Value()
+	require.True(t, ok)
+	assert.InDelta(t, (50000.0*2+50100.0*4)/6, vwap, 1e-9)
+}
@@ -0,0 +1,279 @@
+package indicators
+
+import (
+	"math"
+)
+
+// Indicator is a streaming calculation over a series of values. Each value
+// is processed once, in constant time and memory bounded by the period.
+type Indicator interface {
+	// Update adds the next value of the series
+	Update(value float64)
+	// Value returns the current result, or false until enough values have
+	// been seen
+	Value() (float64, bool)
+}
+
+// window holds the last period values of a series with their running mean
+// and sum of squared deviations, updated with Welford's method as values
+// enter and leave
+type window struct {
+	values []float64
+	next   int // position of the oldest value once the window is full
+	full   bool
+	mean   float64
+	m2     float64
+}
+
+// newWindow creates a window over the last period values
+func newWindow(period int) *window {
+	if period < 1 {
+		period = 1
+	}
+	return &window{values: make([]float64, 0, period)}
+}
+
+// add pushes a value, evicting the oldest once the window is full
+func (w *window) add(value float64) {
+	if w.full {
+		w.remove(w.values[w.next])
+		w.values[w.next] = value
+		w.next = (w.next + 1) % len(w.values)
+	} else {
+		w.values = append(w.values, value)
+		w.full = len(w.values) == cap(w.values)
+	}
+
+	n := float64(w.count())
+	delta := value - w.mean
+	w.mean += delta / n
+	w.m2 += delta * (value - w.mean)
+}
+
+// remove takes a value out of the running statistics
+func (w *window) remove(value float64) {
+	n := float64(w.count() - 1)
+	if n == 0 {
+		w.mean, w.m2 = 0, 0
+		return
+	}
+	delta := value - w.mean
+	w.mean -= delta / n
+	w.m2 -= delta * (value - w.mean)
+	if w.m2 < 0 {
+		w.m2 = 0
+	}
+}
+
+// count returns the number of values in the window
+func (w *window) count() int {
+	return len(w.values)
+}
+
+// variance returns the population variance of the window
+func (w *window) variance() float64 {
+	return w.m2 / float64(w.count())
+}
+
+// sampleVariance returns the sample variance of the window
+func (w *window) sampleVariance() float64 {
+	if w.count() < 2 {
+		return 0
+	}
+	return w.m2 / float64(w.count()-1)
+}
+
+// SMA is the simple moving average of the last Period values
+type SMA struct {
+	window *window
+}
+
+// NewSMA creates a simple moving average
+func NewSMA(period int) *SMA {
+	return &SMA{window: newWindow(period)}
+}
+
+// Update implements Indicator
+func (s *SMA) Update(value float64) {
+	s.window.add(value)
+}
+
+// Value implements Indicator
+func (s *SMA) Value() (float64, bool) {
+	return s.window.mean, s.window.full
+}
+
+// EMA is the exponential moving average with smoothing 2/(Period+1),
+// seeded with the simple average of the first Period values
+type EMA struct {
+	period int
+	alpha  float64
+	count  int
+	value  float64
+}
+
+// NewEMA creates an exponential moving average
+func NewEMA(period int) *EMA {
+	if period < 1 {
+		period = 1
+	}
+	return &EMA{period: period, alpha: 2 / float64(period+1)}
+}
+
+// Update implements Indicator
+func (e *EMA) Update(value float64) {
+	e.count++
+	if e.count <= e.period {
+		e.value += (value - e.value) / float64(e.count)
+		return
+	}
+	e.value += e.alpha * (value - e.value)
+}
+
+// Value implements Indicator
+func (e *EMA) Value() (float64, bool) {
+	return e.value, e.count >= e.period
+}
+
+// RSI is the relative strength index with Wilder's smoothing over Period
+// changes. It ranges from 0 to 100 and is 100 when prices only rose.
+type RSI struct {
+	period  int
+	started bool
+	last    float64
+	changes int
+	avgGain float64
+	avgLoss float64
+}
+
+// NewRSI creates a relative strength index
+func NewRSI(period int) *RSI {
+	if period < 1 {
+		period = 1
+	}
+	return &RSI{period: period}
+}
+
+// Update implements Indicator
+func (r *RSI) Update(value float64) {
+	if !r.started {
+		// The first value only sets the reference for the first change
+		r.started = true
+		r.last = value
+		return
+	}
+
+	change := value - r.last
+	r.last = value
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	r.changes++
+	if r.changes <= r.period {
+		// Seed with the simple average of the first Period changes
+		r.avgGain += (gain - r.avgGain) / float64(r.changes)
+		r.avgLoss += (loss - r.avgLoss) / float64(r.changes)
+		return
+	}
+	n := float64(r.period)
+	r.avgGain = (r.avgGain*(n-1) + gain) / n
+	r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+}
+
+// Value implements Indicator
+func (r *RSI) Value() (float64, bool) {
+	if r.changes < r.period {
+		return 0, false
+	}
+	if r.avgLoss == 0 {
+		if r.avgGain == 0 {
+			return 50, true
+		}
+		return 100, true
+	}
+	return 100 - 100/(1+r.avgGain/r.avgLoss), true
+}
+
+// Bollinger holds Bollinger bands: the simple moving average of the last
+// Period values plus and minus Width population standard deviations
+type Bollinger struct {
+	window *window
+	width  float64
+}
+
+// NewBollinger creates Bollinger bands
+func NewBollinger(period int, width float64) *Bollinger {
+	return &Bollinger{window: newWindow(period), width: width}
+}
+
+// Update implements Indicator
+func (b *Bollinger) Update(value float64) {
+	b.window.add(value)
+}
+
+// Value returns the middle band
+func (b *Bollinger) Value() (float64, bool) {
+	return b.window.mean, b.window.full
+}
+
+// Bands returns the lower, middle and upper bands
+func (b *Bollinger) Bands() (lower, middle, upper float64, ok bool) {
+	offset := b.width * math.Sqrt(b.window.variance())
+	middle = b.window.mean
+	return middle - offset, middle, middle + offset, b.window.full
+}
+
+// Volatility is the sample standard deviation of the log returns over the
+// last Period changes, per update and not annualised
+type Volatility struct {
+	returns *window
+	last    float64
+}
+
+// NewVolatility creates a rolling volatility
+func NewVolatility(period int) *Volatility {
+	return &Volatility{returns: newWindow(period)}
+}
+
+// Update implements Indicator. Non-positive values are ignored.
+func (v *Volatility) Update(value float64) {
+	if value <= 0 {
+		return
+	}
+	if v.last > 0 {
+		v.returns.add(math.Log(value / v.last))
+	}
+	v.last = value
+}
+
+// Value implements Indicator
+func (v *Volatility) Value() (float64, bool) {
+	return math.Sqrt(v.returns.sampleVariance()), v.returns.full && v.returns.count() > 1
+}
+
+// VWAP is the volume-weighted average price of the last Period updates
+type VWAP struct {
+	notional *window // price*volume of each update
+	volume   *window
+}
+
+// NewVWAP creates a rolling volume-weighted average price
+func NewVWAP(period int) *VWAP {
+	return &VWAP{notional: newWindow(period), volume: newWindow(period)}
+}
+
+// Update adds a price traded or quoted with the given volume
+func (v *VWAP) Update(price, volume float64) {
+	v.notional.add(price * volume)
+	v.volume.add(volume)
+}
+
+// Value returns the current VWAP, or false until Period updates have been
+// seen or while the window holds no volume
+func (v *VWAP) Value() (float64, bool) {
+	if v.volume.mean <= 0 {
+		return 0, false
+	}
+	// Both windows hold the same number of updates, so the ratio of their
+	// means is the ratio of their sums
+	return v.notional.mean / v.volume.mean, v.volume.full
+}
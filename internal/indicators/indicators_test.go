@@ -0,0 +1,199 @@
+package indicators
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tolerance = 1e-6
+
+// randomWalk returns a reproducible price series around start
+func randomWalk(seed int64, n int, start float64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	prices := make([]float64, n)
+	price := start
+	for i := range prices {
+		price *= 1 + rng.NormFloat64()*0.002
+		prices[i] = price
+	}
+	return prices
+}
+
+// Batch reference implementations, recomputed from scratch over a prefix
+
+func batchMean(xs []float64) float64 {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func batchSMA(xs []float64, n int) (float64, bool) {
+	if len(xs) < n {
+		return 0, false
+	}
+	return batchMean(xs[len(xs)-n:]), true
+}
+
+func batchEMA(xs []float64, n int) (float64, bool) {
+	if len(xs) < n {
+		return 0, false
+	}
+	alpha := 2 / float64(n+1)
+	ema := batchMean(xs[:n])
+	for _, x := range xs[n:] {
+		ema = alpha*x + (1-alpha)*ema
+	}
+	return ema, true
+}
+
+func batchRSI(xs []float64, n int) (float64, bool) {
+	if len(xs) < n+1 {
+		return 0, false
+	}
+	var gains, losses []float64
+	for i := 1; i < len(xs); i++ {
+		change := xs[i] - xs[i-1]
+		gains = append(gains, math.Max(change, 0))
+		losses = append(losses, math.Max(-change, 0))
+	}
+	avgGain, avgLoss := batchMean(gains[:n]), batchMean(losses[:n])
+	for i := n; i < len(gains); i++ {
+		avgGain = (avgGain*float64(n-1) + gains[i]) / float64(n)
+		avgLoss = (avgLoss*float64(n-1) + losses[i]) / float64(n)
+	}
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50, true
+		}
+		return 100, true
+	}
+	return 100 - 100/(1+avgGain/avgLoss), true
+}
+
+func batchStd(xs []float64, sample bool) float64 {
+	mean := batchMean(xs)
+	sum := 0.0
+	for _, x := range xs {
+		sum += (x - mean) * (x - mean)
+	}
+	if sample {
+		return math.Sqrt(sum / float64(len(xs)-1))
+	}
+	return math.Sqrt(sum / float64(len(xs)))
+}
+
+func batchVolatility(xs []float64, n int) (float64, bool) {
+	if len(xs) < n+1 {
+		return 0, false
+	}
+	var returns []float64
+	for i := len(xs) - n; i < len(xs); i++ {
+		returns = append(returns, math.Log(xs[i]/xs[i-1]))
+	}
+	return batchStd(returns, true), true
+}
+
+func TestStreamingMatchesBatch(t *testing.T) {
+	prices := randomWalk(1, 500, 50000)
+
+	for _, period := range []int{1, 2, 14, 20} {
+		tests := []struct {
+			name      string
+			indicator Indicator
+			batch     func([]float64, int) (float64, bool)
+		}{
+			{"SMA", NewSMA(period), batchSMA},
+			{"EMA", NewEMA(period), batchEMA},
+			{"RSI", NewRSI(period), batchRSI},
+			{"Bollinger middle", NewBollinger(period, 2), batchSMA},
+		}
+		if period > 1 {
+			tests = append(tests, struct {
+				name      string
+				indicator Indicator
+				batch     func([]float64, int) (float64, bool)
+			}{"Volatility", NewVolatility(period), batchVolatility})
+		}
+
+		for _, tt := range tests {
+			for i, price := range prices {
+				tt.indicator.Update(price)
+				got, gotOK := tt.indicator.Value()
+				want, wantOK := tt.batch(prices[:i+1], period)
+				require.Equal(t, wantOK, gotOK, "%s(%d) ready after %d values", tt.name, period, i+1)
+				if wantOK {
+					require.InDelta(t, want, got, tolerance*math.Max(1, math.Abs(want)), "%s(%d) after %d values", tt.name, period, i+1)
+				}
+			}
+		}
+	}
+}
+
+func TestBollingerBandsMatchBatch(t *testing.T) {
+	prices := randomWalk(2, 300, 3000)
+	bands := NewBollinger(20, 2)
+
+	for i, price := range prices {
+		bands.Update(price)
+		lower, middle, upper, ok := bands.Bands()
+		if i+1 < 20 {
+			assert.False(t, ok)
+			continue
+		}
+		require.True(t, ok)
+		window := prices[i+1-20 : i+1]
+		mean, std := batchMean(window), batchStd(window, false)
+		require.InDelta(t, mean, middle, tolerance*mean)
+		require.InDelta(t, mean-2*std, lower, tolerance*mean)
+		require.InDelta(t, mean+2*std, upper, tolerance*mean)
+	}
+}
+
+func TestVWAPMatchesBatch(t *testing.T) {
+	prices := randomWalk(3, 300, 0.45)
+	rng := rand.New(rand.NewSource(4))
+	vwap := NewVWAP(10)
+
+	var volumes []float64
+	for i, price := range prices {
+		volume := rng.Float64() * 1000
+		volumes = append(volumes, volume)
+		vwap.Update(price, volume)
+
+		got, ok := vwap.Value()
+		if i+1 < 10 {
+			assert.False(t, ok)
+			continue
+		}
+		require.True(t, ok)
+		notional, total := 0.0, 0.0
+		for j := i + 1 - 10; j <= i; j++ {
+			notional += prices[j] * volumes[j]
+			total += volumes[j]
+		}
+		require.InDelta(t, notional/total, got, tolerance)
+	}
+}
+
+func TestRSIExtremes(t *testing.T) {
+	rising := NewRSI(3)
+	for _, price := range []float64{1, 2, 3, 4} {
+		rising.Update(price)
+	}
+	value, ok := rising.Value()
+	require.True(t, ok)
+	assert.Equal(t, 100.0, value)
+
+	flat := NewRSI(3)
+	for i := 0; i < 4; i++ {
+		flat.Update(5)
+	}
+	value, _ = flat.Value()
+	assert.Equal(t, 50.0, value)
+}
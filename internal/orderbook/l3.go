@@ -0,0 +1,277 @@
+package orderbook
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"trading-engine/internal/types"
+)
+
+// priceLevel holds the resting orders at one price in arrival order
+type priceLevel struct {
+	price  types.Decimal
+	orders []*types.BookOrder
+}
+
+// quantity returns the total resting quantity at the level
+func (l *priceLevel) quantity() types.Decimal {
+	var total types.Decimal
+	for _, order := range l.orders {
+		total = total.Add(order.Quantity)
+	}
+	return total
+}
+
+// L3Book represents an order-by-order book with price-time priority
+type L3Book struct {
+	mu          sync.RWMutex
+	symbol      string
+	orders      map[string]*types.BookOrder
+	bids        []*priceLevel // sorted descending by price
+	asks        []*priceLevel // sorted ascending by price
+	lastUpdated time.Time
+}
+
+// NewL3 creates a new order-by-order book
+func NewL3(symbol string) *L3Book {
+	return &L3Book{
+		symbol: symbol,
+		orders: make(map[string]*types.BookOrder),
+		bids:   make([]*priceLevel, 0),
+		asks:   make([]*priceLevel, 0),
+	}
+}
+
+// Apply applies a single order event to the book
+func (b *L3Book) Apply(event types.OrderEvent) error {
+	switch event.Type {
+	case types.OrderEventAdd:
+		return b.Add(types.BookOrder{
+			ID:        event.OrderID,
+			Side:      event.Side,
+			Price:     event.Price,
+			Quantity:  event.Quantity,
+			Timestamp: event.Timestamp,
+		})
+	case types.OrderEventModify:
+		return b.Modify(event.OrderID, event.Price, event.Quantity, event.Timestamp)
+	case types.OrderEventCancel:
+		return b.Cancel(event.OrderID, event.Timestamp)
+	case types.OrderEventExecute:
+		return b.Execute(event.OrderID, event.Quantity, event.Timestamp)
+	default:
+		return fmt.Errorf("unknown order event type %q", event.Type)
+	}
+}
+
+// Add inserts a new order at the back of the queue for its price
+func (b *L3Book) Add(order types.BookOrder) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.orders[order.ID]; exists {
+		return fmt.Errorf("order %s already exists", order.ID)
+	}
+	if order.Side != types.SideBuy && order.Side != types.SideSell {
+		return fmt.Errorf("order %s has invalid side %q", order.ID, order.Side)
+	}
+	if !order.Quantity.IsPositive() {
+		return fmt.Errorf("order %s has non-positive quantity %s", order.ID, order.Quantity)
+	}
+
+	b.enqueue(&order)
+	b.lastUpdated = order.Timestamp
+	return nil
+}
+
+// Modify changes the price and/or quantity of a resting order. Reducing the
+// quantity at the same price keeps queue position; any other change sends
+// the order to the back of the queue at its new price.
+func (b *L3Book) Modify(id string, price, quantity types.Decimal, timestamp time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	order, exists := b.orders[id]
+	if !exists {
+		return fmt.Errorf("order %s not found", id)
+	}
+	if !quantity.IsPositive() {
+		b.remove(order)
+		b.lastUpdated = timestamp
+		return nil
+	}
+
+	if price.Equal(order.Price) && quantity.LessThanOrEqual(order.Quantity) {
+		order.Quantity = quantity
+		b.lastUpdated = timestamp
+		return nil
+	}
+
+	b.remove(order)
+	order.Price = price
+	order.Quantity = quantity
+	order.Timestamp = timestamp
+	b.enqueue(order)
+	b.lastUpdated = timestamp
+	return nil
+}
+
+// Cancel removes a resting order from the book
+func (b *L3Book) Cancel(id string, timestamp time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	order, exists := b.orders[id]
+	if !exists {
+		return fmt.Errorf("order %s not found", id)
+	}
+
+	b.remove(order)
+	b.lastUpdated = timestamp
+	return nil
+}
+
+// Execute reduces a resting order by a traded quantity, removing it when
+// fully filled. The order keeps its queue position.
+func (b *L3Book) Execute(id string, quantity types.Decimal, timestamp time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	order, exists := b.orders[id]
+	if !exists {
+		return fmt.Errorf("order %s not found", id)
+	}
+	if !quantity.IsPositive() {
+		return fmt.Errorf("execution of %s for order %s is not positive", quantity, id)
+	}
+	if quantity.GreaterThan(order.Quantity) {
+		return fmt.Errorf("execution of %s exceeds resting quantity %s for order %s",
+			quantity, order.Quantity, id)
+	}
+
+	order.Quantity = order.Quantity.Sub(quantity)
+	if !order.Quantity.IsPositive() {
+		b.remove(order)
+	}
+	b.lastUpdated = timestamp
+	return nil
+}
+
+// Order returns a copy of a resting order
+func (b *L3Book) Order(id string) (types.BookOrder, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	order, exists := b.orders[id]
+	if !exists {
+		return types.BookOrder{}, false
+	}
+	return *order, true
+}
+
+// QueuePosition returns the number of orders and the quantity resting ahead
+// of an order at its price level
+func (b *L3Book) QueuePosition(id string) (int, types.Decimal, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	order, exists := b.orders[id]
+	if !exists {
+		return 0, types.Zero, false
+	}
+
+	level := b.findLevel(order.Side, order.Price)
+	var qtyAhead types.Decimal
+	for i, queued := range level.orders {
+		if queued == order {
+			return i, qtyAhead, true
+		}
+		qtyAhead = qtyAhead.Add(queued.Quantity)
+	}
+	return 0, types.Zero, false
+}
+
+// Snapshot aggregates the resting orders into an L2 snapshot
+func (b *L3Book) Snapshot() types.OrderBookSnapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	snapshot := types.OrderBookSnapshot{
+		Symbol:    b.symbol,
+		Timestamp: b.lastUpdated,
+		Bids:      make([]types.OrderBookEntry, 0, len(b.bids)),
+		Asks:      make([]types.OrderBookEntry, 0, len(b.asks)),
+	}
+	for _, level := range b.bids {
+		snapshot.Bids = append(snapshot.Bids, types.OrderBookEntry{Price: level.price, Quantity: level.quantity()})
+	}
+	for _, level := range b.asks {
+		snapshot.Asks = append(snapshot.Asks, types.OrderBookEntry{Price: level.price, Quantity: level.quantity()})
+	}
+	return snapshot
+}
+
+// OrderBook returns an L2 order book aggregated from the resting orders
+func (b *L3Book) OrderBook() *OrderBook {
+	ob := New()
+	ob.Update(b.Snapshot())
+	return ob
+}
+
+// enqueue appends an order to the back of its price level, creating the
+// level at its sorted position if needed
+func (b *L3Book) enqueue(order *types.BookOrder) {
+	levels, before := b.side(order.Side)
+	i := sort.Search(len(*levels), func(i int) bool {
+		return !before((*levels)[i].price, order.Price)
+	})
+	if i == len(*levels) || !(*levels)[i].price.Equal(order.Price) {
+		*levels = append(*levels, nil)
+		copy((*levels)[i+1:], (*levels)[i:])
+		(*levels)[i] = &priceLevel{price: order.Price}
+	}
+	(*levels)[i].orders = append((*levels)[i].orders, order)
+	b.orders[order.ID] = order
+}
+
+// remove deletes an order from its level, dropping the level when empty
+func (b *L3Book) remove(order *types.BookOrder) {
+	levels, before := b.side(order.Side)
+	i := sort.Search(len(*levels), func(i int) bool {
+		return !before((*levels)[i].price, order.Price)
+	})
+	if i < len(*levels) && (*levels)[i].price.Equal(order.Price) {
+		level := (*levels)[i]
+		for j, queued := range level.orders {
+			if queued == order {
+				level.orders = append(level.orders[:j], level.orders[j+1:]...)
+				break
+			}
+		}
+		if len(level.orders) == 0 {
+			*levels = append((*levels)[:i], (*levels)[i+1:]...)
+		}
+	}
+	delete(b.orders, order.ID)
+}
+
+// findLevel returns the level holding a price on one side
+func (b *L3Book) findLevel(side types.Side, price types.Decimal) *priceLevel {
+	levels, before := b.side(side)
+	i := sort.Search(len(*levels), func(i int) bool {
+		return !before((*levels)[i].price, price)
+	})
+	if i < len(*levels) && (*levels)[i].price.Equal(price) {
+		return (*levels)[i]
+	}
+	return &priceLevel{price: price}
+}
+
+// side returns the levels for a side and its price ordering
+func (b *L3Book) side(side types.Side) (*[]*priceLevel, func(a, c types.Decimal) bool) {
+	if side == types.SideBuy {
+		return &b.bids, types.Decimal.GreaterThan
+	}
+	return &b.asks, types.Decimal.LessThan
+}
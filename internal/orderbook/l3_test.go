@@ -0,0 +1,160 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupL3Book(t *testing.T) *L3Book {
+	book := NewL3("BTCUSD")
+	now := time.Now()
+
+	orders := []types.BookOrder{
+		{ID: "b1", Side: types.SideBuy, Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0), Timestamp: now},
+		{ID: "b2", Side: types.SideBuy, Price: types.NewDecimal(50000), Quantity: types.NewDecimal(0.5), Timestamp: now.Add(time.Millisecond)},
+		{ID: "b3", Side: types.SideBuy, Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0), Timestamp: now},
+		{ID: "a1", Side: types.SideSell, Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0), Timestamp: now},
+		{ID: "a2", Side: types.SideSell, Price: types.NewDecimal(50100), Quantity: types.NewDecimal(2.0), Timestamp: now.Add(time.Millisecond)},
+		{ID: "a3", Side: types.SideSell, Price: types.NewDecimal(50150), Quantity: types.NewDecimal(1.5), Timestamp: now},
+	}
+	for _, order := range orders {
+		require.NoError(t, book.Add(order))
+	}
+	return book
+}
+
+func TestL3Aggregation(t *testing.T) {
+	book := setupL3Book(t)
+
+	ob := book.OrderBook()
+
+	bidPrice, bidQty, exists := ob.GetBestBid()
+	require.True(t, exists)
+	assert.Equal(t, types.NewDecimal(50000.0), bidPrice)
+	assert.Equal(t, types.NewDecimal(1.5), bidQty)
+
+	askPrice, askQty, exists := ob.GetBestAsk()
+	require.True(t, exists)
+	assert.Equal(t, types.NewDecimal(50100.0), askPrice)
+	assert.Equal(t, types.NewDecimal(3.0), askQty)
+
+	// 3.0 @ 50100 + 1.0 @ 50150
+	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, types.NewDecimal(4.0))
+	require.True(t, canFill)
+	assert.Equal(t, types.NewDecimal(50112.5), fillPrice)
+}
+
+func TestL3QueuePosition(t *testing.T) {
+	book := setupL3Book(t)
+
+	ahead, qtyAhead, ok := book.QueuePosition("a1")
+	require.True(t, ok)
+	assert.Equal(t, 0, ahead)
+	assert.Equal(t, types.NewDecimal(0.0), qtyAhead)
+
+	ahead, qtyAhead, ok = book.QueuePosition("a2")
+	require.True(t, ok)
+	assert.Equal(t, 1, ahead)
+	assert.Equal(t, types.NewDecimal(1.0), qtyAhead)
+
+	// Partial execution of the head order keeps priority and shrinks the queue ahead
+	require.NoError(t, book.Execute("a1", types.NewDecimal(0.4), time.Now()))
+	_, qtyAhead, _ = book.QueuePosition("a2")
+	assert.Equal(t, types.NewDecimal(0.6), qtyAhead)
+
+	// Fully executing the head order moves a2 to the front
+	require.NoError(t, book.Execute("a1", types.NewDecimal(0.6), time.Now()))
+	_, exists := book.Order("a1")
+	assert.False(t, exists)
+	ahead, _, _ = book.QueuePosition("a2")
+	assert.Equal(t, 0, ahead)
+
+	_, _, ok = book.QueuePosition("missing")
+	assert.False(t, ok)
+}
+
+func TestL3ModifyPriority(t *testing.T) {
+	book := setupL3Book(t)
+
+	// Reducing quantity keeps queue position
+	require.NoError(t, book.Modify("b1", types.NewDecimal(50000), types.NewDecimal(0.8), time.Now()))
+	ahead, _, _ := book.QueuePosition("b1")
+	assert.Equal(t, 0, ahead)
+
+	// Increasing quantity sends the order to the back of the queue
+	require.NoError(t, book.Modify("b1", types.NewDecimal(50000), types.NewDecimal(1.2), time.Now()))
+	ahead, qtyAhead, _ := book.QueuePosition("b1")
+	assert.Equal(t, 1, ahead)
+	assert.Equal(t, types.NewDecimal(0.5), qtyAhead)
+
+	// Changing price moves the order to a new level
+	require.NoError(t, book.Modify("b2", types.NewDecimal(50050), types.NewDecimal(0.5), time.Now()))
+	snapshot := book.Snapshot()
+	require.Len(t, snapshot.Bids, 3)
+	assert.Equal(t, types.OrderBookEntry{Price: types.NewDecimal(50050), Quantity: types.NewDecimal(0.5)}, snapshot.Bids[0])
+	assert.Equal(t, types.OrderBookEntry{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.2)}, snapshot.Bids[1])
+}
+
+func TestL3CancelAndErrors(t *testing.T) {
+	book := setupL3Book(t)
+
+	require.NoError(t, book.Cancel("b3", time.Now()))
+	snapshot := book.Snapshot()
+	require.Len(t, snapshot.Bids, 1)
+
+	assert.Error(t, book.Cancel("b3", time.Now()))
+	assert.Error(t, book.Add(types.BookOrder{ID: "a1", Side: types.SideSell, Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1)}))
+	assert.Error(t, book.Execute("a3", types.NewDecimal(5.0), time.Now()))
+	assert.Error(t, book.Apply(types.OrderEvent{Type: "replace", OrderID: "a3"}))
+}
+
+func TestL3RejectsInvalidSideAndQuantity(t *testing.T) {
+	book := setupL3Book(t)
+	before := book.Snapshot()
+
+	for _, side := range []types.Side{"", "HOLD"} {
+		err := book.Add(types.BookOrder{ID: "x1", Side: side, Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1)})
+		assert.Error(t, err, "side %q", side)
+	}
+	_, exists := book.Order("x1")
+	assert.False(t, exists)
+
+	assert.Error(t, book.Execute("a1", types.NewDecimal(0), time.Now()))
+	assert.Error(t, book.Execute("a1", types.NewDecimal(-1), time.Now()))
+	assert.Equal(t, before, book.Snapshot(), "rejected events leave the book unchanged")
+}
+
+func TestL3MatchesSnapshot(t *testing.T) {
+	book := setupL3Book(t)
+
+	events := []types.OrderEvent{
+		{Type: types.OrderEventExecute, OrderID: "a1", Quantity: types.NewDecimal(1.0)},
+		{Type: types.OrderEventAdd, OrderID: "b4", Side: types.SideBuy, Price: types.NewDecimal(50050), Quantity: types.NewDecimal(0.3)},
+		{Type: types.OrderEventCancel, OrderID: "b3"},
+		{Type: types.OrderEventModify, OrderID: "a3", Price: types.NewDecimal(50200), Quantity: types.NewDecimal(1.5)},
+	}
+	for _, event := range events {
+		require.NoError(t, book.Apply(event))
+	}
+
+	expected := New()
+	expected.Update(types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50050), Quantity: types.NewDecimal(0.3)},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.5)},
+		},
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(50200), Quantity: types.NewDecimal(1.5)},
+		},
+	})
+
+	actual := book.OrderBook()
+	assert.Equal(t, expected.bids, actual.bids)
+	assert.Equal(t, expected.asks, actual.asks)
+}
@@ -2,6 +2,7 @@ package orderbook
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"sync"
 	"time"
@@ -15,6 +16,30 @@ type OrderBook struct {
 	bids        []types.OrderBookEntry // sorted descending by price
 	asks        []types.OrderBookEntry // sorted ascending by price
 	lastUpdated time.Time
+	stale       bool // set when updates were missed; cleared by the next snapshot
+	depletions  []depletion
+}
+
+// depletion records liquidity removed from a level by a simulated execution
+type depletion struct {
+	side     types.Side // book side the liquidity was taken from
+	price    types.Decimal
+	quantity types.Decimal
+	at       time.Time
+	halfLife time.Duration // zero: restored by the next snapshot
+}
+
+// remaining returns the part of the depletion still in effect at a given time
+func (d depletion) remaining(now time.Time) types.Decimal {
+	if d.halfLife <= 0 {
+		return types.Zero
+	}
+	elapsed := now.Sub(d.at)
+	if elapsed <= 0 {
+		return d.quantity
+	}
+	factor := math.Pow(0.5, float64(elapsed)/float64(d.halfLife))
+	return d.quantity.Mul(types.NewDecimal(factor))
 }
 
 // New creates a new order book
@@ -32,87 +57,287 @@ func (ob *OrderBook) Update(snapshot types.OrderBookSnapshot) {
 
 	ob.symbol = snapshot.Symbol
 	ob.lastUpdated = snapshot.Timestamp
+	ob.stale = false
 
 	// Copy and sort bids (descending by price)
 	ob.bids = make([]types.OrderBookEntry, len(snapshot.Bids))
 	copy(ob.bids, snapshot.Bids)
 	sort.Slice(ob.bids, func(i, j int) bool {
-		return ob.bids[i].Price > ob.bids[j].Price
+		return ob.bids[i].Price.GreaterThan(ob.bids[j].Price)
 	})
 
 	// Copy and sort asks (ascending by price)
 	ob.asks = make([]types.OrderBookEntry, len(snapshot.Asks))
 	copy(ob.asks, snapshot.Asks)
 	sort.Slice(ob.asks, func(i, j int) bool {
-		return ob.asks[i].Price < ob.asks[j].Price
+		return ob.asks[i].Price.LessThan(ob.asks[j].Price)
 	})
+
+	ob.reapplyDepletions()
+}
+
+// reapplyDepletions removes still-decaying simulated executions from a fresh
+// snapshot and forgets those that have fully replenished
+func (ob *OrderBook) reapplyDepletions() {
+	active := ob.depletions[:0]
+	for _, d := range ob.depletions {
+		remaining := d.remaining(ob.lastUpdated)
+		if !remaining.IsPositive() {
+			continue
+		}
+		active = append(active, d)
+
+		if d.side == types.SideBuy {
+			ob.bids = reduceLevel(ob.bids, d.price, remaining, types.Decimal.GreaterThan)
+		} else {
+			ob.asks = reduceLevel(ob.asks, d.price, remaining, types.Decimal.LessThan)
+		}
+	}
+	ob.depletions = active
+}
+
+// Consume removes liquidity matched by an order of the given side and
+// quantity, walking levels from the top of the book. A non-zero limit stops
+// the walk at prices worse than the limit. The removed liquidity stays out of
+// the book until the next snapshot or delta for its level, or replenishes
+// with the given half-life across them when halfLife is positive. It returns
+// the per-level fills.
+func (ob *OrderBook) Consume(side types.Side, quantity, limit types.Decimal, halfLife time.Duration) []types.Fill {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	levels := &ob.asks
+	bookSide := types.SideSell
+	worse := types.Decimal.GreaterThan
+	if side == types.SideSell {
+		levels = &ob.bids
+		bookSide = types.SideBuy
+		worse = types.Decimal.LessThan
+	}
+
+	var fills []types.Fill
+	remaining := quantity
+	for len(*levels) > 0 && remaining.IsPositive() {
+		level := &(*levels)[0]
+		if !limit.IsZero() && worse(level.Price, limit) {
+			break
+		}
+
+		fillQty := types.MinDecimal(level.Quantity, remaining)
+		fills = append(fills, types.Fill{Price: level.Price, Quantity: fillQty})
+		ob.depletions = append(ob.depletions, depletion{
+			side:     bookSide,
+			price:    level.Price,
+			quantity: fillQty,
+			at:       ob.lastUpdated,
+			halfLife: halfLife,
+		})
+
+		remaining = remaining.Sub(fillQty)
+		level.Quantity = level.Quantity.Sub(fillQty)
+		if !level.Quantity.IsPositive() {
+			*levels = (*levels)[1:]
+		}
+	}
+
+	return fills
+}
+
+// reduceLevel subtracts quantity from the level at price, removing it when empty
+func reduceLevel(levels []types.OrderBookEntry, price, quantity types.Decimal, before func(a, b types.Decimal) bool) []types.OrderBookEntry {
+	i := sort.Search(len(levels), func(i int) bool {
+		return !before(levels[i].Price, price)
+	})
+	if i == len(levels) || !levels[i].Price.Equal(price) {
+		return levels
+	}
+	return setLevel(levels, price, levels[i].Quantity.Sub(quantity), before)
+}
+
+// ApplyDelta applies an incremental update to the order book in place.
+// Each level update replaces the quantity at its price; a zero quantity
+// removes the level. Bids stay sorted descending and asks ascending. As with
+// a snapshot, simulated executions still decaying are subtracted from the
+// updated levels. A delta with an update for an invalid side is rejected
+// without changing the book.
+func (ob *OrderBook) ApplyDelta(delta types.OrderBookDelta) error {
+	for _, update := range delta.Updates {
+		if update.Side != types.SideBuy && update.Side != types.SideSell {
+			return fmt.Errorf("delta for %s has invalid side %q at %s", delta.Symbol, update.Side, update.Price)
+		}
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.symbol = delta.Symbol
+	ob.lastUpdated = delta.Timestamp
+
+	for _, update := range delta.Updates {
+		quantity := update.Quantity
+		if quantity.IsPositive() {
+			quantity = quantity.Sub(ob.depleted(update.Side, update.Price))
+		}
+		if update.Side == types.SideBuy {
+			ob.bids = setLevel(ob.bids, update.Price, quantity, types.Decimal.GreaterThan)
+		} else {
+			ob.asks = setLevel(ob.asks, update.Price, quantity, types.Decimal.LessThan)
+		}
+	}
+	return nil
+}
+
+// depleted returns the simulated executions still in effect at a level
+func (ob *OrderBook) depleted(side types.Side, price types.Decimal) types.Decimal {
+	var total types.Decimal
+	for _, d := range ob.depletions {
+		if d.side == side && d.price.Equal(price) {
+			total = total.Add(d.remaining(ob.lastUpdated))
+		}
+	}
+	return total
+}
+
+// Symbol returns the symbol of the last applied update
+func (ob *OrderBook) Symbol() string {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.symbol
+}
+
+// Snapshot returns a copy of the current book state
+func (ob *OrderBook) Snapshot() types.OrderBookSnapshot {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	snapshot := types.OrderBookSnapshot{
+		Symbol:    ob.symbol,
+		Timestamp: ob.lastUpdated,
+		Bids:      make([]types.OrderBookEntry, len(ob.bids)),
+		Asks:      make([]types.OrderBookEntry, len(ob.asks)),
+	}
+	copy(snapshot.Bids, ob.bids)
+	copy(snapshot.Asks, ob.asks)
+	return snapshot
+}
+
+// LastUpdated returns the feed timestamp of the last applied update
+func (ob *OrderBook) LastUpdated() time.Time {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.lastUpdated
+}
+
+// MarkStale flags the book as out of sync with the venue until the next snapshot
+func (ob *OrderBook) MarkStale() {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.stale = true
+}
+
+// IsStale reports whether the book is waiting for a snapshot to resync
+func (ob *OrderBook) IsStale() bool {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.stale
+}
+
+// setLevel inserts, updates or removes the level at price in a sorted slice.
+// before reports whether price a sorts ahead of price b on this side.
+func setLevel(levels []types.OrderBookEntry, price, quantity types.Decimal, before func(a, b types.Decimal) bool) []types.OrderBookEntry {
+	i := sort.Search(len(levels), func(i int) bool {
+		return !before(levels[i].Price, price)
+	})
+	exists := i < len(levels) && levels[i].Price.Equal(price)
+
+	switch {
+	case !quantity.IsPositive() && exists:
+		// Remove level
+		return append(levels[:i], levels[i+1:]...)
+	case !quantity.IsPositive():
+		// Nothing to remove
+		return levels
+	case exists:
+		levels[i].Quantity = quantity
+		return levels
+	}
+
+	// Insert new level at its sorted position
+	levels = append(levels, types.OrderBookEntry{})
+	copy(levels[i+1:], levels[i:])
+	levels[i] = types.OrderBookEntry{Price: price, Quantity: quantity}
+	return levels
 }
 
 // GetBestBid returns the highest bid price and quantity
-func (ob *OrderBook) GetBestBid() (float64, float64, bool) {
+func (ob *OrderBook) GetBestBid() (types.Decimal, types.Decimal, bool) {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
 	if len(ob.bids) == 0 {
-		return 0, 0, false
+		return types.Zero, types.Zero, false
 	}
 	return ob.bids[0].Price, ob.bids[0].Quantity, true
 }
 
 // GetBestAsk returns the lowest ask price and quantity
-func (ob *OrderBook) GetBestAsk() (float64, float64, bool) {
+func (ob *OrderBook) GetBestAsk() (types.Decimal, types.Decimal, bool) {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
 	if len(ob.asks) == 0 {
-		return 0, 0, false
+		return types.Zero, types.Zero, false
 	}
 	return ob.asks[0].Price, ob.asks[0].Quantity, true
 }
 
 // GetSpread returns the bid-ask spread
-func (ob *OrderBook) GetSpread() (float64, bool) {
+func (ob *OrderBook) GetSpread() (types.Decimal, bool) {
 	bidPrice, _, bidExists := ob.GetBestBid()
 	askPrice, _, askExists := ob.GetBestAsk()
 
 	if !bidExists || !askExists {
-		return 0, false
+		return types.Zero, false
 	}
 
-	return askPrice - bidPrice, true
+	return askPrice.Sub(bidPrice), true
 }
 
 // GetMidPrice returns the mid price
-func (ob *OrderBook) GetMidPrice() (float64, bool) {
+func (ob *OrderBook) GetMidPrice() (types.Decimal, bool) {
 	bidPrice, _, bidExists := ob.GetBestBid()
 	askPrice, _, askExists := ob.GetBestAsk()
 
 	if !bidExists || !askExists {
-		return 0, false
+		return types.Zero, false
 	}
 
-	return (bidPrice + askPrice) / 2, true
+	return bidPrice.Add(askPrice).Div(types.NewDecimalFromInt(2)), true
 }
 
 // GetCumulativeDepth returns cumulative quantity up to a price level
-func (ob *OrderBook) GetCumulativeDepth(side types.Side, priceLevel float64) float64 {
+func (ob *OrderBook) GetCumulativeDepth(side types.Side, priceLevel types.Decimal) types.Decimal {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
-	var depth float64
+	var depth types.Decimal
 
 	if side == types.SideBuy {
 		// For bids, sum all quantities at prices >= priceLevel
 		for _, bid := range ob.bids {
-			if bid.Price >= priceLevel {
-				depth += bid.Quantity
+			if bid.Price.GreaterThanOrEqual(priceLevel) {
+				depth = depth.Add(bid.Quantity)
 			}
 		}
 	} else {
 		// For asks, sum all quantities at prices <= priceLevel
 		for _, ask := range ob.asks {
-			if ask.Price <= priceLevel {
-				depth += ask.Quantity
+			if ask.Price.LessThanOrEqual(priceLevel) {
+				depth = depth.Add(ask.Quantity)
 			}
 		}
 	}
@@ -121,30 +346,30 @@ func (ob *OrderBook) GetCumulativeDepth(side types.Side, priceLevel float64) flo
 }
 
 // GetLiquidity returns total liquidity within a price range
-func (ob *OrderBook) GetLiquidity(fromMid, percentage float64) (float64, float64) {
+func (ob *OrderBook) GetLiquidity(fromMid, percentage float64) (types.Decimal, types.Decimal) {
 	midPrice, exists := ob.GetMidPrice()
 	if !exists {
-		return 0, 0
+		return types.Zero, types.Zero
 	}
 
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
-	var bidLiquidity, askLiquidity float64
+	var bidLiquidity, askLiquidity types.Decimal
 
 	// Calculate bid liquidity within percentage range
-	minBidPrice := midPrice * (1 - percentage)
+	minBidPrice := midPrice.Mul(types.NewDecimal(1 - percentage))
 	for _, bid := range ob.bids {
-		if bid.Price >= minBidPrice {
-			bidLiquidity += bid.Quantity
+		if bid.Price.GreaterThanOrEqual(minBidPrice) {
+			bidLiquidity = bidLiquidity.Add(bid.Quantity)
 		}
 	}
 
 	// Calculate ask liquidity within percentage range
-	maxAskPrice := midPrice * (1 + percentage)
+	maxAskPrice := midPrice.Mul(types.NewDecimal(1 + percentage))
 	for _, ask := range ob.asks {
-		if ask.Price <= maxAskPrice {
-			askLiquidity += ask.Quantity
+		if ask.Price.LessThanOrEqual(maxAskPrice) {
+			askLiquidity = askLiquidity.Add(ask.Quantity)
 		}
 	}
 
@@ -155,28 +380,28 @@ func (ob *OrderBook) GetLiquidity(fromMid, percentage float64) (float64, float64
 func (ob *OrderBook) GetOrderBookImbalance() float64 {
 	bidLiq, askLiq := ob.GetLiquidity(0, 0.01) // 1% from mid
 
-	totalLiq := bidLiq + askLiq
-	if totalLiq == 0 {
+	totalLiq := bidLiq.Add(askLiq)
+	if totalLiq.IsZero() {
 		return 0
 	}
 
 	// Returns positive for bid-heavy, negative for ask-heavy
-	return (bidLiq - askLiq) / totalLiq
+	return bidLiq.Sub(askLiq).Float64() / totalLiq.Float64()
 }
 
 // CanFill checks if an order can be filled at the given price and quantity
-func (ob *OrderBook) CanFill(side types.Side, price, quantity float64) bool {
+func (ob *OrderBook) CanFill(side types.Side, price, quantity types.Decimal) bool {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
-	var availableQty float64
+	var availableQty types.Decimal
 
 	if side == types.SideBuy {
 		// Buying against asks
 		for _, ask := range ob.asks {
-			if ask.Price <= price {
-				availableQty += ask.Quantity
-				if availableQty >= quantity {
+			if ask.Price.LessThanOrEqual(price) {
+				availableQty = availableQty.Add(ask.Quantity)
+				if availableQty.GreaterThanOrEqual(quantity) {
 					return true
 				}
 			}
@@ -184,9 +409,9 @@ func (ob *OrderBook) CanFill(side types.Side, price, quantity float64) bool {
 	} else {
 		// Selling against bids
 		for _, bid := range ob.bids {
-			if bid.Price >= price {
-				availableQty += bid.Quantity
-				if availableQty >= quantity {
+			if bid.Price.GreaterThanOrEqual(price) {
+				availableQty = availableQty.Add(bid.Quantity)
+				if availableQty.GreaterThanOrEqual(quantity) {
 					return true
 				}
 			}
@@ -197,51 +422,75 @@ func (ob *OrderBook) CanFill(side types.Side, price, quantity float64) bool {
 }
 
 // GetFillPrice calculates the average fill price for a market order
-func (ob *OrderBook) GetFillPrice(side types.Side, quantity float64) (float64, bool) {
+func (ob *OrderBook) GetFillPrice(side types.Side, quantity types.Decimal) (types.Decimal, bool) {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
 	var remainingQty = quantity
-	var totalCost float64
+	var totalCost types.Decimal
 
 	if side == types.SideBuy {
 		// Buying against asks
 		for _, ask := range ob.asks {
-			if remainingQty <= 0 {
+			if !remainingQty.IsPositive() {
 				break
 			}
 
-			fillQty := ask.Quantity
-			if fillQty > remainingQty {
-				fillQty = remainingQty
-			}
+			fillQty := types.MinDecimal(ask.Quantity, remainingQty)
 
-			totalCost += fillQty * ask.Price
-			remainingQty -= fillQty
+			totalCost = totalCost.Add(fillQty.Mul(ask.Price))
+			remainingQty = remainingQty.Sub(fillQty)
 		}
 	} else {
 		// Selling against bids
 		for _, bid := range ob.bids {
-			if remainingQty <= 0 {
+			if !remainingQty.IsPositive() {
 				break
 			}
 
-			fillQty := bid.Quantity
-			if fillQty > remainingQty {
-				fillQty = remainingQty
-			}
+			fillQty := types.MinDecimal(bid.Quantity, remainingQty)
 
-			totalCost += fillQty * bid.Price
-			remainingQty -= fillQty
+			totalCost = totalCost.Add(fillQty.Mul(bid.Price))
+			remainingQty = remainingQty.Sub(fillQty)
 		}
 	}
 
-	if remainingQty > 0 {
+	if remainingQty.IsPositive() || !quantity.IsPositive() {
 		// Could not fill completely
-		return 0, false
+		return types.Zero, false
+	}
+
+	return totalCost.Div(quantity), true
+}
+
+// GetFills returns the per-level fills an order of the given side and
+// quantity would receive without changing the book. A non-zero limit stops
+// the walk at prices worse than the limit, so the fills may cover less than
+// the requested quantity.
+func (ob *OrderBook) GetFills(side types.Side, quantity, limit types.Decimal) []types.Fill {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	levels := ob.asks
+	worse := types.Decimal.GreaterThan
+	if side == types.SideSell {
+		levels = ob.bids
+		worse = types.Decimal.LessThan
+	}
+
+	var fills []types.Fill
+	remaining := quantity
+	for _, level := range levels {
+		if !remaining.IsPositive() || (!limit.IsZero() && worse(level.Price, limit)) {
+			break
+		}
+
+		fillQty := types.MinDecimal(level.Quantity, remaining)
+		fills = append(fills, types.Fill{Price: level.Price, Quantity: fillQty})
+		remaining = remaining.Sub(fillQty)
 	}
 
-	return totalCost / quantity, true
+	return fills
 }
 
 // String returns a string representation of the order book
@@ -23,14 +23,14 @@ func TestOrderBookUpdate(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0},
-			{Price: 49950, Quantity: 2.0},
-			{Price: 49900, Quantity: 1.5},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(1.5)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
-			{Price: 50150, Quantity: 2.0},
-			{Price: 50200, Quantity: 1.5},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(50200), Quantity: types.NewDecimal(1.5)},
 		},
 	}
 
@@ -41,14 +41,14 @@ func TestOrderBookUpdate(t *testing.T) {
 	assert.Len(t, ob.asks, 3)
 
 	// Check bid sorting (descending)
-	assert.Equal(t, 50000.0, ob.bids[0].Price)
-	assert.Equal(t, 49950.0, ob.bids[1].Price)
-	assert.Equal(t, 49900.0, ob.bids[2].Price)
+	assert.Equal(t, types.NewDecimal(50000.0), ob.bids[0].Price)
+	assert.Equal(t, types.NewDecimal(49950.0), ob.bids[1].Price)
+	assert.Equal(t, types.NewDecimal(49900.0), ob.bids[2].Price)
 
 	// Check ask sorting (ascending)
-	assert.Equal(t, 50100.0, ob.asks[0].Price)
-	assert.Equal(t, 50150.0, ob.asks[1].Price)
-	assert.Equal(t, 50200.0, ob.asks[2].Price)
+	assert.Equal(t, types.NewDecimal(50100.0), ob.asks[0].Price)
+	assert.Equal(t, types.NewDecimal(50150.0), ob.asks[1].Price)
+	assert.Equal(t, types.NewDecimal(50200.0), ob.asks[2].Price)
 }
 
 func TestGetBestBidAsk(t *testing.T) {
@@ -66,12 +66,12 @@ func TestGetBestBidAsk(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0},
-			{Price: 49950, Quantity: 2.0},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
-			{Price: 50150, Quantity: 2.0},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
 		},
 	}
 
@@ -80,14 +80,14 @@ func TestGetBestBidAsk(t *testing.T) {
 	// Test best bid
 	bidPrice, bidQty, exists := ob.GetBestBid()
 	assert.True(t, exists)
-	assert.Equal(t, 50000.0, bidPrice)
-	assert.Equal(t, 1.0, bidQty)
+	assert.Equal(t, types.NewDecimal(50000.0), bidPrice)
+	assert.Equal(t, types.NewDecimal(1.0), bidQty)
 
 	// Test best ask
 	askPrice, askQty, exists := ob.GetBestAsk()
 	assert.True(t, exists)
-	assert.Equal(t, 50100.0, askPrice)
-	assert.Equal(t, 1.0, askQty)
+	assert.Equal(t, types.NewDecimal(50100.0), askPrice)
+	assert.Equal(t, types.NewDecimal(1.0), askQty)
 }
 
 func TestGetSpread(t *testing.T) {
@@ -97,10 +97,10 @@ func TestGetSpread(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
 		},
 	}
 
@@ -108,7 +108,7 @@ func TestGetSpread(t *testing.T) {
 
 	spread, exists := ob.GetSpread()
 	assert.True(t, exists)
-	assert.Equal(t, 100.0, spread)
+	assert.Equal(t, types.NewDecimal(100.0), spread)
 }
 
 func TestGetMidPrice(t *testing.T) {
@@ -118,10 +118,10 @@ func TestGetMidPrice(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
 		},
 	}
 
@@ -129,7 +129,7 @@ func TestGetMidPrice(t *testing.T) {
 
 	midPrice, exists := ob.GetMidPrice()
 	assert.True(t, exists)
-	assert.Equal(t, 50050.0, midPrice)
+	assert.Equal(t, types.NewDecimal(50050.0), midPrice)
 }
 
 func TestGetCumulativeDepth(t *testing.T) {
@@ -139,26 +139,26 @@ func TestGetCumulativeDepth(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0},
-			{Price: 49950, Quantity: 2.0},
-			{Price: 49900, Quantity: 1.5},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(1.5)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
-			{Price: 50150, Quantity: 2.0},
-			{Price: 50200, Quantity: 1.5},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(50200), Quantity: types.NewDecimal(1.5)},
 		},
 	}
 
 	ob.Update(snapshot)
 
 	// Test bid depth
-	bidDepth := ob.GetCumulativeDepth(types.SideBuy, 49950)
-	assert.Equal(t, 3.0, bidDepth) // 1.0 + 2.0 from levels >= 49950
+	bidDepth := ob.GetCumulativeDepth(types.SideBuy, types.NewDecimal(49950))
+	assert.Equal(t, types.NewDecimal(3.0), bidDepth) // 1.0 + 2.0 from levels >= 49950
 
 	// Test ask depth
-	askDepth := ob.GetCumulativeDepth(types.SideSell, 50150)
-	assert.Equal(t, 3.0, askDepth) // 1.0 + 2.0 from levels <= 50150
+	askDepth := ob.GetCumulativeDepth(types.SideSell, types.NewDecimal(50150))
+	assert.Equal(t, types.NewDecimal(3.0), askDepth) // 1.0 + 2.0 from levels <= 50150
 }
 
 func TestCanFill(t *testing.T) {
@@ -168,29 +168,29 @@ func TestCanFill(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0},
-			{Price: 49950, Quantity: 2.0},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
-			{Price: 50150, Quantity: 2.0},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
 		},
 	}
 
 	ob.Update(snapshot)
 
 	// Test buy order (against asks)
-	canFill := ob.CanFill(types.SideBuy, 50150, 2.5)
+	canFill := ob.CanFill(types.SideBuy, types.NewDecimal(50150), types.NewDecimal(2.5))
 	assert.True(t, canFill) // Can fill 1.0 + 2.0 = 3.0 >= 2.5
 
-	canFill = ob.CanFill(types.SideBuy, 50150, 4.0)
+	canFill = ob.CanFill(types.SideBuy, types.NewDecimal(50150), types.NewDecimal(4.0))
 	assert.False(t, canFill) // Only 3.0 available
 
 	// Test sell order (against bids)
-	canFill = ob.CanFill(types.SideSell, 49950, 2.5)
+	canFill = ob.CanFill(types.SideSell, types.NewDecimal(49950), types.NewDecimal(2.5))
 	assert.True(t, canFill) // Can fill 1.0 + 2.0 = 3.0 >= 2.5
 
-	canFill = ob.CanFill(types.SideSell, 49950, 4.0)
+	canFill = ob.CanFill(types.SideSell, types.NewDecimal(49950), types.NewDecimal(4.0))
 	assert.False(t, canFill) // Only 3.0 available
 }
 
@@ -201,31 +201,31 @@ func TestGetFillPrice(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0},
-			{Price: 49950, Quantity: 2.0},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
-			{Price: 50150, Quantity: 2.0},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
 		},
 	}
 
 	ob.Update(snapshot)
 
 	// Test buy order (market buy against asks)
-	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, 2.0)
+	fillPrice, canFill := ob.GetFillPrice(types.SideBuy, types.NewDecimal(2.0))
 	require.True(t, canFill)
-	expectedPrice := (1.0*50100 + 1.0*50150) / 2.0 // 50125
+	expectedPrice := types.NewDecimal(50125) // (1.0*50100 + 1.0*50150) / 2.0
 	assert.Equal(t, expectedPrice, fillPrice)
 
 	// Test sell order (market sell against bids)
-	fillPrice, canFill = ob.GetFillPrice(types.SideSell, 2.0)
+	fillPrice, canFill = ob.GetFillPrice(types.SideSell, types.NewDecimal(2.0))
 	require.True(t, canFill)
-	expectedPrice = (1.0*50000 + 1.0*49950) / 2.0 // 49975
+	expectedPrice = types.NewDecimal(49975) // (1.0*50000 + 1.0*49950) / 2.0
 	assert.Equal(t, expectedPrice, fillPrice)
 
 	// Test insufficient liquidity
-	_, canFill = ob.GetFillPrice(types.SideBuy, 5.0)
+	_, canFill = ob.GetFillPrice(types.SideBuy, types.NewDecimal(5.0))
 	assert.False(t, canFill)
 }
 
@@ -236,12 +236,12 @@ func TestGetLiquidity(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 1.0}, // within 1% from mid (50050)
-			{Price: 49500, Quantity: 2.0}, // outside 1% from mid
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)}, // within 1% from mid (50050)
+			{Price: types.NewDecimal(49500), Quantity: types.NewDecimal(2.0)}, // outside 1% from mid
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0}, // within 1% from mid (50050)
-			{Price: 50600, Quantity: 2.0}, // outside 1% from mid
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)}, // within 1% from mid (50050)
+			{Price: types.NewDecimal(50600), Quantity: types.NewDecimal(2.0)}, // outside 1% from mid
 		},
 	}
 
@@ -251,9 +251,9 @@ func TestGetLiquidity(t *testing.T) {
 	// 1% range: 49549.5 to 50550.5
 	// Bids within range: 50000 (1.0)
 	// Asks within range: 50100 (1.0)
-	bidLiq, askLiq := ob.GetLiquidity(0, 0.01) // 1%
-	assert.Equal(t, 1.0, bidLiq)               // Only the 50000 bid is within 1%
-	assert.Equal(t, 1.0, askLiq)               // Only the 50100 ask is within 1%
+	bidLiq, askLiq := ob.GetLiquidity(0, 0.01)     // 1%
+	assert.Equal(t, types.NewDecimal(1.0), bidLiq) // Only the 50000 bid is within 1%
+	assert.Equal(t, types.NewDecimal(1.0), askLiq) // Only the 50100 ask is within 1%
 }
 
 func TestGetOrderBookImbalance(t *testing.T) {
@@ -263,10 +263,10 @@ func TestGetOrderBookImbalance(t *testing.T) {
 		Symbol:    "BTCUSD",
 		Timestamp: time.Now(),
 		Bids: []types.OrderBookEntry{
-			{Price: 50000, Quantity: 3.0},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(3.0)},
 		},
 		Asks: []types.OrderBookEntry{
-			{Price: 50100, Quantity: 1.0},
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
 		},
 	}
 
@@ -276,3 +276,268 @@ func TestGetOrderBookImbalance(t *testing.T) {
 	// (3.0 - 1.0) / (3.0 + 1.0) = 0.5
 	assert.Equal(t, 0.5, imbalance)
 }
+
+func TestApplyDelta(t *testing.T) {
+	ob := New()
+
+	ob.Update(types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: time.Now(),
+		Bids: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(1.5)},
+		},
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50200), Quantity: types.NewDecimal(1.5)},
+		},
+	})
+
+	err := ob.ApplyDelta(types.OrderBookDelta{
+		Symbol:    "BTCUSD",
+		Timestamp: time.Now(),
+		Updates: []types.PriceLevelUpdate{
+			{Side: types.SideBuy, Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},  // insert between levels
+			{Side: types.SideBuy, Price: types.NewDecimal(50050), Quantity: types.NewDecimal(0.5)},  // insert new best bid
+			{Side: types.SideBuy, Price: types.NewDecimal(49900), Quantity: types.NewDecimal(0)},    // remove level
+			{Side: types.SideSell, Price: types.NewDecimal(50100), Quantity: types.NewDecimal(3.0)}, // update quantity
+			{Side: types.SideSell, Price: types.NewDecimal(50300), Quantity: types.NewDecimal(1.0)}, // append worst ask
+			{Side: types.SideSell, Price: types.NewDecimal(50250), Quantity: types.NewDecimal(0)},   // remove missing level is a no-op
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, ob.bids, 3)
+	assert.Equal(t, types.NewDecimal(50050.0), ob.bids[0].Price)
+	assert.Equal(t, types.NewDecimal(50000.0), ob.bids[1].Price)
+	assert.Equal(t, types.NewDecimal(49950.0), ob.bids[2].Price)
+	assert.Equal(t, types.NewDecimal(2.0), ob.bids[2].Quantity)
+
+	require.Len(t, ob.asks, 3)
+	assert.Equal(t, types.NewDecimal(50100.0), ob.asks[0].Price)
+	assert.Equal(t, types.NewDecimal(3.0), ob.asks[0].Quantity)
+	assert.Equal(t, types.NewDecimal(50200.0), ob.asks[1].Price)
+	assert.Equal(t, types.NewDecimal(50300.0), ob.asks[2].Price)
+}
+
+func TestApplyDeltaRejectsInvalidSide(t *testing.T) {
+	ob := New()
+	ob.Update(types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)}},
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)}},
+	})
+
+	err := ob.ApplyDelta(types.OrderBookDelta{
+		Symbol: "BTCUSD",
+		Updates: []types.PriceLevelUpdate{
+			{Side: types.SideBuy, Price: types.NewDecimal(50050), Quantity: types.NewDecimal(2.0)},
+			{Side: "bid", Price: types.NewDecimal(49990), Quantity: types.NewDecimal(2.0)},
+		},
+	})
+	assert.Error(t, err)
+
+	// Nothing of the rejected delta is applied
+	assert.Equal(t, []types.OrderBookEntry{{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)}}, ob.bids)
+	assert.Equal(t, []types.OrderBookEntry{{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)}}, ob.asks)
+}
+
+func TestApplyDeltaMatchesSnapshot(t *testing.T) {
+	start := time.Now()
+
+	replayed := New()
+	replayed.Update(types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: start,
+		Bids: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(1.5)},
+		},
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(50200), Quantity: types.NewDecimal(1.5)},
+		},
+	})
+
+	deltas := []types.OrderBookDelta{
+		{
+			Symbol:    "BTCUSD",
+			Timestamp: start.Add(100 * time.Millisecond),
+			Updates: []types.PriceLevelUpdate{
+				{Side: types.SideSell, Price: types.NewDecimal(50100), Quantity: types.NewDecimal(0)},
+				{Side: types.SideBuy, Price: types.NewDecimal(50050), Quantity: types.NewDecimal(0.8)},
+			},
+		},
+		{
+			Symbol:    "BTCUSD",
+			Timestamp: start.Add(200 * time.Millisecond),
+			Updates: []types.PriceLevelUpdate{
+				{Side: types.SideBuy, Price: types.NewDecimal(49900), Quantity: types.NewDecimal(0)},
+				{Side: types.SideBuy, Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.5)},
+				{Side: types.SideSell, Price: types.NewDecimal(50125), Quantity: types.NewDecimal(0.7)},
+				{Side: types.SideSell, Price: types.NewDecimal(50250), Quantity: types.NewDecimal(1.1)},
+			},
+		},
+	}
+	for _, delta := range deltas {
+		replayed.ApplyDelta(delta)
+	}
+
+	expected := New()
+	expected.Update(types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: start.Add(200 * time.Millisecond),
+		Bids: []types.OrderBookEntry{
+			{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(2.5)},
+			{Price: types.NewDecimal(50050), Quantity: types.NewDecimal(0.8)},
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+		},
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50250), Quantity: types.NewDecimal(1.1)},
+			{Price: types.NewDecimal(50125), Quantity: types.NewDecimal(0.7)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(50200), Quantity: types.NewDecimal(1.5)},
+		},
+	})
+
+	assert.Equal(t, expected.symbol, replayed.symbol)
+	assert.Equal(t, expected.lastUpdated, replayed.lastUpdated)
+	assert.Equal(t, expected.bids, replayed.bids)
+	assert.Equal(t, expected.asks, replayed.asks)
+}
+
+func TestConsume(t *testing.T) {
+	ob := New()
+	start := time.Now()
+
+	snapshot := types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: start,
+		Bids: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1.0)},
+		},
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(2.0)},
+		},
+	}
+	ob.Update(snapshot)
+
+	fills := ob.Consume(types.SideBuy, types.NewDecimal(1.5), types.Zero, 0)
+	require.Len(t, fills, 2)
+	assert.Equal(t, types.Fill{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)}, fills[0])
+	assert.Equal(t, types.Fill{Price: types.NewDecimal(50150), Quantity: types.NewDecimal(0.5)}, fills[1])
+
+	askPrice, askQty, _ := ob.GetBestAsk()
+	assert.Equal(t, types.NewDecimal(50150), askPrice)
+	assert.Equal(t, types.NewDecimal(1.5), askQty)
+
+	// A limit stops the walk at worse prices
+	fills = ob.Consume(types.SideBuy, types.NewDecimal(5.0), types.NewDecimal(50100), 0)
+	assert.Empty(t, fills)
+
+	// The next snapshot restores the book
+	snapshot.Timestamp = start.Add(100 * time.Millisecond)
+	ob.Update(snapshot)
+	askPrice, askQty, _ = ob.GetBestAsk()
+	assert.Equal(t, types.NewDecimal(50100), askPrice)
+	assert.Equal(t, types.NewDecimal(1.0), askQty)
+}
+
+func TestConsumeReplenishment(t *testing.T) {
+	ob := New()
+	start := time.Now()
+
+	snapshot := types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: start,
+		Bids: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(4.0)},
+		},
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+		},
+	}
+	ob.Update(snapshot)
+
+	ob.Consume(types.SideSell, types.NewDecimal(2.0), types.Zero, time.Second)
+
+	// One half-life later half of the consumed quantity is still missing
+	snapshot.Timestamp = start.Add(time.Second)
+	ob.Update(snapshot)
+	_, bidQty, _ := ob.GetBestBid()
+	assert.Equal(t, types.NewDecimal(3.0), bidQty)
+
+	// Two half-lives later a quarter is still missing
+	snapshot.Timestamp = start.Add(2 * time.Second)
+	ob.Update(snapshot)
+	_, bidQty, _ = ob.GetBestBid()
+	assert.Equal(t, types.NewDecimal(3.5), bidQty)
+}
+
+func TestConsumeReplenishmentAcrossDeltas(t *testing.T) {
+	ob := New()
+	start := time.Now()
+
+	ob.Update(types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: start,
+		Bids:      []types.OrderBookEntry{{Price: types.NewDecimal(50000), Quantity: types.NewDecimal(4.0)}},
+		Asks:      []types.OrderBookEntry{{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)}},
+	})
+	ob.Consume(types.SideSell, types.NewDecimal(2.0), types.Zero, time.Second)
+
+	// A delta restating the level keeps the decaying impact, as a snapshot does
+	ob.ApplyDelta(types.OrderBookDelta{
+		Symbol:    "BTCUSD",
+		Timestamp: start.Add(time.Second),
+		Updates:   []types.PriceLevelUpdate{{Side: types.SideBuy, Price: types.NewDecimal(50000), Quantity: types.NewDecimal(4.0)}},
+	})
+	_, bidQty, _ := ob.GetBestBid()
+	assert.Equal(t, types.NewDecimal(3.0), bidQty)
+
+	// Without a half-life the level's next delta restores it
+	ob.Consume(types.SideBuy, types.NewDecimal(0.5), types.Zero, 0)
+	ob.ApplyDelta(types.OrderBookDelta{
+		Symbol:    "BTCUSD",
+		Timestamp: start.Add(2 * time.Second),
+		Updates: []types.PriceLevelUpdate{
+			{Side: types.SideBuy, Price: types.NewDecimal(50000), Quantity: types.NewDecimal(4.0)},
+			{Side: types.SideSell, Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1.0)},
+		},
+	})
+	_, bidQty, _ = ob.GetBestBid()
+	assert.Equal(t, types.NewDecimal(3.5), bidQty)
+	_, askQty, _ := ob.GetBestAsk()
+	assert.Equal(t, types.NewDecimal(1.0), askQty)
+}
+
+func TestGetFills(t *testing.T) {
+	ob := New()
+	ob.Update(types.OrderBookSnapshot{
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(100), Quantity: types.NewDecimal(1.0)},
+			{Price: types.NewDecimal(101), Quantity: types.NewDecimal(2.0)},
+			{Price: types.NewDecimal(102), Quantity: types.NewDecimal(3.0)},
+		},
+	})
+
+	fills := ob.GetFills(types.SideBuy, types.NewDecimal(2.5), types.Zero)
+	assert.Equal(t, []types.Fill{
+		{Price: types.NewDecimal(100), Quantity: types.NewDecimal(1.0)},
+		{Price: types.NewDecimal(101), Quantity: types.NewDecimal(1.5)},
+	}, fills)
+
+	// Limit stops the walk; the book is left untouched
+	fills = ob.GetFills(types.SideBuy, types.NewDecimal(5.0), types.NewDecimal(101))
+	assert.Equal(t, []types.Fill{
+		{Price: types.NewDecimal(100), Quantity: types.NewDecimal(1.0)},
+		{Price: types.NewDecimal(101), Quantity: types.NewDecimal(2.0)},
+	}, fills)
+	_, qty, _ := ob.GetBestAsk()
+	assert.Equal(t, types.NewDecimal(1.0), qty)
+
+	assert.Empty(t, ob.GetFills(types.SideSell, types.NewDecimal(1.0), types.Zero))
+}
@@ -0,0 +1,56 @@
+package orderbook
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registry holds one order book per symbol so a single session can
+// track several instruments from one feed
+type Registry struct {
+	mu    sync.RWMutex
+	books map[string]*OrderBook
+}
+
+// NewRegistry creates an empty book registry
+func NewRegistry() *Registry {
+	return &Registry{
+		books: make(map[string]*OrderBook),
+	}
+}
+
+// Get returns the order book for a symbol if one exists
+func (r *Registry) Get(symbol string) (*OrderBook, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ob, exists := r.books[symbol]
+	return ob, exists
+}
+
+// GetOrCreate returns the order book for a symbol, creating it if needed
+func (r *Registry) GetOrCreate(symbol string) *OrderBook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ob, exists := r.books[symbol]
+	if !exists {
+		ob = New()
+		ob.symbol = symbol
+		r.books[symbol] = ob
+	}
+	return ob
+}
+
+// Symbols returns the registered symbols in sorted order
+func (r *Registry) Symbols() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	symbols := make([]string, 0, len(r.books))
+	for symbol := range r.books {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,149 @@
+package portfolio
+
+import (
+	"fmt"
+	"time"
+	"trading-engine/internal/types"
+)
+
+// Method selects which open lots a reducing fill is matched against
+type Method string
+
+const (
+	MethodFIFO    Method = "fifo"    // oldest lots first
+	MethodLIFO    Method = "lifo"    // newest lots first
+	MethodAverage Method = "average" // a single lot at the average cost
+)
+
+// DefaultMethod is used when no matching method is configured
+const DefaultMethod = MethodFIFO
+
+// ParseMethod returns the method with the given name, or DefaultMethod for
+// an empty name
+func ParseMethod(name string) (Method, error) {
+	switch method := Method(name); method {
+	case "":
+		return DefaultMethod, nil
+	case MethodFIFO, MethodLIFO, MethodAverage:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown lot matching method %q (want fifo, lifo or average)", name)
+}
+
+// Lot is an open quantity bought or sold at one price. Quantity is signed:
+// positive for a long lot and negative for a short one.
+type Lot struct {
+	Quantity types.Decimal
+	Price    types.Decimal
+	Time     time.Time
+}
+
+// holding is the open lots and accumulated P&L of one symbol. All open lots
+// are on the same side.
+type holding struct {
+	symbol   string
+	lots     []Lot
+	realized types.Decimal
+	mark     types.Decimal // last book mid, or the last fill price before the first book
+	fromBook bool          // mark is a book mid
+	fees     types.Decimal
+	fills    int
+}
+
+// quantity returns the signed open quantity
+func (h *holding) quantity() types.Decimal {
+	var total types.Decimal
+	for _, lot := range h.lots {
+		total = total.Add(lot.Quantity)
+	}
+	return total
+}
+
+// averageCost returns the average price of the open lots
+func (h *holding) averageCost() types.Decimal {
+	quantity := h.quantity()
+	if quantity.IsZero() {
+		return types.Zero
+	}
+	var cost types.Decimal
+	for _, lot := range h.lots {
+		cost = cost.Add(lot.Price.Mul(lot.Quantity))
+	}
+	return cost.Div(quantity)
+}
+
+// unrealized values the open lots at the mark
+func (h *holding) unrealized() types.Decimal {
+	var pnl types.Decimal
+	for _, lot := range h.lots {
+		pnl = pnl.Add(h.mark.Sub(lot.Price).Mul(lot.Quantity))
+	}
+	return pnl
+}
+
+// apply matches a fill against the open lots and returns the P&L it
+// realised. Quantity beyond the open lots opens a lot on the fill's side.
+func (h *holding) apply(method Method, side types.Side, quantity, price types.Decimal, timestamp time.Time) types.Decimal {
+	signed := quantity
+	if side == types.SideSell {
+		signed = quantity.Neg()
+	}
+	h.fills++
+	if !h.fromBook {
+		h.mark = price
+	}
+
+	open := h.quantity()
+	if open.IsZero() || open.Sign() == signed.Sign() {
+		h.add(method, Lot{Quantity: signed, Price: price, Time: timestamp})
+		return types.Zero
+	}
+
+	var realized types.Decimal
+	remaining := quantity
+	for remaining.IsPositive() && len(h.lots) > 0 {
+		i := 0
+		if method == MethodLIFO {
+			i = len(h.lots) - 1
+		}
+		lot := &h.lots[i]
+
+		closing := types.MinDecimal(remaining, lot.Quantity.Abs())
+		pnl := price.Sub(lot.Price).Mul(closing)
+		if lot.Quantity.IsNegative() {
+			pnl = pnl.Neg()
+			lot.Quantity = lot.Quantity.Add(closing)
+		} else {
+			lot.Quantity = lot.Quantity.Sub(closing)
+		}
+		realized = realized.Add(pnl)
+		remaining = remaining.Sub(closing)
+
+		if lot.Quantity.IsZero() {
+			h.lots = append(h.lots[:i], h.lots[i+1:]...)
+		}
+	}
+	if remaining.IsPositive() {
+		// Flipped: the rest of the fill opens a position on the other side
+		if side == types.SideSell {
+			remaining = remaining.Neg()
+		}
+		h.lots = append(h.lots, Lot{Quantity: remaining, Price: price, Time: timestamp})
+	}
+
+	h.realized = h.realized.Add(realized)
+	return realized
+}
+
+// add opens a lot, or averages it into the single lot of an average-cost
+// holding
+func (h *holding) add(method Method, lot Lot) {
+	if method != MethodAverage || len(h.lots) == 0 {
+		h.lots = append(h.lots, lot)
+		return
+	}
+	current := &h.lots[0]
+	quantity := current.Quantity.Add(lot.Quantity)
+	current.Price = current.Price.Mul(current.Quantity).Add(lot.Price.Mul(lot.Quantity)).Div(quantity)
+	current.Quantity = quantity
+}
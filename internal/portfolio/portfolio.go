@@ -0,0 +1,207 @@
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// Portfolio tracks the open lots of every symbol traded in a session from
+// the broker's execution reports and marks them to the book mid on every
+// engine update
+type Portfolio struct {
+	method      Method
+	bookUpdates <-chan types.OrderBookSnapshot
+	done        chan struct{}
+
+	mu       sync.Mutex
+	holdings map[string]*holding
+}
+
+// New creates an empty portfolio that matches reducing fills using method
+func New(method Method) *Portfolio {
+	if method == "" {
+		method = DefaultMethod
+	}
+	return &Portfolio{
+		method:   method,
+		done:     make(chan struct{}),
+		holdings: make(map[string]*holding),
+	}
+}
+
+// SetBookUpdates connects the portfolio to the book updates published by
+// the engine, which mark open positions to mid
+func (p *Portfolio) SetBookUpdates(updates <-chan types.OrderBookSnapshot) {
+	p.bookUpdates = updates
+}
+
+// Start marks positions with book updates until the engine closes the
+// updates channel
+func (p *Portfolio) Start() {
+	defer close(p.done)
+
+	for snapshot := range p.bookUpdates {
+		ob := orderbook.New()
+		ob.Update(snapshot)
+		if mid, exists := ob.GetMidPrice(); exists {
+			p.Mark(snapshot.Symbol, mid)
+		}
+	}
+}
+
+// Wait blocks until Start has processed the last book update
+func (p *Portfolio) Wait() {
+	<-p.done
+}
+
+// Apply books the fills and fee of an execution report and returns the P&L
+// the fills realised before fees. Reports without fills are ignored.
+func (p *Portfolio) Apply(execution types.Execution) types.Decimal {
+	if !execution.Quantity.IsPositive() {
+		return types.Zero
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := p.holding(execution.Symbol)
+	h.fees = h.fees.Add(execution.Fee)
+	return h.apply(p.method, execution.Side, execution.Quantity, execution.Price, execution.Timestamp)
+}
+
+// Mark values a symbol's open lots at price
+func (p *Portfolio) Mark(symbol string, price types.Decimal) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := p.holding(symbol)
+	h.mark, h.fromBook = price, true
+}
+
+// Lots returns a copy of a symbol's open lots in the order they were opened
+func (p *Portfolio) Lots(symbol string) []Lot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, exists := p.holdings[symbol]
+	if !exists {
+		return nil
+	}
+	return append([]Lot(nil), h.lots...)
+}
+
+// Position returns a symbol's net position marked at the latest price, or
+// false when nothing has been traded in it
+func (p *Portfolio) Position(symbol string) (types.Position, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, exists := p.holdings[symbol]
+	if !exists || h.fills == 0 {
+		return types.Position{}, false
+	}
+	position := types.Position{
+		Symbol:        symbol,
+		Quantity:      h.quantity(),
+		EntryPrice:    h.averageCost(),
+		CurrentPrice:  h.mark,
+		UnrealizedPnL: h.unrealized(),
+		RealizedPnL:   h.realized,
+	}
+	if len(h.lots) > 0 {
+		position.EntryTime = h.lots[0].Time
+	}
+	return position, true
+}
+
+// Report returns the P&L of every traded symbol and of the whole portfolio
+func (p *Portfolio) Report() Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := Report{Method: p.method}
+	for _, h := range p.holdings {
+		if h.fills == 0 {
+			// Marked by book updates but never traded
+			continue
+		}
+		symbol := SymbolReport{
+			Symbol:      h.symbol,
+			Quantity:    h.quantity(),
+			AverageCost: h.averageCost(),
+			MarkPrice:   h.mark,
+			OpenLots:    len(h.lots),
+			Fills:       h.fills,
+			Realized:    h.realized,
+			Unrealized:  h.unrealized(),
+			Fees:        h.fees,
+		}
+		symbol.Total = symbol.Realized.Add(symbol.Unrealized).Sub(symbol.Fees)
+
+		report.Symbols = append(report.Symbols, symbol)
+		report.Realized = report.Realized.Add(symbol.Realized)
+		report.Unrealized = report.Unrealized.Add(symbol.Unrealized)
+		report.Fees = report.Fees.Add(symbol.Fees)
+	}
+	report.Total = report.Realized.Add(report.Unrealized).Sub(report.Fees)
+	sort.Slice(report.Symbols, func(i, j int) bool { return report.Symbols[i].Symbol < report.Symbols[j].Symbol })
+	return report
+}
+
+// holding returns the holding of a symbol, creating it on first use. The
+// caller must hold mu.
+func (p *Portfolio) holding(symbol string) *holding {
+	h, exists := p.holdings[symbol]
+	if !exists {
+		h = &holding{symbol: symbol}
+		p.holdings[symbol] = h
+	}
+	return h
+}
+
+// SymbolReport is the position and P&L of one symbol. Unrealized P&L values
+// the open lots at MarkPrice, the last book mid or, before the first book,
+// the last fill price. Total is realized plus unrealized P&L less fees.
+type SymbolReport struct {
+	Symbol      string
+	Quantity    types.Decimal // signed: positive long, negative short
+	AverageCost types.Decimal
+	MarkPrice   types.Decimal
+	OpenLots    int
+	Fills       int
+	Realized    types.Decimal
+	Unrealized  types.Decimal
+	Fees        types.Decimal // net of rebates
+	Total       types.Decimal
+}
+
+// String summarises the symbol's position and P&L
+func (r SymbolReport) String() string {
+	return fmt.Sprintf("%s: position %.4f @ %.2f in %d lots, mark %.2f, realized %.2f, unrealized %.2f, fees %.2f, total %.2f",
+		r.Symbol, r.Quantity, r.AverageCost, r.OpenLots, r.MarkPrice, r.Realized, r.Unrealized, r.Fees, r.Total)
+}
+
+// Report is the P&L of a portfolio per symbol and in total
+type Report struct {
+	Method     Method
+	Symbols    []SymbolReport // sorted by symbol
+	Realized   types.Decimal
+	Unrealized types.Decimal
+	Fees       types.Decimal
+	Total      types.Decimal // realized plus unrealized less fees
+}
+
+// String summarises the portfolio P&L
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "total %.2f = realized %.2f + unrealized %.2f - fees %.2f (%s)",
+		r.Total, r.Realized, r.Unrealized, r.Fees, r.Method)
+	for _, symbol := range r.Symbols {
+		b.WriteString("\n  " + symbol.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fill returns a filled execution report
+func fill(side types.Side, quantity, price float64) types.Execution {
+	return types.Execution{
+		OrderID:   "O1",
+		Status:    types.StatusFilled,
+		Symbol:    "BTCUSD",
+		Side:      side,
+		Price:     types.NewDecimal(price),
+		Quantity:  types.NewDecimal(quantity),
+		Timestamp: time.Now(),
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	method, err := ParseMethod("")
+	require.NoError(t, err)
+	assert.Equal(t, MethodFIFO, method)
+
+	method, err = ParseMethod("lifo")
+	require.NoError(t, err)
+	assert.Equal(t, MethodLIFO, method)
+
+	_, err = ParseMethod("hifo")
+	assert.Error(t, err)
+}
+
+func TestLotMatching(t *testing.T) {
+	// Buy 1 @ 100 and 1 @ 110, then sell 1 @ 120 and mark at 130
+	tests := []struct {
+		method     Method
+		realized   float64
+		unrealized float64
+		lots       int
+	}{
+		{MethodFIFO, 20, 20, 1},    // the 100 lot is sold, the 110 lot is open
+		{MethodLIFO, 10, 30, 1},    // the 110 lot is sold, the 100 lot is open
+		{MethodAverage, 15, 25, 1}, // one lot at 105
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			p := New(tt.method)
+			p.Apply(fill(types.SideBuy, 1, 100))
+			p.Apply(fill(types.SideBuy, 1, 110))
+			assert.Equal(t, types.NewDecimal(tt.realized), p.Apply(fill(types.SideSell, 1, 120)))
+			p.Mark("BTCUSD", types.NewDecimal(130))
+
+			report := p.Report()
+			require.Len(t, report.Symbols, 1)
+			symbol := report.Symbols[0]
+			assert.Equal(t, types.NewDecimal(1), symbol.Quantity)
+			assert.Equal(t, tt.lots, symbol.OpenLots)
+			assert.Equal(t, types.NewDecimal(tt.realized), symbol.Realized)
+			assert.Equal(t, types.NewDecimal(tt.unrealized), symbol.Unrealized)
+			assert.Equal(t, types.NewDecimal(40), report.Total, "the method only moves P&L between realized and unrealized")
+		})
+	}
+}
+
+func TestShortsAndFlips(t *testing.T) {
+	p := New(MethodFIFO)
+	p.Apply(fill(types.SideSell, 1, 100))
+	p.Apply(fill(types.SideSell, 1, 104))
+
+	position, ok := p.Position("BTCUSD")
+	require.True(t, ok)
+	assert.Equal(t, types.NewDecimal(-2), position.Quantity)
+	assert.Equal(t, types.NewDecimal(102), position.EntryPrice)
+
+	// Buying 3 covers both short lots and opens a 1 lot long
+	assert.Equal(t, types.NewDecimal(6), p.Apply(fill(types.SideBuy, 3, 99)))
+	lots := p.Lots("BTCUSD")
+	require.Len(t, lots, 1)
+	assert.Equal(t, types.NewDecimal(1), lots[0].Quantity)
+	assert.Equal(t, types.NewDecimal(99), lots[0].Price)
+
+	p.Mark("BTCUSD", types.NewDecimal(97))
+	position, _ = p.Position("BTCUSD")
+	assert.Equal(t, types.NewDecimal(97), position.CurrentPrice)
+	assert.Equal(t, types.NewDecimal(-2), position.UnrealizedPnL)
+	assert.Equal(t, types.NewDecimal(6), position.RealizedPnL)
+}
+
+func TestReportsOnlyTradedSymbols(t *testing.T) {
+	p := New(MethodAverage)
+	p.Mark("ETHUSD", types.NewDecimal(3000))
+	p.Apply(types.Execution{Symbol: "BTCUSD", Status: types.StatusRejected})
+	assert.Empty(t, p.Report().Symbols)
+
+	_, ok := p.Position("ETHUSD")
+	assert.False(t, ok)
+}
+
+func TestMarksWithBookUpdates(t *testing.T) {
+	updates := make(chan types.OrderBookSnapshot, 2)
+	p := New(MethodFIFO)
+	p.SetBookUpdates(updates)
+	p.Apply(fill(types.SideBuy, 2, 50000))
+
+	updates <- types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(50090), Quantity: types.NewDecimal(1)}},
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(50110), Quantity: types.NewDecimal(1)}},
+	}
+	close(updates)
+	go p.Start()
+	p.Wait()
+
+	// A later fill does not replace the book mark
+	p.Apply(fill(types.SideSell, 1, 50200))
+
+	report := p.Report()
+	require.Len(t, report.Symbols, 1)
+	assert.Equal(t, types.NewDecimal(50100), report.Symbols[0].MarkPrice)
+	assert.Equal(t, types.NewDecimal(200), report.Realized)
+	assert.Equal(t, types.NewDecimal(100), report.Unrealized)
+	assert.Equal(t, types.NewDecimal(300), report.Total)
+}
+
+func TestFeesReduceTotal(t *testing.T) {
+	p := New(MethodFIFO)
+	buy := fill(types.SideBuy, 1, 100)
+	buy.Fee = types.NewDecimal(0.5)
+	sell := fill(types.SideSell, 1, 110)
+	sell.Fee = types.NewDecimal(-0.1) // maker rebate
+	p.Apply(buy)
+	p.Apply(sell)
+
+	report := p.Report()
+	assert.Equal(t, types.NewDecimal(10), report.Realized)
+	assert.Equal(t, types.NewDecimal(0.4), report.Fees)
+	assert.Equal(t, types.NewDecimal(9.6), report.Total)
+	assert.Equal(t, types.NewDecimal(9.6), report.Symbols[0].Total)
+}
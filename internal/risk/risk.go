@@ -0,0 +1,445 @@
+package risk
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// Limits are the pre-trade checks applied to every new order and replace.
+// A zero value disables its check.
+type Limits struct {
+	MaxOrderSize types.Decimal // quantity of a single order
+	MaxNotional  types.Decimal // quantity times price of a single order
+	MaxPosition  types.Decimal // absolute position per symbol if every working order on a side filled
+	PriceBand    float64       // fraction of mid a limit or stop price may be away from it
+	MaxOrderRate int           // orders and replaces passed per second on the feed clock
+	MaxDailyLoss types.Decimal // loss since the start of the UTC day that trips the kill switch
+}
+
+// Check names a pre-trade check
+type Check string
+
+const (
+	CheckOrderSize Check = "order size"
+	CheckNotional  Check = "notional"
+	CheckPosition  Check = "position"
+	CheckPriceBand Check = "price band"
+	CheckOrderRate Check = "order rate"
+	CheckDailyLoss Check = "daily loss"
+)
+
+// Stats counts the requests the manager checked and the rejects by check
+type Stats struct {
+	Checked    int
+	Passed     int
+	Rejected   map[Check]int
+	KillSwitch bool // the daily loss limit was reached today
+}
+
+// Rejects returns the number of requests rejected by any check
+func (s Stats) Rejects() int {
+	total := 0
+	for _, count := range s.Rejected {
+		total += count
+	}
+	return total
+}
+
+// String summarises the counters
+func (s Stats) String() string {
+	summary := fmt.Sprintf("%d checked, %d passed, %d rejected", s.Checked, s.Passed, s.Rejects())
+	if len(s.Rejected) > 0 {
+		checks := make([]string, 0, len(s.Rejected))
+		for check, count := range s.Rejected {
+			checks = append(checks, fmt.Sprintf("%s %d", check, count))
+		}
+		sort.Strings(checks)
+		summary += " (" + strings.Join(checks, ", ") + ")"
+	}
+	if s.KillSwitch {
+		summary += ", kill switch tripped"
+	}
+	return summary
+}
+
+// rejectPrefix starts the reason of every reject the manager sends
+const rejectPrefix = "risk: "
+
+// workingOrder is an order the manager passed that has not reached a
+// terminal state. It keeps the terms the broker has accepted; replaces the
+// manager passed wait in pending, in send order, until the broker answers.
+type workingOrder struct {
+	signal    types.TradeSignal
+	remaining types.Decimal
+	pending   []types.TradeSignal
+}
+
+// projected returns the order as it will stand once the pending replaces
+// are accepted
+func (o *workingOrder) projected() *workingOrder {
+	projected := *o
+	for _, replace := range o.pending {
+		projected.signal = amended(&projected, replace)
+		projected.remaining = projected.signal.Quantity
+	}
+	projected.pending = nil
+	return &projected
+}
+
+// Manager sits between a strategy and the broker. It forwards requests that
+// pass the pre-trade checks and answers the others with a rejected
+// execution report. Positions, working orders and the day's P&L are kept
+// from the broker's reports passed to Apply.
+type Manager struct {
+	limits     Limits
+	books      *orderbook.Registry
+	signals    <-chan types.TradeSignal
+	orders     chan<- types.TradeSignal
+	executions chan<- types.Execution
+	stop       chan struct{}
+
+	mu        sync.Mutex
+	positions map[string]*types.Position
+	fees      types.Decimal
+	working   map[string]*workingOrder // by client order ID
+	passed    []time.Time              // feed times of the requests passed in the last second
+	day       time.Time                // start of the current UTC day
+	dayStart  types.Decimal            // session P&L at the start of the day
+	stats     Stats
+}
+
+// New creates a manager that checks the requests on signals against limits,
+// forwards the ones that pass on orders and reports rejects on executions
+func New(books *orderbook.Registry, limits Limits, signals <-chan types.TradeSignal,
+	orders chan<- types.TradeSignal, executions chan<- types.Execution) *Manager {
+	return &Manager{
+		limits:     limits,
+		books:      books,
+		signals:    signals,
+		orders:     orders,
+		executions: executions,
+		stop:       make(chan struct{}),
+		positions:  make(map[string]*types.Position),
+		working:    make(map[string]*workingOrder),
+		stats:      Stats{Rejected: make(map[Check]int)},
+	}
+}
+
+// Start checks requests until the signals channel is closed, then closes
+// the orders channel
+func (m *Manager) Start() {
+	log.Println("Risk manager started")
+
+	for signal := range m.signals {
+		if reason, check := m.check(signal); check != "" {
+			m.reject(signal, reason)
+			continue
+		}
+		// The broker always drains its signals, so wait rather than drop
+		// an order the strategy believes was sent
+		m.orders <- signal
+	}
+
+	log.Println("Risk manager finished")
+	close(m.orders)
+}
+
+// Stop abandons rejects waiting for room on the executions channel, for
+// when nothing will read the channel again
+func (m *Manager) Stop() {
+	close(m.stop)
+}
+
+// Stats returns a copy of the counters
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Rejected = make(map[Check]int, len(m.stats.Rejected))
+	for check, count := range m.stats.Rejected {
+		stats.Rejected[check] = count
+	}
+	return stats
+}
+
+// Apply updates positions and working orders with a report from the broker
+func (m *Manager) Apply(execution types.Execution) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if execution.Quantity.IsPositive() {
+		position, exists := m.positions[execution.Symbol]
+		if !exists {
+			position = &types.Position{Symbol: execution.Symbol}
+			m.positions[execution.Symbol] = position
+		}
+		position.Apply(execution.Side, execution.Quantity, execution.Price, execution.Timestamp)
+		m.fees = m.fees.Add(execution.Fee)
+	}
+
+	order, exists := m.working[execution.OrderID]
+	if !exists {
+		return
+	}
+	if execution.Replaced && len(order.pending) > 0 {
+		// The broker took the oldest pending replace
+		order.signal = amended(order, order.pending[0])
+		order.remaining = order.signal.Quantity
+		order.pending = order.pending[1:]
+	}
+	order.remaining = order.remaining.Sub(execution.Quantity)
+	switch {
+	case execution.Status == types.StatusRequestRejected:
+		// A rejected cancel or replace leaves the order working. A broker
+		// reject drops the oldest pending replace; the manager's own
+		// rejects were never pending.
+		if !strings.HasPrefix(execution.Reason, rejectPrefix) && len(order.pending) > 0 {
+			order.pending = order.pending[1:]
+		}
+	case execution.Status.IsTerminal():
+		delete(m.working, execution.OrderID)
+	}
+}
+
+// check returns why a request fails a check and the check it failed, or an
+// empty check when it may go to the broker. Cancels always pass.
+func (m *Manager) check(signal types.TradeSignal) (string, Check) {
+	if signal.Action == types.ActionCancel {
+		return "", ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order := signal
+	var working, previous *workingOrder
+	if signal.Action == types.ActionReplace {
+		working = m.working[signal.OrderID]
+		if working == nil {
+			// Unknown to the manager; the broker answers it
+			return "", ""
+		}
+		// Check against the terms the order will have after the replaces
+		// already sent, and leave it out of the other working orders
+		previous = working.projected()
+		order = amended(previous, signal)
+	}
+
+	m.stats.Checked++
+	now := m.now(order.Symbol)
+	reason, check := m.checkLimits(order, previous, now)
+	if check != "" {
+		m.stats.Rejected[check]++
+		return reason, check
+	}
+
+	m.stats.Passed++
+	m.passed = append(m.passed, now)
+	if working != nil {
+		// The amendment applies once the broker accepts it
+		working.pending = append(working.pending, signal)
+	} else if order.OrderID != "" {
+		m.working[order.OrderID] = &workingOrder{signal: order, remaining: order.Quantity}
+	}
+	return "", ""
+}
+
+// checkLimits applies every check to an order. previous is the working
+// order a replace amends, or nil for a new order.
+func (m *Manager) checkLimits(order types.TradeSignal, previous *workingOrder, now time.Time) (string, Check) {
+	mid, hasMid := types.Zero, false
+	if ob, exists := m.books.Get(order.Symbol); exists {
+		mid, hasMid = ob.GetMidPrice()
+	}
+
+	if loss := m.dailyLoss(now); m.limits.MaxDailyLoss.IsPositive() && !m.stats.KillSwitch &&
+		loss.GreaterThanOrEqual(m.limits.MaxDailyLoss) {
+		log.Printf("Risk kill switch tripped: daily loss %.2f reached limit %.2f", loss, m.limits.MaxDailyLoss)
+		m.stats.KillSwitch = true
+	}
+	if m.stats.KillSwitch && !m.reduces(order, previous) {
+		return "kill switch tripped by daily loss, only reducing orders pass", CheckDailyLoss
+	}
+
+	if m.limits.MaxOrderSize.IsPositive() && order.Quantity.GreaterThan(m.limits.MaxOrderSize) {
+		return fmt.Sprintf("order size %.4f exceeds limit %.4f", order.Quantity, m.limits.MaxOrderSize), CheckOrderSize
+	}
+
+	if m.limits.PriceBand > 0 && hasMid {
+		band := mid.Mul(types.NewDecimal(m.limits.PriceBand))
+		for _, price := range []types.Decimal{order.Price, order.StopPrice} {
+			if !price.IsZero() && price.Sub(mid).Abs().GreaterThan(band) {
+				return fmt.Sprintf("price %.2f is more than %.2f%% from mid %.2f",
+					price, m.limits.PriceBand*100, mid), CheckPriceBand
+			}
+		}
+	}
+
+	if m.limits.MaxNotional.IsPositive() {
+		// Market orders are valued at mid
+		price := order.Price
+		if price.IsZero() {
+			price = order.StopPrice
+		}
+		if price.IsZero() {
+			price = mid
+		}
+		if notional := order.Quantity.Mul(price); notional.GreaterThan(m.limits.MaxNotional) {
+			return fmt.Sprintf("notional %.2f exceeds limit %.2f", notional, m.limits.MaxNotional), CheckNotional
+		}
+	}
+
+	if m.limits.MaxPosition.IsPositive() {
+		if exposure := m.exposure(order, previous); exposure.GreaterThan(m.limits.MaxPosition) {
+			return fmt.Sprintf("position would reach %.4f, limit %.4f", exposure, m.limits.MaxPosition), CheckPosition
+		}
+	}
+
+	if m.limits.MaxOrderRate > 0 {
+		cutoff := now.Add(-time.Second)
+		for len(m.passed) > 0 && !m.passed[0].After(cutoff) {
+			m.passed = m.passed[1:]
+		}
+		if len(m.passed) >= m.limits.MaxOrderRate {
+			return fmt.Sprintf("%d orders in the last second, limit %d", len(m.passed), m.limits.MaxOrderRate), CheckOrderRate
+		}
+	}
+	return "", ""
+}
+
+// amended returns the order a replace would leave working, where zero
+// fields of the replace keep the current values
+func amended(previous *workingOrder, replace types.TradeSignal) types.TradeSignal {
+	order := previous.signal
+	order.Quantity = previous.remaining
+	if !replace.Price.IsZero() {
+		order.Price = replace.Price
+	}
+	if !replace.Quantity.IsZero() {
+		order.Quantity = replace.Quantity
+	}
+	if !replace.StopPrice.IsZero() {
+		order.StopPrice = replace.StopPrice
+	}
+	return order
+}
+
+// exposure returns the absolute position of the order's symbol if the order
+// and every other working order on its side filled in full
+func (m *Manager) exposure(order types.TradeSignal, previous *workingOrder) types.Decimal {
+	total := order.Quantity.Add(m.workingQuantity(order.Symbol, order.Side, previous))
+	if order.Side == types.SideSell {
+		total = total.Neg()
+	}
+	return m.position(order.Symbol).Add(total).Abs()
+}
+
+// reduces reports whether an order, with the other working orders on its
+// side, can only bring the symbol's position towards flat
+func (m *Manager) reduces(order types.TradeSignal, previous *workingOrder) bool {
+	position := m.position(order.Symbol)
+	if position.IsZero() || (order.Side == types.SideBuy) == position.IsPositive() {
+		return false
+	}
+	total := order.Quantity.Add(m.workingQuantity(order.Symbol, order.Side, previous))
+	return total.LessThanOrEqual(position.Abs())
+}
+
+// workingQuantity returns the remaining quantity of the working orders on a
+// side of a symbol, leaving out the order being replaced
+func (m *Manager) workingQuantity(symbol string, side types.Side, exclude *workingOrder) types.Decimal {
+	var total types.Decimal
+	for id, order := range m.working {
+		if exclude != nil && id == exclude.signal.OrderID {
+			continue
+		}
+		if order.signal.Symbol == symbol && order.signal.Side == side {
+			// An order with replaces pending counts at the larger of its
+			// current and amended size
+			remaining := order.remaining
+			if len(order.pending) > 0 {
+				remaining = types.MaxDecimal(remaining, order.projected().remaining)
+			}
+			total = total.Add(remaining)
+		}
+	}
+	return total
+}
+
+// position returns the signed position held in a symbol
+func (m *Manager) position(symbol string) types.Decimal {
+	if position, exists := m.positions[symbol]; exists {
+		return position.Quantity
+	}
+	return types.Zero
+}
+
+// dailyLoss returns how much the session P&L has fallen since the start of
+// the UTC day of now, marking positions to mid. A new day resets the base
+// and the kill switch.
+func (m *Manager) dailyLoss(now time.Time) types.Decimal {
+	pnl := m.fees.Neg()
+	for symbol, position := range m.positions {
+		if ob, exists := m.books.Get(symbol); exists {
+			if mid, ok := ob.GetMidPrice(); ok {
+				position.Mark(mid)
+			}
+		}
+		pnl = pnl.Add(position.RealizedPnL).Add(position.UnrealizedPnL)
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	if day.After(m.day) {
+		if m.stats.KillSwitch {
+			log.Printf("Risk kill switch reset for %s", day.Format("2006-01-02"))
+			m.stats.KillSwitch = false
+		}
+		m.day = day
+		m.dayStart = pnl
+	}
+	return m.dayStart.Sub(pnl)
+}
+
+// now returns the feed clock of a symbol's book, or the wall clock when
+// the book has not been updated
+func (m *Manager) now(symbol string) time.Time {
+	if ob, exists := m.books.Get(symbol); exists {
+		if updated := ob.LastUpdated(); !updated.IsZero() {
+			return updated
+		}
+	}
+	return time.Now()
+}
+
+// reject logs and reports a request that failed a check
+func (m *Manager) reject(signal types.TradeSignal, reason string) {
+	reason = rejectPrefix + reason
+	log.Printf("Order rejected: %s", reason)
+	status := types.StatusRejected
+	if signal.Action == types.ActionReplace {
+		// The order the replace names keeps working
+		status = types.StatusRequestRejected
+	}
+	execution := types.Execution{
+		OrderID:   signal.OrderID,
+		Status:    status,
+		Reason:    reason,
+		Symbol:    signal.Symbol,
+		Side:      signal.Side,
+		Timestamp: m.now(signal.Symbol),
+	}
+	// The strategy tracks the order until it sees a terminal report, so
+	// wait for room rather than drop the reject
+	select {
+	case m.executions <- execution:
+	case <-m.stop:
+		log.Printf("Risk reject for order %s dropped at shutdown", signal.OrderID)
+	}
+}
@@ -0,0 +1,312 @@
+package risk
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// session is the feed time the test books start at
+var session = time.Date(2025, 8, 30, 10, 0, 0, 0, time.UTC)
+
+// setBook replaces the BTCUSD book with one level each side at a feed time
+func setBook(books *orderbook.Registry, bid, ask float64, at time.Time) {
+	books.GetOrCreate("BTCUSD").Update(types.OrderBookSnapshot{
+		Symbol:    "BTCUSD",
+		Timestamp: at,
+		Bids:      []types.OrderBookEntry{{Price: types.NewDecimal(bid), Quantity: types.NewDecimal(5)}},
+		Asks:      []types.OrderBookEntry{{Price: types.NewDecimal(ask), Quantity: types.NewDecimal(5)}},
+	})
+}
+
+// newManager creates a manager over a book with mid 100
+func newManager(limits Limits) (*Manager, *orderbook.Registry) {
+	books := orderbook.NewRegistry()
+	setBook(books, 99.5, 100.5, session)
+	return New(books, limits, nil, nil, nil), books
+}
+
+// order returns a new order; a zero price is a market order
+func order(id string, side types.Side, quantity, price float64) types.TradeSignal {
+	return types.TradeSignal{
+		OrderID:  id,
+		Action:   types.ActionNew,
+		Symbol:   "BTCUSD",
+		Side:     side,
+		Price:    types.NewDecimal(price),
+		Quantity: types.NewDecimal(quantity),
+	}
+}
+
+// fill returns a report filling quantity of an order
+func fill(id string, side types.Side, quantity, price float64, status types.OrderStatus) types.Execution {
+	return types.Execution{
+		OrderID:  id,
+		Status:   status,
+		Symbol:   "BTCUSD",
+		Side:     side,
+		Price:    types.NewDecimal(price),
+		Quantity: types.NewDecimal(quantity),
+	}
+}
+
+func TestOrderChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		signal types.TradeSignal
+		check  Check
+	}{
+		{"size within limit", Limits{MaxOrderSize: types.NewDecimal(2)}, order("S1", types.SideBuy, 2, 0), ""},
+		{"size over limit", Limits{MaxOrderSize: types.NewDecimal(2)}, order("S1", types.SideBuy, 3, 0), CheckOrderSize},
+		{"limit notional", Limits{MaxNotional: types.NewDecimal(500)}, order("S1", types.SideBuy, 6, 90), CheckNotional},
+		{"market notional at mid", Limits{MaxNotional: types.NewDecimal(500)}, order("S1", types.SideBuy, 5, 0), ""},
+		{"price inside band", Limits{PriceBand: 0.05}, order("S1", types.SideSell, 1, 105), ""},
+		{"fat-finger price", Limits{PriceBand: 0.05}, order("S1", types.SideSell, 1, 10), CheckPriceBand},
+		{"market order has no price", Limits{PriceBand: 0.05}, order("S1", types.SideSell, 1, 0), ""},
+		{"position over limit", Limits{MaxPosition: types.NewDecimal(1)}, order("S1", types.SideSell, 1.5, 0), CheckPosition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newManager(tt.limits)
+			reason, check := m.check(tt.signal)
+			assert.Equal(t, tt.check, check, reason)
+		})
+	}
+
+	stop := order("S1", types.SideSell, 1, 0)
+	stop.Type = types.OrderTypeStop
+	stop.StopPrice = types.NewDecimal(50)
+	m, _ := newManager(Limits{PriceBand: 0.05})
+	_, check := m.check(stop)
+	assert.Equal(t, CheckPriceBand, check, "stop prices are banded too")
+}
+
+func TestPositionCountsFillsAndWorkingOrders(t *testing.T) {
+	m, _ := newManager(Limits{MaxPosition: types.NewDecimal(2)})
+
+	_, check := m.check(order("S1", types.SideBuy, 1, 99))
+	require.Empty(t, check)
+	m.Apply(fill("S1", types.SideBuy, 0, 0, types.StatusAcked))
+
+	// 1 working plus 1.5 new would take the position to 2.5
+	_, check = m.check(order("S2", types.SideBuy, 1.5, 99))
+	assert.Equal(t, CheckPosition, check)
+
+	// Once S1 fills the position is 1 and selling 3 would go 2 short
+	m.Apply(fill("S1", types.SideBuy, 1, 99, types.StatusFilled))
+	_, check = m.check(order("S3", types.SideSell, 3, 0))
+	assert.Empty(t, check)
+	_, check = m.check(order("S4", types.SideSell, 0.5, 0))
+	assert.Equal(t, CheckPosition, check, "S3 is still working")
+
+	// Cancelling S3 frees its quantity
+	m.Apply(types.Execution{OrderID: "S3", Status: types.StatusCancelled, Symbol: "BTCUSD"})
+	_, check = m.check(order("S5", types.SideBuy, 1, 0))
+	assert.Empty(t, check)
+}
+
+func TestReplaceCheckedAsAmended(t *testing.T) {
+	m, _ := newManager(Limits{MaxOrderSize: types.NewDecimal(2), PriceBand: 0.05})
+
+	_, check := m.check(order("S1", types.SideBuy, 1, 99))
+	require.Empty(t, check)
+	m.Apply(fill("S1", types.SideBuy, 0, 0, types.StatusAcked))
+
+	// The replace keeps the quantity and moves the price out of the band
+	replace := types.TradeSignal{OrderID: "S1", Action: types.ActionReplace, Symbol: "BTCUSD", Price: types.NewDecimal(80)}
+	_, check = m.check(replace)
+	assert.Equal(t, CheckPriceBand, check)
+
+	replace = types.TradeSignal{OrderID: "S1", Action: types.ActionReplace, Symbol: "BTCUSD", Quantity: types.NewDecimal(3)}
+	_, check = m.check(replace)
+	assert.Equal(t, CheckOrderSize, check)
+
+	// A passed replace waits for the broker before it applies
+	replace.Quantity = types.NewDecimal(2)
+	_, check = m.check(replace)
+	require.Empty(t, check)
+	assert.Equal(t, types.NewDecimal(1), m.working["S1"].remaining)
+
+	// A broker reject leaves the order working on its old terms
+	m.Apply(types.Execution{OrderID: "S1", Status: types.StatusRequestRejected, Symbol: "BTCUSD", Reason: "price not on tick"})
+	require.Contains(t, m.working, "S1")
+	assert.Equal(t, types.NewDecimal(1), m.working["S1"].remaining)
+	assert.Empty(t, m.working["S1"].pending)
+
+	// An accepted replace takes the new terms, less what it filled
+	_, check = m.check(replace)
+	require.Empty(t, check)
+	m.Apply(types.Execution{OrderID: "S1", Status: types.StatusPartiallyFilled, Symbol: "BTCUSD", Side: types.SideBuy,
+		Price: types.NewDecimal(99), Quantity: types.NewDecimal(0.5), Replaced: true})
+	assert.Equal(t, types.NewDecimal(1.5), m.working["S1"].remaining)
+	assert.Empty(t, m.working["S1"].pending)
+
+	// The manager's own reject of a replace does not drop a pending one
+	_, check = m.check(types.TradeSignal{OrderID: "S1", Action: types.ActionReplace, Symbol: "BTCUSD", Price: types.NewDecimal(98)})
+	require.Empty(t, check)
+	m.Apply(types.Execution{OrderID: "S1", Status: types.StatusRequestRejected, Symbol: "BTCUSD", Reason: "risk: order size"})
+	assert.Len(t, m.working["S1"].pending, 1)
+
+	cancel := types.TradeSignal{OrderID: "S1", Action: types.ActionCancel, Symbol: "BTCUSD"}
+	_, check = m.check(cancel)
+	assert.Empty(t, check, "cancels always pass")
+}
+
+func TestOrderRateOnFeedClock(t *testing.T) {
+	m, books := newManager(Limits{MaxOrderRate: 2})
+
+	_, check := m.check(order("S1", types.SideBuy, 1, 0))
+	require.Empty(t, check)
+	setBook(books, 99.5, 100.5, session.Add(500*time.Millisecond))
+	_, check = m.check(order("S2", types.SideBuy, 1, 0))
+	require.Empty(t, check)
+
+	_, check = m.check(order("S3", types.SideBuy, 1, 0))
+	assert.Equal(t, CheckOrderRate, check)
+
+	// A second after S1 it leaves the window
+	setBook(books, 99.5, 100.5, session.Add(time.Second))
+	_, check = m.check(order("S4", types.SideBuy, 1, 0))
+	assert.Empty(t, check)
+	_, check = m.check(order("S5", types.SideBuy, 1, 0))
+	assert.Equal(t, CheckOrderRate, check, "S2 and S4 are within the second")
+
+	stats := m.Stats()
+	assert.Equal(t, 5, stats.Checked)
+	assert.Equal(t, 3, stats.Passed)
+	assert.Equal(t, 2, stats.Rejected[CheckOrderRate])
+}
+
+func TestDailyLossKillSwitch(t *testing.T) {
+	m, books := newManager(Limits{MaxDailyLoss: types.NewDecimal(15)})
+
+	_, check := m.check(order("S1", types.SideBuy, 2, 0))
+	require.Empty(t, check)
+	m.Apply(fill("S1", types.SideBuy, 2, 100, types.StatusFilled))
+
+	// Mid falls to 95: a loss of 10 is within the limit
+	setBook(books, 94.5, 95.5, session.Add(time.Minute))
+	_, check = m.check(order("S2", types.SideBuy, 1, 0))
+	require.Empty(t, check)
+	m.Apply(types.Execution{OrderID: "S2", Status: types.StatusCancelled, Symbol: "BTCUSD"})
+
+	// Mid falls to 92: a loss of 16 trips the switch
+	setBook(books, 91.5, 92.5, session.Add(2*time.Minute))
+	_, check = m.check(order("S3", types.SideBuy, 1, 0))
+	assert.Equal(t, CheckDailyLoss, check)
+	assert.True(t, m.Stats().KillSwitch)
+
+	// Reducing the position still passes, but not beyond flat
+	_, check = m.check(order("S4", types.SideSell, 3, 0))
+	assert.Equal(t, CheckDailyLoss, check)
+	_, check = m.check(order("S5", types.SideSell, 2, 0))
+	assert.Empty(t, check)
+
+	// The switch stays tripped after the mid recovers
+	setBook(books, 109.5, 110.5, session.Add(3*time.Minute))
+	_, check = m.check(order("S6", types.SideBuy, 1, 0))
+	assert.Equal(t, CheckDailyLoss, check)
+
+	// and resets at the start of the next UTC day
+	setBook(books, 109.5, 110.5, session.Add(14*time.Hour+time.Minute))
+	_, check = m.check(order("S7", types.SideBuy, 1, 0))
+	assert.Empty(t, check)
+	assert.False(t, m.Stats().KillSwitch)
+}
+
+func TestDailyLossResetsEachDay(t *testing.T) {
+	m, books := newManager(Limits{MaxDailyLoss: types.NewDecimal(15)})
+
+	m.Apply(fill("S1", types.SideBuy, 2, 100, types.StatusFilled))
+	_, check := m.check(order("S2", types.SideBuy, 0.1, 0))
+	require.Empty(t, check)
+	m.Apply(types.Execution{OrderID: "S2", Status: types.StatusCancelled, Symbol: "BTCUSD"})
+
+	// Down 10 at the end of the day and 10 more the next day
+	setBook(books, 94.5, 95.5, session.Add(13*time.Hour+59*time.Minute))
+	_, check = m.check(order("S3", types.SideBuy, 0.1, 0))
+	require.Empty(t, check)
+	m.Apply(types.Execution{OrderID: "S3", Status: types.StatusCancelled, Symbol: "BTCUSD"})
+
+	setBook(books, 84.5, 85.5, session.Add(14*time.Hour+time.Minute))
+	_, check = m.check(order("S4", types.SideBuy, 0.1, 0))
+	assert.Empty(t, check, "only the new day's loss counts")
+}
+
+func TestStartForwardsAndRejects(t *testing.T) {
+	books := orderbook.NewRegistry()
+	setBook(books, 99.5, 100.5, session)
+
+	signals := make(chan types.TradeSignal, 3)
+	orders := make(chan types.TradeSignal, 3)
+	executions := make(chan types.Execution, 3)
+	m := New(books, Limits{MaxOrderSize: types.NewDecimal(1)}, signals, orders, executions)
+
+	signals <- order("S1", types.SideBuy, 1, 0)
+	signals <- order("S2", types.SideBuy, 5, 0)
+	signals <- types.TradeSignal{OrderID: "S1", Action: types.ActionCancel, Symbol: "BTCUSD"}
+	close(signals)
+	m.Start()
+
+	var forwarded []string
+	for signal := range orders {
+		forwarded = append(forwarded, signal.OrderID)
+	}
+	assert.Equal(t, []string{"S1", "S1"}, forwarded)
+
+	require.Len(t, executions, 1)
+	reject := <-executions
+	assert.Equal(t, "S2", reject.OrderID)
+	assert.Equal(t, types.StatusRejected, reject.Status)
+	assert.Contains(t, reject.Reason, "risk: order size")
+
+	assert.Equal(t, "2 checked, 1 passed, 1 rejected (order size 1)", m.Stats().String())
+}
+
+func TestRejectWaitsForFullExecutionsChannel(t *testing.T) {
+	books := orderbook.NewRegistry()
+	setBook(books, 99.5, 100.5, session)
+
+	signals := make(chan types.TradeSignal, 1)
+	orders := make(chan types.TradeSignal, 1)
+	executions := make(chan types.Execution, 1)
+	m := New(books, Limits{MaxOrderSize: types.NewDecimal(1)}, signals, orders, executions)
+
+	// A broker report already fills the channel
+	executions <- fill("B1", types.SideBuy, 1, 100, types.StatusFilled)
+	signals <- order("S1", types.SideBuy, 5, 0)
+	close(signals)
+	finished := make(chan struct{})
+	go func() {
+		m.Start()
+		close(finished)
+	}()
+
+	assert.Equal(t, "B1", (<-executions).OrderID)
+	reject := <-executions
+	assert.Equal(t, "S1", reject.OrderID)
+	assert.True(t, reject.Status.IsTerminal())
+	<-finished
+}
+
+func TestStopReleasesBlockedReject(t *testing.T) {
+	books := orderbook.NewRegistry()
+	setBook(books, 99.5, 100.5, session)
+
+	signals := make(chan types.TradeSignal, 1)
+	orders := make(chan types.TradeSignal, 1)
+	m := New(books, Limits{MaxOrderSize: types.NewDecimal(1)}, signals, orders, make(chan types.Execution))
+
+	signals <- order("S1", types.SideBuy, 5, 0)
+	close(signals)
+	m.Stop()
+	m.Start()
+
+	_, open := <-orders
+	assert.False(t, open, "Start returns and closes the orders channel")
+}
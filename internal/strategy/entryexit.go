@@ -0,0 +1,129 @@
+package strategy
+
+import (
+	"log"
+	"time"
+	"trading-engine/internal/types"
+)
+
+func init() {
+	Register(DefaultName, func(config Config) Strategy { return NewEntryExit(config) })
+}
+
+// EntryExit buys once the book first has enough liquidity and exits the
+// position at market on take-profit, stop-loss or after the maximum holding
+// time. Positions are signed: a sell fill while flat opens a short, which is
+// exited with the same rules mirrored.
+type EntryExit struct {
+	positionExits
+	config  Config
+	symbol  string
+	entered bool
+	gate    LiquidityGate
+}
+
+// NewEntryExit creates the entry/exit strategy
+func NewEntryExit(config Config) *EntryExit {
+	return &EntryExit{
+		positionExits: newPositionExits("Entry/exit", config),
+		config:        config,
+		symbol:        config.Symbol,
+		gate:          newLiquidityGate(config),
+	}
+}
+
+// LiquidityGate returns the decisions of the pre-trade liquidity gate
+func (s *EntryExit) LiquidityGate() LiquidityGate {
+	return s.gate
+}
+
+// OnStart implements Strategy
+func (s *EntryExit) OnStart(ctx *Context) {
+	if s.symbol == "" {
+		log.Println("Entry/exit strategy waiting for the first book")
+		return
+	}
+	log.Printf("Entry/exit strategy waiting for %s book", s.symbol)
+}
+
+// OnBook enters on the first update of the strategy's book that passes the
+// liquidity gate and then exits the position as soon as the price it would
+// exit at, the bid for a long and the ask for a short, reaches the
+// take-profit or stop-loss price
+func (s *EntryExit) OnBook(ctx *Context, snapshot types.OrderBookSnapshot) {
+	if s.symbol == "" {
+		s.symbol = snapshot.Symbol
+		log.Printf("Entry/exit strategy trading %s from the first book", s.symbol)
+	}
+	if snapshot.Symbol != s.symbol {
+		return
+	}
+	if !s.entered {
+		if !s.gate.Check(snapshot, types.SideBuy) {
+			return
+		}
+		s.entered = true
+		s.enter(ctx)
+		return
+	}
+
+	touch, marked := s.markPosition(snapshot)
+	if !marked {
+		return
+	}
+	if reason, triggered := s.checkExit(s.position.Side(), s.position.EntryPrice, touch); triggered {
+		log.Printf("%s triggered: touch %.2f, entry %.2f", reason, touch, s.position.EntryPrice)
+		s.exitPosition(ctx, reason)
+	}
+}
+
+// OnExecution updates the position with reports that carry fills
+func (s *EntryExit) OnExecution(ctx *Context, execution types.Execution) {
+	s.applyFill(execution)
+}
+
+// OnTimer exits the position at market once MaxHoldTime has passed, as a
+// fallback when neither price trigger fires
+func (s *EntryExit) OnTimer(ctx *Context, now time.Time) {
+	s.checkMaxHold(ctx, now)
+}
+
+// OnStop implements Strategy
+func (s *EntryExit) OnStop(ctx *Context) {
+	s.logOpenPosition()
+}
+
+// enter sends the entry order: a market buy in auto-entry mode, otherwise a
+// limit buy at the configured entry price
+func (s *EntryExit) enter(ctx *Context) {
+	if s.config.EntryPrice == 0 {
+		// Auto-entry mode - use a market order
+		log.Println("Generating market buy signal (auto-entry)")
+		signal := types.TradeSignal{
+			Symbol:   s.symbol,
+			Side:     types.SideBuy,
+			Price:    types.Zero, // Market order
+			Quantity: types.NewDecimal(s.config.OrderSize),
+		}
+		if _, ok := ctx.Submit(signal); ok {
+			log.Println("Buy signal sent")
+		} else {
+			log.Println("Failed to send buy signal - channel full")
+		}
+		return
+	}
+
+	// Use specified entry price
+	log.Printf("Generating limit buy signal at %.2f", s.config.EntryPrice)
+	signal := types.TradeSignal{
+		Symbol:   s.symbol,
+		Side:     types.SideBuy,
+		Price:    types.NewDecimal(s.config.EntryPrice),
+		Quantity: types.NewDecimal(s.config.OrderSize),
+	}
+	if _, ok := ctx.Submit(signal); ok {
+		log.Println("Limit buy signal sent")
+	} else {
+		log.Println("Failed to send limit buy signal - channel full")
+	}
+}
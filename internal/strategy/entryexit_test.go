@@ -0,0 +1,209 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bidBook returns a book for a symbol with a single bid level
+func bidBook(symbol string, bid float64) types.OrderBookSnapshot {
+	return types.OrderBookSnapshot{
+		Symbol: symbol,
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(bid), Quantity: types.NewDecimal(1)}},
+	}
+}
+
+func TestCheckExit(t *testing.T) {
+	s := NewEntryExit(Config{TakeProfit: 0.05, StopLoss: 0.02})
+	entry := types.NewDecimal(50000)
+
+	tests := []struct {
+		bid       float64
+		reason    string
+		triggered bool
+	}{
+		{50000, "", false},
+		{52499.99, "", false},
+		{52500, "take-profit", true},
+		{53000, "take-profit", true},
+		{49000.01, "", false},
+		{49000, "stop-loss", true},
+		{48000, "stop-loss", true},
+	}
+	for _, tt := range tests {
+		reason, triggered := s.checkExit(types.SideBuy, entry, types.NewDecimal(tt.bid))
+		assert.Equal(t, tt.triggered, triggered, "bid %v", tt.bid)
+		assert.Equal(t, tt.reason, reason, "bid %v", tt.bid)
+	}
+
+	// Disabled rules never fire
+	off := NewEntryExit(Config{})
+	_, triggered := off.checkExit(types.SideBuy, entry, types.NewDecimal(1))
+	assert.False(t, triggered)
+
+	// The rules are mirrored for a short
+	reason, triggered := s.checkExit(types.SideSell, entry, types.NewDecimal(47500))
+	assert.True(t, triggered)
+	assert.Equal(t, "take-profit", reason)
+	reason, triggered = s.checkExit(types.SideSell, entry, types.NewDecimal(51000))
+	assert.True(t, triggered)
+	assert.Equal(t, "stop-loss", reason)
+	_, triggered = s.checkExit(types.SideSell, entry, types.NewDecimal(49000))
+	assert.False(t, triggered)
+}
+
+func TestEntryExitEntersOnFirstBook(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewEntryExit(Config{Symbol: "BTCUSD", EntryPrice: 49000, OrderSize: 2})
+
+	s.OnBook(ctx, bidBook("ETHUSD", 3000))
+	assert.Empty(t, signals, "other symbols are ignored")
+
+	s.OnBook(ctx, bidBook("BTCUSD", 48900))
+	s.OnBook(ctx, bidBook("BTCUSD", 48950))
+	require.Len(t, signals, 1)
+	entry := <-signals
+	assert.Equal(t, types.SideBuy, entry.Side)
+	assert.Equal(t, types.NewDecimal(49000), entry.Price)
+	assert.Equal(t, types.NewDecimal(2), entry.Quantity)
+}
+
+func TestEntryExitDefaultsSymbolFromFirstBook(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewEntryExit(Config{OrderSize: 3})
+
+	s.OnBook(ctx, bidBook("ETHUSD", 3000))
+	s.OnBook(ctx, bidBook("BTCUSD", 50000))
+	require.Len(t, signals, 1)
+	assert.Equal(t, "ETHUSD", (<-signals).Symbol)
+}
+
+func TestEntryExitSellsWhenStopCrossed(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewEntryExit(Config{TakeProfit: 0.05, StopLoss: 0.02, MaxHoldTime: time.Hour})
+	s.entered = true
+	s.position = &types.Position{Symbol: "BTCUSD", Quantity: types.NewDecimal(1.5), EntryPrice: types.NewDecimal(50000)}
+
+	s.OnBook(ctx, bidBook("BTCUSD", 49500))
+	s.OnBook(ctx, bidBook("BTCUSD", 48900)) // below 49000 stop
+	s.OnBook(ctx, bidBook("BTCUSD", 48800)) // exit already working, no second order
+
+	require.Len(t, signals, 1)
+	exit := <-signals
+	assert.Equal(t, types.SideSell, exit.Side)
+	assert.True(t, exit.Price.IsZero())
+	assert.Equal(t, types.NewDecimal(1.5), exit.Quantity)
+
+	position, open := s.Position()
+	require.True(t, open)
+	assert.Equal(t, types.NewDecimal(48800), position.CurrentPrice)
+	assert.Equal(t, types.NewDecimal(-1800), position.UnrealizedPnL)
+}
+
+func TestEntryExitMaxHold(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewEntryExit(Config{MaxHoldTime: time.Minute})
+	opened := time.Now()
+
+	s.OnExecution(ctx, types.Execution{OrderID: "S1", Status: types.StatusFilled, Symbol: "BTCUSD", Side: types.SideBuy,
+		Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1), Timestamp: opened})
+	s.OnTimer(ctx, opened.Add(59*time.Second))
+	assert.Empty(t, signals)
+
+	s.OnTimer(ctx, opened.Add(time.Minute))
+	require.Len(t, signals, 1)
+	assert.Equal(t, types.SideSell, (<-signals).Side)
+}
+
+func TestExitsShareOCOGroup(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewEntryExit(Config{MaxHoldTime: time.Hour})
+
+	s.OnExecution(ctx, types.Execution{OrderID: "S1", Status: types.StatusFilled, Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(50000), Quantity: types.NewDecimal(2)})
+	s.exitPosition(ctx, "stop-loss")
+	s.exitPosition(ctx, "time-based") // exit already working
+	require.Len(t, signals, 1)
+	exit := <-signals
+	assert.Equal(t, "exit-S1", exit.OCOGroup)
+
+	// The remainder of a partial exit is covered by a new group
+	partial := types.Execution{OrderID: exit.OrderID, Status: types.StatusCancelled, Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(0.5)}
+	ctx.trackReport(partial)
+	s.OnExecution(ctx, partial)
+	s.exitPosition(ctx, "time-based")
+	require.Len(t, signals, 1)
+	next := <-signals
+	assert.Equal(t, "exit-"+exit.OrderID, next.OCOGroup)
+	assert.Equal(t, types.NewDecimal(1.5), next.Quantity)
+}
+
+func TestEntryExitWaitsForLiquidity(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewEntryExit(Config{OrderSize: 1, LiquidityThresh: 2})
+
+	book := func(askQty float64) types.OrderBookSnapshot {
+		return types.OrderBookSnapshot{
+			Symbol: "BTCUSD",
+			Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(5)}},
+			Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(askQty)}},
+		}
+	}
+	s.OnBook(ctx, book(1))
+	assert.Empty(t, signals, "entry blocked by thin asks")
+
+	s.OnBook(ctx, book(2.5))
+	require.Len(t, signals, 1)
+
+	gate := s.LiquidityGate()
+	assert.True(t, gate.Passed)
+	assert.Equal(t, 2, gate.Checks)
+	assert.Equal(t, 1, gate.Blocked)
+	assert.Equal(t, types.NewDecimal(2.5), gate.Liquidity)
+}
+
+func TestEntryExitShortsWhenSellingFlat(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewEntryExit(Config{TakeProfit: 0.05, StopLoss: 0.02, MaxHoldTime: time.Hour})
+	s.entered = true
+
+	s.OnExecution(ctx, types.Execution{OrderID: "S1", Status: types.StatusFilled, Symbol: "BTCUSD", Side: types.SideSell,
+		Price: types.NewDecimal(50000), Quantity: types.NewDecimal(2)})
+	position, open := s.Position()
+	require.True(t, open)
+	assert.Equal(t, types.NewDecimal(-2), position.Quantity)
+
+	// A short is marked at the ask and stopped out as it rises
+	ask := types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(51000), Quantity: types.NewDecimal(5)}},
+	}
+	s.OnBook(ctx, ask)
+	require.Len(t, signals, 1)
+	exit := <-signals
+	assert.Equal(t, types.SideBuy, exit.Side)
+	assert.Equal(t, types.NewDecimal(2), exit.Quantity)
+	assert.Equal(t, "exit-S1", exit.OCOGroup)
+
+	position, _ = s.Position()
+	assert.Equal(t, types.NewDecimal(-2000), position.UnrealizedPnL)
+
+	// Buying more than the short flips to a long
+	s.OnExecution(ctx, types.Execution{OrderID: exit.OrderID, Status: types.StatusFilled, Symbol: "BTCUSD", Side: types.SideBuy,
+		Price: types.NewDecimal(51000), Quantity: types.NewDecimal(3)})
+	position, open = s.Position()
+	require.True(t, open)
+	assert.Equal(t, types.NewDecimal(1), position.Quantity)
+	assert.Equal(t, types.NewDecimal(51000), position.EntryPrice)
+	assert.Equal(t, types.NewDecimal(-2000), position.RealizedPnL)
+}
@@ -0,0 +1,73 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// defaultLiquidityBand is the band around mid used when Config.LiquidityBand is unset
+const defaultLiquidityBand = 0.01
+
+// LiquidityGate blocks entries until the book holds enough liquidity near
+// mid on the side an order would take from, and records its decisions
+type LiquidityGate struct {
+	Threshold float64       // minimum quantity within the band; zero disables the gate
+	Band      float64       // fraction of mid either side of it (0.01 = 1%)
+	Checks    int           // books evaluated
+	Blocked   int           // books on which an entry was blocked
+	Passed    bool          // an entry was allowed
+	Liquidity types.Decimal // liquidity seen at the last check
+}
+
+// Gated is implemented by strategies that check a LiquidityGate before
+// entering
+type Gated interface {
+	LiquidityGate() LiquidityGate
+}
+
+// newLiquidityGate creates a gate from the strategy configuration
+func newLiquidityGate(config Config) LiquidityGate {
+	band := config.LiquidityBand
+	if band <= 0 {
+		band = defaultLiquidityBand
+	}
+	return LiquidityGate{Threshold: config.LiquidityThresh, Band: band}
+}
+
+// Check reports whether an order on side may enter against the book. A buy
+// counts the ask liquidity within the band and a sell the bid liquidity.
+func (g *LiquidityGate) Check(snapshot types.OrderBookSnapshot, side types.Side) bool {
+	ob := orderbook.New()
+	ob.Update(snapshot)
+	bidLiquidity, askLiquidity := ob.GetLiquidity(0, g.Band)
+	liquidity := askLiquidity
+	if side == types.SideSell {
+		liquidity = bidLiquidity
+	}
+
+	g.Checks++
+	g.Liquidity = liquidity
+	if liquidity.LessThan(types.NewDecimal(g.Threshold)) {
+		g.Blocked++
+		log.Printf("Liquidity gate blocked %s entry on %s: %.4f within %.2f%% of mid, need %.4f",
+			side, snapshot.Symbol, liquidity, g.Band*100, g.Threshold)
+		return false
+	}
+
+	g.Passed = true
+	log.Printf("Liquidity gate passed %s entry on %s: %.4f within %.2f%% of mid (threshold %.4f)",
+		side, snapshot.Symbol, liquidity, g.Band*100, g.Threshold)
+	return true
+}
+
+// String summarises the gate decisions
+func (g LiquidityGate) String() string {
+	decision := "blocked"
+	if g.Passed {
+		decision = "passed"
+	}
+	return fmt.Sprintf("%s (liquidity %.4f within %.2f%% of mid, threshold %.4f, blocked %d of %d checks)",
+		decision, g.Liquidity, g.Band*100, g.Threshold, g.Blocked, g.Checks)
+}
@@ -0,0 +1,41 @@
+package strategy
+
+import (
+	"testing"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiquidityGate(t *testing.T) {
+	book := types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids: []types.OrderBookEntry{
+			{Price: types.NewDecimal(49900), Quantity: types.NewDecimal(4)},
+			{Price: types.NewDecimal(40000), Quantity: types.NewDecimal(100)}, // outside the band
+		},
+		Asks: []types.OrderBookEntry{
+			{Price: types.NewDecimal(50100), Quantity: types.NewDecimal(1)},
+			{Price: types.NewDecimal(50400), Quantity: types.NewDecimal(2)},
+			{Price: types.NewDecimal(60000), Quantity: types.NewDecimal(100)}, // outside the band
+		},
+	}
+
+	gate := newLiquidityGate(Config{LiquidityThresh: 3.5})
+	assert.Equal(t, 0.01, gate.Band)
+	assert.False(t, gate.Check(book, types.SideBuy), "3 on the ask within 1%")
+	assert.Equal(t, types.NewDecimal(3), gate.Liquidity)
+	assert.True(t, gate.Check(book, types.SideSell), "4 on the bid within 1%")
+	assert.Equal(t, 2, gate.Checks)
+	assert.Equal(t, 1, gate.Blocked)
+	assert.True(t, gate.Passed)
+
+	// A narrower band counts less of the book
+	narrow := newLiquidityGate(Config{LiquidityThresh: 1, LiquidityBand: 0.002})
+	assert.True(t, narrow.Check(book, types.SideBuy))
+	assert.Equal(t, types.NewDecimal(1), narrow.Liquidity)
+
+	// A zero threshold disables the gate
+	off := newLiquidityGate(Config{})
+	assert.True(t, off.Check(types.OrderBookSnapshot{Symbol: "BTCUSD"}, types.SideBuy))
+}
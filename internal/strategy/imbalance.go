@@ -0,0 +1,188 @@
+package strategy
+
+import (
+	"log"
+	"time"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// Defaults for imbalance settings left at zero in Config
+const (
+	defaultImbalanceEntry   = 0.05
+	defaultImbalanceConfirm = 2
+	defaultDepthFraction    = 0.5
+)
+
+func init() {
+	Register("imbalance", func(config Config) Strategy { return NewImbalance(config) })
+}
+
+// Imbalance trades order book imbalance. It goes long when the book is
+// bid-heavy and short when it is ask-heavy for ImbalanceConfirm consecutive
+// updates, sizes the entry by the depth it would take from, and exits when
+// the imbalance turns against the position or on the stop/target rules.
+type Imbalance struct {
+	positionExits
+	config    Config
+	symbol    string
+	gate      LiquidityGate
+	streak    int        // consecutive updates beyond the entry threshold
+	direction types.Side // side the streak points to
+}
+
+// NewImbalance creates the imbalance strategy
+func NewImbalance(config Config) *Imbalance {
+	if config.ImbalanceEntry <= 0 {
+		config.ImbalanceEntry = defaultImbalanceEntry
+	}
+	if config.ImbalanceConfirm <= 0 {
+		config.ImbalanceConfirm = defaultImbalanceConfirm
+	}
+	if config.DepthFraction <= 0 {
+		config.DepthFraction = defaultDepthFraction
+	}
+	return &Imbalance{
+		positionExits: newPositionExits("Imbalance", config),
+		config:        config,
+		symbol:        config.Symbol,
+		gate:          newLiquidityGate(config),
+	}
+}
+
+// LiquidityGate returns the decisions of the pre-trade liquidity gate
+func (s *Imbalance) LiquidityGate() LiquidityGate {
+	return s.gate
+}
+
+// OnStart implements Strategy
+func (s *Imbalance) OnStart(ctx *Context) {
+	log.Printf("Imbalance strategy: entry %.4f for %d updates, exit %.4f, depth fraction %.2f",
+		s.config.ImbalanceEntry, s.config.ImbalanceConfirm, s.config.ImbalanceExit, s.config.DepthFraction)
+}
+
+// OnBook evaluates the imbalance of every update of the strategy's book
+func (s *Imbalance) OnBook(ctx *Context, snapshot types.OrderBookSnapshot) {
+	if s.symbol == "" {
+		s.symbol = snapshot.Symbol
+		log.Printf("Imbalance strategy trading %s from the first book", s.symbol)
+	}
+	if snapshot.Symbol != s.symbol {
+		return
+	}
+
+	ob := orderbook.New()
+	ob.Update(snapshot)
+	imbalance := ob.GetOrderBookImbalance()
+
+	if s.position != nil {
+		s.checkImbalanceExit(ctx, snapshot, imbalance)
+		return
+	}
+	s.checkEntry(ctx, ob, snapshot, imbalance)
+}
+
+// OnExecution updates the position with reports that carry fills
+func (s *Imbalance) OnExecution(ctx *Context, execution types.Execution) {
+	s.applyFill(execution)
+}
+
+// OnTimer exits the position at market once MaxHoldTime has passed
+func (s *Imbalance) OnTimer(ctx *Context, now time.Time) {
+	s.checkMaxHold(ctx, now)
+}
+
+// OnStop implements Strategy
+func (s *Imbalance) OnStop(ctx *Context) {
+	s.logOpenPosition()
+}
+
+// checkEntry extends the imbalance streak and enters once it has lasted
+// ImbalanceConfirm updates
+func (s *Imbalance) checkEntry(ctx *Context, ob *orderbook.OrderBook, snapshot types.OrderBookSnapshot, imbalance float64) {
+	var direction types.Side
+	switch {
+	case imbalance >= s.config.ImbalanceEntry:
+		direction = types.SideBuy
+	case imbalance <= -s.config.ImbalanceEntry:
+		direction = types.SideSell
+	default:
+		s.streak = 0
+		return
+	}
+
+	if direction == s.direction {
+		s.streak++
+	} else {
+		s.direction = direction
+		s.streak = 1
+	}
+	if s.streak < s.config.ImbalanceConfirm || len(ctx.OpenOrders()) > 0 {
+		return
+	}
+	if !s.gate.Check(snapshot, direction) {
+		return
+	}
+
+	quantity := s.entrySize(ctx, ob, direction)
+	if !quantity.IsPositive() {
+		log.Printf("Imbalance %.4f signals %s but there is no depth to take", imbalance, direction)
+		return
+	}
+
+	log.Printf("Imbalance %.4f for %d updates: entering %s %.4f", imbalance, s.streak, direction, quantity)
+	s.streak = 0
+	signal := types.TradeSignal{
+		Symbol:   s.symbol,
+		Side:     direction,
+		Price:    types.Zero, // Market order
+		Quantity: quantity,
+	}
+	if _, ok := ctx.Submit(signal); !ok {
+		log.Println("Failed to send imbalance entry - channel full")
+	}
+}
+
+// entrySize returns DepthFraction of the depth within LiquidityBand on the
+// side an order takes from, capped at OrderSize and rounded down to the lot
+// size
+func (s *Imbalance) entrySize(ctx *Context, ob *orderbook.OrderBook, side types.Side) types.Decimal {
+	bidDepth, askDepth := ob.GetLiquidity(0, s.gate.Band)
+	depth := askDepth
+	if side == types.SideSell {
+		depth = bidDepth
+	}
+
+	quantity := depth.Mul(types.NewDecimal(s.config.DepthFraction))
+	if s.config.OrderSize > 0 {
+		quantity = types.MinDecimal(quantity, types.NewDecimal(s.config.OrderSize))
+	}
+	if instrument, ok := ctx.Instrument(s.symbol); ok {
+		quantity = quantity.Truncate(instrument.LotSize)
+	}
+	return quantity
+}
+
+// checkImbalanceExit closes the position when the imbalance turns against
+// it or the price reaches the stop or target
+func (s *Imbalance) checkImbalanceExit(ctx *Context, snapshot types.OrderBookSnapshot, imbalance float64) {
+	side := s.position.Side()
+	touch, marked := s.markPosition(snapshot)
+
+	reason := ""
+	switch {
+	case side == types.SideBuy && imbalance <= -s.config.ImbalanceExit,
+		side == types.SideSell && imbalance >= s.config.ImbalanceExit:
+		reason = "imbalance reversal"
+	case marked:
+		var triggered bool
+		if reason, triggered = s.checkExit(side, s.position.EntryPrice, touch); !triggered {
+			return
+		}
+	default:
+		return
+	}
+
+	log.Printf("Imbalance %s exit: imbalance %.4f, touch %.2f, entry %.2f", reason, imbalance, touch, s.position.EntryPrice)
+	s.exitPosition(ctx, reason)
+}
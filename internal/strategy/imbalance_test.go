@@ -0,0 +1,131 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// imbalancedBook returns a BTCUSD book around 50000 with the given depth on
+// each side
+func imbalancedBook(bidQty, askQty float64) types.OrderBookSnapshot {
+	return types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(bidQty)}},
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(50050), Quantity: types.NewDecimal(askQty)}},
+	}
+}
+
+func TestImbalanceEntersAfterConfirmation(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	ctx.setInstrument(types.Instrument{Symbol: "BTCUSD", LotSize: types.NewDecimal(0.01)})
+	s := NewImbalance(Config{OrderSize: 10, ImbalanceEntry: 0.2, ImbalanceConfirm: 3, DepthFraction: 0.5})
+
+	s.OnBook(ctx, imbalancedBook(3, 1)) // +0.5
+	s.OnBook(ctx, imbalancedBook(3, 1))
+	s.OnBook(ctx, imbalancedBook(1, 1)) // neutral resets the streak
+	s.OnBook(ctx, imbalancedBook(3, 1.05))
+	s.OnBook(ctx, imbalancedBook(3, 1.05))
+	assert.Empty(t, signals)
+
+	s.OnBook(ctx, imbalancedBook(3, 1.05))
+	require.Len(t, signals, 1)
+	entry := <-signals
+	assert.Equal(t, types.SideBuy, entry.Side)
+	assert.True(t, entry.Price.IsZero())
+	// Half the ask depth, rounded down to the lot size
+	assert.Equal(t, types.NewDecimal(0.52), entry.Quantity)
+}
+
+func TestImbalanceShortsAskHeavyBook(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewImbalance(Config{OrderSize: 0.5, ImbalanceEntry: 0.2, ImbalanceConfirm: 1})
+
+	s.OnBook(ctx, imbalancedBook(4, 10))
+	require.Len(t, signals, 1)
+	entry := <-signals
+	assert.Equal(t, types.SideSell, entry.Side)
+	assert.Equal(t, types.NewDecimal(0.5), entry.Quantity, "capped at OrderSize")
+}
+
+func TestImbalanceExits(t *testing.T) {
+	tests := []struct {
+		name  string
+		side  types.Side
+		book  types.OrderBookSnapshot
+		exits bool
+	}{
+		{"long holds while bid-heavy", types.SideBuy, imbalancedBook(3, 1), false},
+		{"long exits on reversal", types.SideBuy, imbalancedBook(1, 3), true},
+		{"short exits on reversal", types.SideSell, imbalancedBook(3, 1), true},
+		{"long exits on stop", types.SideBuy, types.OrderBookSnapshot{
+			Symbol: "BTCUSD",
+			Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(48000), Quantity: types.NewDecimal(3)}},
+			Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(48100), Quantity: types.NewDecimal(1)}},
+		}, true},
+		{"short exits on target", types.SideSell, types.OrderBookSnapshot{
+			Symbol: "BTCUSD",
+			Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(47000), Quantity: types.NewDecimal(1)}},
+			Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(47100), Quantity: types.NewDecimal(3)}},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signals := make(chan types.TradeSignal, 10)
+			ctx := newContext(signals)
+			s := NewImbalance(Config{Symbol: "BTCUSD", StopLoss: 0.02, TakeProfit: 0.05, ImbalanceExit: 0.1})
+			s.OnExecution(ctx, types.Execution{OrderID: "S1", Status: types.StatusFilled, Symbol: "BTCUSD",
+				Side: tt.side, Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1)})
+
+			s.OnBook(ctx, tt.book)
+			if !tt.exits {
+				assert.Empty(t, signals)
+				return
+			}
+			require.Len(t, signals, 1)
+			exit := <-signals
+			assert.NotEqual(t, tt.side, exit.Side)
+			assert.Equal(t, "exit-S1", exit.OCOGroup)
+			assert.Equal(t, types.NewDecimal(1), exit.Quantity)
+		})
+	}
+}
+
+func TestImbalanceShortPnL(t *testing.T) {
+	ctx := newContext(make(chan types.TradeSignal, 10))
+	s := NewImbalance(Config{Symbol: "BTCUSD"})
+
+	s.OnExecution(ctx, types.Execution{OrderID: "S1", Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50000), Quantity: types.NewDecimal(2)})
+	s.OnBook(ctx, imbalancedBook(1, 3)) // still ask-heavy, no exit
+	position, open := s.Position()
+	require.True(t, open)
+	assert.Equal(t, types.SideSell, position.Side())
+	assert.Equal(t, types.NewDecimal(-100), position.UnrealizedPnL, "short marked at the 50050 ask")
+
+	s.OnExecution(ctx, types.Execution{OrderID: "S2", Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49900), Quantity: types.NewDecimal(2)})
+	_, open = s.Position()
+	assert.False(t, open)
+}
+
+func TestImbalanceMaxHold(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+	s := NewImbalance(Config{Symbol: "BTCUSD", MaxHoldTime: time.Minute})
+	opened := time.Now()
+
+	s.OnExecution(ctx, types.Execution{OrderID: "S1", Status: types.StatusFilled, Symbol: "BTCUSD", Side: types.SideSell,
+		Price: types.NewDecimal(50000), Quantity: types.NewDecimal(1), Timestamp: opened})
+	s.OnTimer(ctx, opened.Add(59*time.Second))
+	assert.Empty(t, signals)
+
+	s.OnTimer(ctx, opened.Add(time.Minute))
+	require.Len(t, signals, 1)
+	exit := <-signals
+	assert.Equal(t, types.SideBuy, exit.Side)
+	assert.Equal(t, "exit-S1", exit.OCOGroup)
+}
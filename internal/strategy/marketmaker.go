@@ -0,0 +1,225 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"trading-engine/internal/orderbook"
+	"trading-engine/internal/types"
+)
+
+// Defaults for market-making settings left at zero in Config
+const (
+	defaultQuoteHalfSpread = 0.0005
+	defaultInventoryQuotes = 5 // MaxInventory in multiples of the quote size
+)
+
+func init() {
+	Register("market-maker", func(config Config) Strategy { return NewMarketMaker(config) })
+}
+
+// PnLAttribution splits a market maker's P&L into the edge earned against
+// mid on each fill, the gain or loss from mid moving while inventory is
+// held and the fees paid. Total is the mark-to-market P&L at the last mid
+// after fees.
+type PnLAttribution struct {
+	SpreadCapture types.Decimal
+	InventoryPnL  types.Decimal
+	Fees          types.Decimal // net of maker rebates
+	Total         types.Decimal
+	Inventory     types.Decimal // signed: positive long, negative short
+	Fills         int
+}
+
+// String summarises the attribution
+func (a PnLAttribution) String() string {
+	return fmt.Sprintf("total %.2f = spread capture %.2f + inventory %.2f - fees %.2f (%d fills, inventory %.4f)",
+		a.Total, a.SpreadCapture, a.InventoryPnL, a.Fees, a.Fills, a.Inventory)
+}
+
+// Attributed is implemented by strategies that attribute their P&L
+type Attributed interface {
+	PnLAttribution() PnLAttribution
+}
+
+// MarketMaker quotes both sides around mid. Quotes are skewed against the
+// current inventory so that fills bring it back towards flat, and the side
+// that would take inventory beyond MaxInventory is not quoted.
+type MarketMaker struct {
+	config       Config
+	symbol       string
+	quoteSize    types.Decimal
+	maxInventory types.Decimal
+	bidID        string
+	askID        string
+
+	inventory     types.Decimal // signed position in the traded symbol
+	cash          types.Decimal // proceeds of sells less the cost of buys
+	mid           types.Decimal // last mid seen
+	spreadCapture types.Decimal
+	fees          types.Decimal
+	fills         int
+}
+
+// NewMarketMaker creates the market-making strategy
+func NewMarketMaker(config Config) *MarketMaker {
+	if config.QuoteHalfSpread <= 0 {
+		config.QuoteHalfSpread = defaultQuoteHalfSpread
+	}
+	if config.QuoteSize <= 0 {
+		config.QuoteSize = config.OrderSize
+	}
+	if config.MaxInventory <= 0 {
+		config.MaxInventory = config.QuoteSize * defaultInventoryQuotes
+	}
+	return &MarketMaker{
+		config:       config,
+		symbol:       config.Symbol,
+		quoteSize:    types.NewDecimal(config.QuoteSize),
+		maxInventory: types.NewDecimal(config.MaxInventory),
+	}
+}
+
+// PnLAttribution returns the P&L split at the last mid
+func (s *MarketMaker) PnLAttribution() PnLAttribution {
+	total := s.cash.Add(s.inventory.Mul(s.mid)).Sub(s.fees)
+	return PnLAttribution{
+		SpreadCapture: s.spreadCapture,
+		InventoryPnL:  total.Sub(s.spreadCapture).Add(s.fees),
+		Fees:          s.fees,
+		Total:         total,
+		Inventory:     s.inventory,
+		Fills:         s.fills,
+	}
+}
+
+// OnStart implements Strategy
+func (s *MarketMaker) OnStart(ctx *Context) {
+	log.Printf("Market maker: %.4f per side, half-spread %.4f%%, max inventory %.4f, skew %.4f",
+		s.quoteSize, s.config.QuoteHalfSpread*100, s.maxInventory, s.config.InventorySkew)
+}
+
+// OnBook requotes both sides around the new mid
+func (s *MarketMaker) OnBook(ctx *Context, snapshot types.OrderBookSnapshot) {
+	if s.symbol == "" {
+		s.symbol = snapshot.Symbol
+		log.Printf("Market maker quoting %s from the first book", s.symbol)
+	}
+	if snapshot.Symbol != s.symbol {
+		return
+	}
+
+	ob := orderbook.New()
+	ob.Update(snapshot)
+	mid, exists := ob.GetMidPrice()
+	if !exists {
+		return
+	}
+	s.mid = mid
+
+	bid, ask := s.quotePrices(ctx, mid)
+	s.bidID = s.requote(ctx, s.bidID, types.SideBuy, bid, s.quoteQuantity(ctx, types.SideBuy))
+	s.askID = s.requote(ctx, s.askID, types.SideSell, ask, s.quoteQuantity(ctx, types.SideSell))
+}
+
+// OnExecution updates inventory, cash, spread capture and fees with fills
+func (s *MarketMaker) OnExecution(ctx *Context, execution types.Execution) {
+	if !execution.Quantity.IsPositive() {
+		return
+	}
+
+	notional := execution.Price.Mul(execution.Quantity)
+	edge := s.mid.Sub(execution.Price).Mul(execution.Quantity)
+	if execution.Side == types.SideBuy {
+		s.inventory = s.inventory.Add(execution.Quantity)
+		s.cash = s.cash.Sub(notional)
+	} else {
+		s.inventory = s.inventory.Sub(execution.Quantity)
+		s.cash = s.cash.Add(notional)
+		edge = edge.Neg()
+	}
+	s.spreadCapture = s.spreadCapture.Add(edge)
+	s.fees = s.fees.Add(execution.Fee)
+	s.fills++
+
+	log.Printf("Market maker %s %.4f @ %.2f (mid %.2f, edge %.2f), inventory %.4f",
+		execution.Side, execution.Quantity, execution.Price, s.mid, edge, s.inventory)
+}
+
+// OnTimer implements Strategy
+func (s *MarketMaker) OnTimer(ctx *Context, now time.Time) {}
+
+// OnStop pulls both quotes and logs the P&L attribution
+func (s *MarketMaker) OnStop(ctx *Context) {
+	for _, id := range []string{s.bidID, s.askID} {
+		if id != "" {
+			ctx.Cancel(id)
+		}
+	}
+	log.Printf("Market maker P&L: %v", s.PnLAttribution())
+}
+
+// quotePrices returns the bid and ask around mid, shifted against the
+// inventory by up to InventorySkew of mid at MaxInventory and rounded away
+// from mid to the tick size
+func (s *MarketMaker) quotePrices(ctx *Context, mid types.Decimal) (types.Decimal, types.Decimal) {
+	skew := s.inventory.Div(s.maxInventory).Mul(types.NewDecimal(s.config.InventorySkew))
+	center := mid.Sub(mid.Mul(skew))
+	bid := center.Mul(types.NewDecimal(1 - s.config.QuoteHalfSpread))
+	ask := center.Mul(types.NewDecimal(1 + s.config.QuoteHalfSpread))
+
+	if instrument, ok := ctx.Instrument(s.symbol); ok && instrument.TickSize.IsPositive() {
+		bid = bid.Truncate(instrument.TickSize)
+		if rounded := ask.Truncate(instrument.TickSize); !rounded.Equal(ask) {
+			ask = rounded.Add(instrument.TickSize)
+		}
+	}
+	return bid, ask
+}
+
+// quoteQuantity returns the size to quote on a side, reduced so that a full
+// fill cannot take inventory beyond MaxInventory
+func (s *MarketMaker) quoteQuantity(ctx *Context, side types.Side) types.Decimal {
+	room := s.maxInventory.Sub(s.inventory)
+	if side == types.SideSell {
+		room = s.maxInventory.Add(s.inventory)
+	}
+	quantity := types.MinDecimal(s.quoteSize, room)
+	if instrument, ok := ctx.Instrument(s.symbol); ok {
+		quantity = quantity.Truncate(instrument.LotSize)
+	}
+	return quantity
+}
+
+// requote keeps a working quote that already has the wanted price and size
+// and otherwise cancels it and posts a new one. A quote whose cancel cannot
+// be sent stays the side's quote until a later update. It returns the ID of
+// the quote now working on the side, or "" when the side is not quoted.
+func (s *MarketMaker) requote(ctx *Context, id string, side types.Side, price, quantity types.Decimal) string {
+	if order, exists := ctx.Order(id); exists && !order.Status.IsTerminal() {
+		remaining := order.Signal.Quantity.Sub(order.Filled)
+		if order.Signal.Price.Equal(price) && remaining.Equal(quantity) {
+			return id
+		}
+		if !ctx.Cancel(id) {
+			return id
+		}
+	}
+	if !quantity.IsPositive() {
+		return ""
+	}
+
+	newID, ok := ctx.Submit(types.TradeSignal{
+		Symbol:   s.symbol,
+		Side:     side,
+		Type:     types.OrderTypeLimit,
+		Price:    price,
+		Quantity: quantity,
+		PostOnly: true,
+	})
+	if !ok {
+		log.Printf("Failed to send %s quote - channel full", side)
+		return ""
+	}
+	return newID
+}
@@ -0,0 +1,148 @@
+package strategy
+
+import (
+	"testing"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// midBook returns a BTCUSD book with the given mid and a 100 spread
+func midBook(mid float64) types.OrderBookSnapshot {
+	return types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(mid - 50), Quantity: types.NewDecimal(5)}},
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(mid + 50), Quantity: types.NewDecimal(5)}},
+	}
+}
+
+// drain returns the signals sent so far
+func drain(signals chan types.TradeSignal) []types.TradeSignal {
+	var sent []types.TradeSignal
+	for len(signals) > 0 {
+		sent = append(sent, <-signals)
+	}
+	return sent
+}
+
+func newTestMarketMaker(config Config) (*MarketMaker, *Context, chan types.TradeSignal) {
+	signals := make(chan types.TradeSignal, 20)
+	ctx := newContext(signals)
+	ctx.setInstrument(types.Instrument{Symbol: "BTCUSD", TickSize: types.NewDecimal(0.01), LotSize: types.NewDecimal(0.0001)})
+	return NewMarketMaker(config), ctx, signals
+}
+
+func TestMarketMakerQuotesAroundMid(t *testing.T) {
+	s, ctx, signals := newTestMarketMaker(Config{QuoteSize: 1, QuoteHalfSpread: 0.0001, MaxInventory: 5})
+
+	s.OnBook(ctx, midBook(50000))
+	quotes := drain(signals)
+	require.Len(t, quotes, 2)
+	assert.Equal(t, types.SideBuy, quotes[0].Side)
+	assert.Equal(t, types.NewDecimal(49995), quotes[0].Price)
+	assert.Equal(t, types.SideSell, quotes[1].Side)
+	assert.Equal(t, types.NewDecimal(50005), quotes[1].Price)
+	for _, quote := range quotes {
+		assert.Equal(t, types.OrderTypeLimit, quote.Type)
+		assert.True(t, quote.PostOnly)
+		assert.Equal(t, types.NewDecimal(1), quote.Quantity)
+	}
+
+	// Unchanged quotes keep working
+	for _, quote := range quotes {
+		ctx.trackReport(types.Execution{OrderID: quote.OrderID, Status: types.StatusAcked, Leaves: quote.Quantity})
+	}
+	s.OnBook(ctx, midBook(50000))
+	assert.Empty(t, drain(signals))
+
+	// A new mid cancels and requotes both sides
+	s.OnBook(ctx, midBook(50100))
+	requotes := drain(signals)
+	require.Len(t, requotes, 4)
+	assert.Equal(t, types.ActionCancel, requotes[0].Action)
+	assert.Equal(t, quotes[0].OrderID, requotes[0].OrderID)
+	assert.Equal(t, types.NewDecimal(50094.99), requotes[1].Price)
+	assert.Equal(t, types.ActionCancel, requotes[2].Action)
+	assert.Equal(t, types.NewDecimal(50105.01), requotes[3].Price)
+}
+
+func TestMarketMakerKeepsQuoteWhenCancelFails(t *testing.T) {
+	signals := make(chan types.TradeSignal, 2)
+	ctx := newContext(signals)
+	s := NewMarketMaker(Config{QuoteSize: 1, QuoteHalfSpread: 0.0001, MaxInventory: 5})
+
+	s.OnBook(ctx, midBook(50000))
+	bidID, askID := s.bidID, s.askID
+	for _, id := range []string{bidID, askID} {
+		ctx.trackReport(types.Execution{OrderID: id, Status: types.StatusAcked, Leaves: types.NewDecimal(1)})
+	}
+
+	// The channel is still full, so neither cancel goes out
+	s.OnBook(ctx, midBook(50100))
+	assert.Equal(t, bidID, s.bidID)
+	assert.Equal(t, askID, s.askID)
+	assert.Len(t, signals, 2)
+
+	// Once there is room the stale quotes are replaced
+	drain(signals)
+	s.OnBook(ctx, midBook(50100))
+	requotes := drain(signals)
+	require.Len(t, requotes, 2)
+	assert.Equal(t, types.ActionCancel, requotes[0].Action)
+	assert.Equal(t, bidID, requotes[0].OrderID)
+	assert.NotEqual(t, bidID, s.bidID)
+}
+
+func TestMarketMakerSkewsAndCapsInventory(t *testing.T) {
+	s, ctx, signals := newTestMarketMaker(Config{QuoteSize: 1, QuoteHalfSpread: 0.001, MaxInventory: 3, InventorySkew: 0.01})
+
+	s.OnBook(ctx, midBook(50000))
+	drain(signals)
+	s.OnExecution(ctx, types.Execution{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49950), Quantity: types.NewDecimal(1.5)})
+
+	// Long 1.5 of 3: quotes shift down by 0.5% of mid
+	bid, ask := s.quotePrices(ctx, types.NewDecimal(50000))
+	assert.Equal(t, types.NewDecimal(49700.25), bid)
+	assert.Equal(t, types.NewDecimal(49799.75), ask)
+
+	// Only 1.5 more may be bought, and at most 1 is quoted
+	assert.Equal(t, types.NewDecimal(1), s.quoteQuantity(ctx, types.SideBuy))
+	s.OnExecution(ctx, types.Execution{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49950), Quantity: types.NewDecimal(1)})
+	assert.Equal(t, types.NewDecimal(0.5), s.quoteQuantity(ctx, types.SideBuy))
+	s.OnExecution(ctx, types.Execution{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49950), Quantity: types.NewDecimal(0.5)})
+
+	// At the cap the bid is pulled
+	s.OnBook(ctx, midBook(50000))
+	sent := drain(signals)
+	for _, signal := range sent {
+		if signal.Action == types.ActionNew {
+			assert.Equal(t, types.SideSell, signal.Side)
+		}
+	}
+	assert.Empty(t, s.bidID)
+}
+
+func TestMarketMakerAttribution(t *testing.T) {
+	s, ctx, _ := newTestMarketMaker(Config{QuoteSize: 1})
+
+	s.OnBook(ctx, midBook(50000))
+	s.OnExecution(ctx, types.Execution{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49950), Quantity: types.NewDecimal(1)})
+	s.OnBook(ctx, midBook(50100))
+	s.OnExecution(ctx, types.Execution{Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50150), Quantity: types.NewDecimal(1)})
+
+	attribution := s.PnLAttribution()
+	assert.Equal(t, types.NewDecimal(100), attribution.SpreadCapture, "50 below mid on the buy, 50 above on the sell")
+	assert.Equal(t, types.NewDecimal(100), attribution.InventoryPnL, "mid rose 100 while long 1")
+	assert.Equal(t, types.NewDecimal(200), attribution.Total)
+	assert.True(t, attribution.Inventory.IsZero())
+	assert.Equal(t, 2, attribution.Fills)
+
+	// Open inventory is marked at the last mid
+	s.OnExecution(ctx, types.Execution{Symbol: "BTCUSD", Side: types.SideSell, Price: types.NewDecimal(50150), Quantity: types.NewDecimal(1)})
+	s.OnBook(ctx, midBook(50300))
+	attribution = s.PnLAttribution()
+	assert.Equal(t, types.NewDecimal(150), attribution.SpreadCapture)
+	assert.Equal(t, types.NewDecimal(-100), attribution.InventoryPnL)
+	assert.Equal(t, types.NewDecimal(50), attribution.Total)
+}
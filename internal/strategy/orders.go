@@ -0,0 +1,300 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"trading-engine/internal/indicators"
+	"trading-engine/internal/types"
+)
+
+// Order tracks an order the strategy has sent and the latest report for it.
+// The exits the broker attaches to a bracket order are tracked as orders of
+// their own, with Parent set to the bracket order's ID.
+type Order struct {
+	Signal types.TradeSignal
+	Status types.OrderStatus
+	Filled types.Decimal
+	Reason string
+	Parent string // bracket parent order ID for broker-created exits
+
+	replacing *types.TradeSignal // replace sent and not yet answered
+}
+
+// Context sends a strategy's orders to the broker and tracks them by client
+// order ID using the broker's execution reports. It also keeps indicators
+// over the book updates of every symbol.
+type Context struct {
+	signals         chan<- types.TradeSignal
+	indicators      map[string]*indicators.Book
+	indicatorPeriod int
+
+	mu          sync.Mutex
+	orders      map[string]*Order // outstanding and finished orders by client order ID
+	nextID      int
+	instruments map[string]types.Instrument
+}
+
+// newContext creates a context that sends orders on signals
+func newContext(signals chan<- types.TradeSignal) *Context {
+	return &Context{
+		signals:         signals,
+		indicators:      make(map[string]*indicators.Book),
+		indicatorPeriod: defaultIndicatorPeriod,
+		orders:          make(map[string]*Order),
+		instruments:     make(map[string]types.Instrument),
+	}
+}
+
+// Indicators returns the indicators over a symbol's book updates. They are
+// updated before each OnBook call and must only be read from callbacks.
+func (c *Context) Indicators(symbol string) (*indicators.Book, bool) {
+	book, exists := c.indicators[symbol]
+	return book, exists
+}
+
+// updateIndicators feeds a book update to the symbol's indicators
+func (c *Context) updateIndicators(snapshot types.OrderBookSnapshot) {
+	book, exists := c.indicators[snapshot.Symbol]
+	if !exists {
+		book = indicators.NewBook(c.indicatorPeriod)
+		c.indicators[snapshot.Symbol] = book
+	}
+	book.Update(snapshot)
+}
+
+// setInstrument registers tick and lot size metadata for a symbol
+func (c *Context) setInstrument(instrument types.Instrument) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.instruments[instrument.Symbol] = instrument
+}
+
+// Instrument returns the tick and lot size metadata for a symbol
+func (c *Context) Instrument(symbol string) (types.Instrument, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	instrument, exists := c.instruments[symbol]
+	return instrument, exists
+}
+
+// Submit assigns a client order ID to a new order, starts tracking it and
+// sends it to the broker without blocking. It returns the order ID, or false
+// if the signals channel is full, in which case the order is dropped.
+func (c *Context) Submit(signal types.TradeSignal) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	signal.OrderID = fmt.Sprintf("S%d", c.nextID)
+	signal.Action = types.ActionNew
+	if signal.Timestamp.IsZero() {
+		signal.Timestamp = time.Now()
+	}
+
+	select {
+	case c.signals <- signal:
+		c.orders[signal.OrderID] = &Order{Signal: signal, Status: types.StatusNew}
+		return signal.OrderID, true
+	default:
+		return "", false
+	}
+}
+
+// Cancel requests cancellation of a working order
+func (c *Context) Cancel(id string) bool {
+	order, exists := c.Order(id)
+	if !exists || order.Status.IsTerminal() {
+		return false
+	}
+
+	select {
+	case c.signals <- types.TradeSignal{OrderID: id, Action: types.ActionCancel, Symbol: order.Signal.Symbol, Timestamp: time.Now()}:
+		return true
+	default:
+		log.Printf("Failed to send cancel for order %s - channel full", id)
+		return false
+	}
+}
+
+// Replace requests new terms for a working order: a new limit price and/or
+// a new remaining quantity. A zero price or quantity keeps the current one.
+// The tracked order takes the new terms once the broker accepts them, and
+// only one replace per order may be outstanding at a time.
+func (c *Context) Replace(id string, price, quantity types.Decimal) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	order, exists := c.orders[id]
+	if !exists || order.Status.IsTerminal() || order.replacing != nil {
+		return false
+	}
+
+	signal := types.TradeSignal{
+		OrderID:   id,
+		Action:    types.ActionReplace,
+		Symbol:    order.Signal.Symbol,
+		Side:      order.Signal.Side,
+		Price:     price,
+		Quantity:  quantity,
+		Timestamp: time.Now(),
+	}
+	select {
+	case c.signals <- signal:
+		order.replacing = &signal
+		return true
+	default:
+		log.Printf("Failed to send replace for order %s - channel full", id)
+		return false
+	}
+}
+
+// Order returns the tracked state of an order by client order ID
+func (c *Context) Order(id string) (Order, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	order, exists := c.orders[id]
+	if !exists {
+		return Order{}, false
+	}
+	return *order, true
+}
+
+// OpenOrders returns the orders that have not reached a terminal state
+func (c *Context) OpenOrders() []Order {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var open []Order
+	for _, order := range c.orders {
+		if !order.Status.IsTerminal() {
+			open = append(open, *order)
+		}
+	}
+	return open
+}
+
+// HasOpenOrder reports whether an order on a side is still working
+func (c *Context) HasOpenOrder(side types.Side) bool {
+	for _, order := range c.OpenOrders() {
+		if order.Signal.Side == side {
+			return true
+		}
+	}
+	return false
+}
+
+// BracketExits returns the exits the broker has attached to a bracket order
+func (c *Context) BracketExits(parentID string) []Order {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stopID, targetID := types.BracketExitIDs(parentID)
+	var exits []Order
+	for _, id := range []string{stopID, targetID} {
+		if order, exists := c.orders[id]; exists {
+			exits = append(exits, *order)
+		}
+	}
+	return exits
+}
+
+// trackReport applies an execution report to the tracked order. The first
+// report of a bracket exit starts tracking it. Reports for other unknown
+// orders or orders already in a terminal state are ignored, and a refused
+// cancel or replace leaves the order as it was.
+func (c *Context) trackReport(execution types.Execution) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	order, exists := c.orders[execution.OrderID]
+	if !exists {
+		order, exists = c.trackBracketExit(execution)
+	}
+	if !exists {
+		log.Printf("Report for unknown order %s ignored", execution.OrderID)
+		return
+	}
+	if order.Status.IsTerminal() {
+		return
+	}
+	if execution.Status == types.StatusRequestRejected {
+		order.replacing = nil
+		log.Printf("Request for order %s rejected: %s", execution.OrderID, execution.Reason)
+		return
+	}
+	if execution.Replaced && order.replacing != nil {
+		c.applyReplace(order)
+	}
+
+	order.Status = execution.Status
+	order.Filled = order.Filled.Add(execution.Quantity)
+	order.Reason = execution.Reason
+	if order.Parent != "" && !execution.Status.IsTerminal() {
+		// The broker resizes exits as the parent and OCO siblings fill
+		order.Signal.Quantity = order.Filled.Add(execution.Leaves)
+	}
+
+	switch execution.Status {
+	case types.StatusRejected:
+		log.Printf("Order %s rejected: %s", execution.OrderID, execution.Reason)
+	case types.StatusCancelled, types.StatusExpired:
+		log.Printf("Order %s %s with %.4f filled: %s", execution.OrderID,
+			execution.Status, order.Filled, execution.Reason)
+	default:
+		log.Printf("Order %s %s: %.4f filled", execution.OrderID, execution.Status, order.Filled)
+	}
+}
+
+// applyReplace gives a tracked order the terms of the replace the broker
+// accepted. The caller holds the lock.
+func (c *Context) applyReplace(order *Order) {
+	replace := order.replacing
+	order.replacing = nil
+	if replace.Price.IsPositive() {
+		order.Signal.Price = replace.Price
+	}
+	if replace.Quantity.IsPositive() {
+		order.Signal.Quantity = order.Filled.Add(replace.Quantity)
+	}
+}
+
+// trackBracketExit starts tracking an exit the broker attached to a tracked
+// bracket order, from the exit's first report. The caller holds the lock.
+func (c *Context) trackBracketExit(execution types.Execution) (*Order, bool) {
+	parentID, isExit := types.BracketParentID(execution.OrderID)
+	if !isExit {
+		return nil, false
+	}
+	parent, exists := c.orders[parentID]
+	if !exists {
+		return nil, false
+	}
+
+	stopID, _ := types.BracketExitIDs(parentID)
+	signal := types.TradeSignal{
+		OrderID:   execution.OrderID,
+		Action:    types.ActionNew,
+		Symbol:    parent.Signal.Symbol,
+		Side:      execution.Side,
+		Quantity:  execution.Quantity.Add(execution.Leaves).Add(execution.Cancelled),
+		OCOGroup:  types.BracketGroup(parentID),
+		Timestamp: execution.Timestamp,
+	}
+	if execution.OrderID == stopID {
+		signal.Type = types.OrderTypeStop
+		signal.StopPrice = parent.Signal.StopLossPrice
+	} else {
+		signal.Type = types.OrderTypeLimit
+		signal.Price = parent.Signal.TakeProfitPrice
+	}
+
+	order := &Order{Signal: signal, Status: types.StatusNew, Parent: parentID}
+	c.orders[execution.OrderID] = order
+	log.Printf("Tracking bracket exit %s of order %s", execution.OrderID, parentID)
+	return order, true
+}
@@ -0,0 +1,190 @@
+package strategy
+
+import (
+	"log"
+	"time"
+	"trading-engine/internal/types"
+)
+
+// positionExits tracks a strategy's signed position from its fills and
+// exits it at market on the TakeProfit, StopLoss and MaxHoldTime rules of
+// Config. Strategies embed it and add their own entries and exit signals.
+type positionExits struct {
+	name       string // strategy name that log lines start with
+	takeProfit float64
+	stopLoss   float64
+	maxHold    time.Duration
+
+	position  *types.Position
+	exitGroup string // OCO group shared by the exits of the current position
+}
+
+// newPositionExits creates the position tracking of the named strategy
+func newPositionExits(name string, config Config) positionExits {
+	return positionExits{
+		name:       name,
+		takeProfit: config.TakeProfit,
+		stopLoss:   config.StopLoss,
+		maxHold:    config.MaxHoldTime,
+	}
+}
+
+// Position returns a copy of the open position
+func (p *positionExits) Position() (types.Position, bool) {
+	if p.position == nil {
+		return types.Position{}, false
+	}
+	return *p.position, true
+}
+
+// applyFill updates the position with the fills in an execution report.
+// Acks, rejects and cancels do not change the position.
+func (p *positionExits) applyFill(execution types.Execution) {
+	if !execution.Quantity.IsPositive() {
+		return
+	}
+	if p.position == nil {
+		p.position = &types.Position{Symbol: execution.Symbol}
+	}
+	previous := p.position.Quantity
+	pnl := p.position.Apply(execution.Side, execution.Quantity, execution.Price, execution.Timestamp)
+
+	switch {
+	case p.position.IsFlat():
+		log.Printf("%s position closed: %.4f @ %.2f, PnL %.2f (held for %v)", p.name, execution.Quantity,
+			execution.Price, pnl, execution.Timestamp.Sub(p.position.EntryTime))
+		p.position = nil
+
+	case previous.IsZero() || previous.Sign() != p.position.Quantity.Sign():
+		// Opened from flat, or flipped through flat to the other side
+		if !previous.IsZero() {
+			log.Printf("%s position flipped: PnL %.2f on the closed %s", p.name, pnl, p.position.Side().Opposite())
+		}
+		p.exitGroup = "exit-" + execution.OrderID
+		log.Printf("%s %s position opened: %.4f @ %.2f", p.name, p.position.Side(),
+			p.position.Quantity.Abs(), p.position.EntryPrice)
+		if target, ok := p.takeProfitPrice(p.position.Side(), p.position.EntryPrice); ok {
+			log.Printf("Take-profit armed at %.2f", target)
+		}
+		if stop, ok := p.stopLossPrice(p.position.Side(), p.position.EntryPrice); ok {
+			log.Printf("Stop-loss armed at %.2f", stop)
+		}
+		if p.maxHold > 0 {
+			log.Printf("Max-hold exit scheduled in %v", p.maxHold)
+		}
+
+	case execution.Side == p.position.Side():
+		// Further fills of the entry order average into the position
+		log.Printf("%s position increased: %.4f @ %.2f", p.name, p.position.Quantity.Abs(), p.position.EntryPrice)
+
+	default:
+		// A partial exit leaves the rest of the position open, and the next
+		// exit for the remainder starts a new OCO group
+		p.exitGroup = "exit-" + execution.OrderID
+		log.Printf("%s position reduced: %.4f @ %.2f, PnL %.2f, %.4f remaining", p.name, execution.Quantity,
+			execution.Price, pnl, p.position.Quantity.Abs())
+	}
+}
+
+// markPosition marks the open position at the price it would exit at, the
+// best bid for a long and the best ask for a short, and returns that price
+func (p *positionExits) markPosition(snapshot types.OrderBookSnapshot) (types.Decimal, bool) {
+	if p.position == nil {
+		return types.Zero, false
+	}
+	levels := snapshot.Bids
+	if p.position.Side() == types.SideSell {
+		levels = snapshot.Asks
+	}
+	if len(levels) == 0 {
+		return types.Zero, false
+	}
+	touch := levels[0].Price
+	p.position.Mark(touch)
+	return touch, true
+}
+
+// checkExit reports whether a position on side entered at entryPrice should
+// be closed at the given touch price, and which exit rule fired
+func (p *positionExits) checkExit(side types.Side, entryPrice, touch types.Decimal) (string, bool) {
+	// A long profits as the price rises and a short as it falls
+	favourable, adverse := touch.GreaterThanOrEqual, touch.LessThanOrEqual
+	if side == types.SideSell {
+		favourable, adverse = adverse, favourable
+	}
+	if target, ok := p.takeProfitPrice(side, entryPrice); ok && favourable(target) {
+		return "take-profit", true
+	}
+	if stop, ok := p.stopLossPrice(side, entryPrice); ok && adverse(stop) {
+		return "stop-loss", true
+	}
+	return "", false
+}
+
+// takeProfitPrice returns EntryPrice*(1+TakeProfit) for a long and
+// EntryPrice*(1-TakeProfit) for a short when take-profit is enabled
+func (p *positionExits) takeProfitPrice(side types.Side, entryPrice types.Decimal) (types.Decimal, bool) {
+	if p.takeProfit <= 0 {
+		return types.Zero, false
+	}
+	if side == types.SideSell {
+		return entryPrice.Mul(types.NewDecimal(1 - p.takeProfit)), true
+	}
+	return entryPrice.Mul(types.NewDecimal(1 + p.takeProfit)), true
+}
+
+// stopLossPrice returns EntryPrice*(1-StopLoss) for a long and
+// EntryPrice*(1+StopLoss) for a short when stop-loss is enabled
+func (p *positionExits) stopLossPrice(side types.Side, entryPrice types.Decimal) (types.Decimal, bool) {
+	if p.stopLoss <= 0 {
+		return types.Zero, false
+	}
+	if side == types.SideSell {
+		return entryPrice.Mul(types.NewDecimal(1 + p.stopLoss)), true
+	}
+	return entryPrice.Mul(types.NewDecimal(1 - p.stopLoss)), true
+}
+
+// checkMaxHold exits the position at market once it has been open for
+// MaxHoldTime. A zero MaxHoldTime disables the rule.
+func (p *positionExits) checkMaxHold(ctx *Context, now time.Time) {
+	if p.position == nil || p.maxHold <= 0 || now.Sub(p.position.EntryTime) < p.maxHold {
+		return
+	}
+	p.exitPosition(ctx, "time-based")
+}
+
+// exitPosition sends a market order against the whole open position, a
+// sell for a long and a buy for a short, unless an exit order is already
+// working. All exits of a position share an OCO group, so the broker
+// executes at most one of them even if several exit rules fire together.
+func (p *positionExits) exitPosition(ctx *Context, reason string) {
+	if p.position == nil {
+		return
+	}
+	side := p.position.Side().Opposite()
+	if ctx.HasOpenOrder(side) {
+		return
+	}
+
+	log.Printf("Generating %s exit signal", reason)
+	signal := types.TradeSignal{
+		Symbol:   p.position.Symbol,
+		Side:     side,
+		Price:    types.Zero, // Market order
+		Quantity: p.position.Quantity.Abs(),
+		OCOGroup: p.exitGroup,
+	}
+	if _, ok := ctx.Submit(signal); ok {
+		log.Printf("Exit signal sent (%s)", reason)
+	} else {
+		log.Printf("Failed to send %s exit signal - channel full", reason)
+	}
+}
+
+// logOpenPosition logs a position still open at the end of the session
+func (p *positionExits) logOpenPosition() {
+	if p.position != nil {
+		log.Printf("Session ended with %s %.4f %s still open", p.position.Side(), p.position.Quantity.Abs(), p.position.Symbol)
+	}
+}
@@ -0,0 +1,44 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the strategy used when none is selected
+const DefaultName = "entry-exit"
+
+// Factory creates a strategy from its configuration
+type Factory func(config Config) Strategy
+
+// registry maps strategy names to their factories
+var registry = make(map[string]Factory)
+
+// Register makes a strategy available by name. It panics if the name is
+// already taken, so it is meant to be called from init functions.
+func Register(name string, factory Factory) {
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("strategy: %s registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// NewByName creates the strategy registered under a name
+func NewByName(name string, config Config) (Strategy, error) {
+	factory, exists := registry[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown strategy %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(config), nil
+}
+
+// Names returns the registered strategy names in sorted order
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
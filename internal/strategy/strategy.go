@@ -6,192 +6,159 @@ import (
 	"trading-engine/internal/types"
 )
 
+// defaultTimerInterval is how often OnTimer is called unless overridden
+const defaultTimerInterval = 100 * time.Millisecond
+
+// defaultIndicatorPeriod is the look-back of the indicators unless overridden
+const defaultIndicatorPeriod = 20
+
 // Config holds strategy configuration
 type Config struct {
+	Symbol          string // symbol to trade; empty trades the symbol of the first book update
 	EntryPrice      float64
 	OrderSize       float64
 	StopLoss        float64
 	TakeProfit      float64
-	LiquidityThresh float64
-	MaxHoldTime     time.Duration
+	LiquidityThresh float64       // minimum quantity near mid before entering; zero disables the gate
+	LiquidityBand   float64       // fraction of mid the liquidity gate counts (0.01 = 1%)
+	MaxHoldTime     time.Duration // how long a position is held before exiting at market; zero disables it
+
+	// Imbalance strategy settings
+	ImbalanceEntry   float64 // |imbalance| that signals an entry (0.05 = 5% more depth on one side)
+	ImbalanceExit    float64 // imbalance against the position that signals an exit
+	ImbalanceConfirm int     // consecutive updates beyond ImbalanceEntry needed to enter
+	DepthFraction    float64 // share of the depth within LiquidityBand an entry may take
+
+	// Market-making settings
+	QuoteHalfSpread float64 // distance of each quote from the skewed mid as a fraction of it
+	QuoteSize       float64 // quantity quoted on each side; zero uses OrderSize
+	MaxInventory    float64 // absolute inventory the quotes may build up to
+	InventorySkew   float64 // shift of both quotes as a fraction of mid at MaxInventory
 }
 
-// Strategy implements a multi-factor trading strategy
-type Strategy struct {
-	config     Config
-	signals    chan<- types.TradeSignal
-	executions <-chan types.Execution
-	position   *types.Position
+// Strategy is implemented by trading strategies. The Runner calls every
+// method from a single goroutine, so implementations need no locking of
+// their own. Orders are sent and tracked through the Context.
+type Strategy interface {
+	// OnStart is called once before any other callback
+	OnStart(ctx *Context)
+	// OnBook is called with the book of a symbol after every update the
+	// engine applies, once the symbol's indicators include it
+	OnBook(ctx *Context, snapshot types.OrderBookSnapshot)
+	// OnExecution is called with every report from the broker, after the
+	// Context has applied it to the tracked order
+	OnExecution(ctx *Context, execution types.Execution)
+	// OnTimer is called periodically with the current time
+	OnTimer(ctx *Context, now time.Time)
+	// OnStop is called once when the session ends. No further callbacks
+	// follow it.
+	OnStop(ctx *Context)
 }
 
-// New creates a new strategy instance
-func New(config Config, signals chan<- types.TradeSignal, executions <-chan types.Execution) *Strategy {
-	return &Strategy{
-		config:     config,
-		signals:    signals,
-		executions: executions,
-	}
+// Runner drives a Strategy with the book updates published by the engine,
+// the broker's execution reports and a periodic timer
+type Runner struct {
+	strategy    Strategy
+	ctx         *Context
+	executions  <-chan types.Execution
+	bookUpdates <-chan types.OrderBookSnapshot
+	interval    time.Duration
+	stop        chan struct{}
+	done        chan struct{}
 }
 
-// Start begins the strategy execution
-func (s *Strategy) Start() {
-	log.Println("Strategy started")
-
-	// Listen for executions to track position
-	go s.handleExecutions()
-
-	// For this simulation, we'll generate a simple buy signal after a delay
-	// Wait for the feed to start publishing data
-	time.Sleep(500 * time.Millisecond)
-
-	entryPrice := s.config.EntryPrice
-	if entryPrice == 0 {
-		// Auto-entry mode - use a market order
-		log.Println("Generating market buy signal (auto-entry)")
-		signal := types.TradeSignal{
-			Symbol:    "BTCUSD", // Default symbol
-			Side:      types.SideBuy,
-			Price:     0, // Market order
-			Quantity:  s.config.OrderSize,
-			Timestamp: time.Now(),
-		}
-		select {
-		case s.signals <- signal:
-			log.Println("Buy signal sent")
-		default:
-			log.Println("Failed to send buy signal - channel full")
-		}
-	} else {
-		// Use specified entry price
-		log.Printf("Generating limit buy signal at %.2f", entryPrice)
-		signal := types.TradeSignal{
-			Symbol:    "BTCUSD",
-			Side:      types.SideBuy,
-			Price:     entryPrice,
-			Quantity:  s.config.OrderSize,
-			Timestamp: time.Now(),
-		}
-		select {
-		case s.signals <- signal:
-			log.Println("Limit buy signal sent")
-		default:
-			log.Println("Failed to send limit buy signal - channel full")
-		}
+// NewRunner creates a runner that sends the strategy's orders on signals and
+// feeds it the reports received on executions
+func NewRunner(strategy Strategy, signals chan<- types.TradeSignal, executions <-chan types.Execution) *Runner {
+	return &Runner{
+		strategy:   strategy,
+		ctx:        newContext(signals),
+		executions: executions,
+		interval:   defaultTimerInterval,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
 	}
 }
 
-// handleExecutions processes trade executions and manages positions
-func (s *Strategy) handleExecutions() {
-	log.Println("Strategy execution handler started")
-	for execution := range s.executions {
-		log.Printf("Strategy received execution: %+v", execution)
-
-		if s.position == nil && execution.Side == types.SideBuy {
-			// Opening position
-			s.position = &types.Position{
-				Symbol:     execution.Symbol,
-				Quantity:   execution.Quantity,
-				EntryPrice: execution.Price,
-				EntryTime:  execution.Timestamp,
-			}
+// SetBookUpdates connects the runner to the book updates published by the
+// engine, which are passed to OnBook
+func (r *Runner) SetBookUpdates(updates <-chan types.OrderBookSnapshot) {
+	r.bookUpdates = updates
+}
 
-			log.Printf("Position opened: %.2f @ %.2f", s.position.Quantity, s.position.EntryPrice)
+// SetInstrument registers tick and lot size metadata the strategy can use
+// to size its orders
+func (r *Runner) SetInstrument(instrument types.Instrument) {
+	r.ctx.setInstrument(instrument)
+}
 
-			// Schedule exit signals
-			go s.scheduleExitSignals()
+// SetIndicatorPeriod changes the number of book updates the indicators look
+// back over. Must be called before Start.
+func (r *Runner) SetIndicatorPeriod(period int) {
+	r.ctx.indicatorPeriod = period
+}
 
-		} else if s.position != nil && execution.Side == types.SideSell {
-			// Closing position
-			log.Printf("Position closed: %.2f @ %.2f", execution.Quantity, execution.Price)
+// SetTimerInterval changes how often OnTimer is called. Must be called
+// before Start.
+func (r *Runner) SetTimerInterval(interval time.Duration) {
+	r.interval = interval
+}
 
-			// Calculate PnL
-			pnl := (execution.Price - s.position.EntryPrice) * execution.Quantity
-			holdTime := execution.Timestamp.Sub(s.position.EntryTime)
+// Context returns the context the strategy sends its orders through
+func (r *Runner) Context() *Context {
+	return r.ctx
+}
 
-			log.Printf("Trade PnL: %.2f (held for %v)", pnl, holdTime)
+// Start runs the strategy until Stop is called
+func (r *Runner) Start() {
+	log.Println("Strategy started")
+	defer close(r.done)
 
-			s.position = nil
-		}
-	}
-	log.Println("Strategy execution handler finished")
-}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
 
-// scheduleExitSignals generates exit signals based on strategy rules
-func (s *Strategy) scheduleExitSignals() {
-	if s.position == nil {
-		return
-	}
+	r.strategy.OnStart(r.ctx)
 
-	log.Println("Scheduling exit signals...")
-
-	// Time-based exit
-	go func() {
-		time.Sleep(s.config.MaxHoldTime)
-		if s.position != nil {
-			log.Println("Generating time-based exit signal")
-			signal := types.TradeSignal{
-				Symbol:    s.position.Symbol,
-				Side:      types.SideSell,
-				Price:     0, // Market order
-				Quantity:  s.position.Quantity,
-				Timestamp: time.Now(),
+	updates := r.bookUpdates
+	executions := r.executions
+	for {
+		select {
+		case snapshot, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
 			}
-			select {
-			case s.signals <- signal:
-				log.Println("Exit signal sent")
-			default:
-				log.Println("Failed to send exit signal - channel full")
+			r.ctx.updateIndicators(snapshot)
+			r.strategy.OnBook(r.ctx, snapshot)
+
+		case execution, ok := <-executions:
+			if !ok {
+				executions = nil
+				continue
 			}
+			log.Printf("Strategy received execution: %+v", execution)
+			r.ctx.trackReport(execution)
+			r.strategy.OnExecution(r.ctx, execution)
+
+		case now := <-ticker.C:
+			r.strategy.OnTimer(r.ctx, now)
+
+		case <-r.stop:
+			r.strategy.OnStop(r.ctx)
+			log.Println("Strategy finished")
+			return
 		}
-	}()
-
-	// Take profit exit (trigger first for demo)
-	if s.config.TakeProfit > 0 {
-		go func() {
-			time.Sleep(2 * time.Second) // Trigger before time-based exit
-
-			if s.position != nil {
-				profitPrice := s.position.EntryPrice * (1 + s.config.TakeProfit)
-				log.Printf("Generating take-profit exit signal at %.2f", profitPrice)
-				signal := types.TradeSignal{
-					Symbol:    s.position.Symbol,
-					Side:      types.SideSell,
-					Price:     0, // Use market order for demo
-					Quantity:  s.position.Quantity,
-					Timestamp: time.Now(),
-				}
-				select {
-				case s.signals <- signal:
-					log.Println("Take-profit signal sent")
-				default:
-					log.Println("Failed to send take-profit signal - channel full")
-				}
-			}
-		}()
 	}
+}
 
-	// Stop loss exit
-	if s.config.StopLoss > 0 {
-		go func() {
-			time.Sleep(3 * time.Second) // Simulate some time for price movement
-
-			if s.position != nil {
-				stopPrice := s.position.EntryPrice * (1 - s.config.StopLoss)
-				log.Printf("Generating stop-loss exit signal at %.2f", stopPrice)
-				signal := types.TradeSignal{
-					Symbol:    s.position.Symbol,
-					Side:      types.SideSell,
-					Price:     0, // Use market order for demo
-					Quantity:  s.position.Quantity,
-					Timestamp: time.Now(),
-				}
-				select {
-				case s.signals <- signal:
-					log.Println("Stop-loss signal sent")
-				default:
-					log.Println("Failed to send stop-loss signal - channel full")
-				}
-			}
-		}()
-	}
+// Done is closed once the strategy has finished and reads no more reports
+func (r *Runner) Done() <-chan struct{} {
+	return r.done
+}
+
+// Stop ends the session for the strategy and waits for OnStop to return.
+// The signals channel may be closed once Stop returns.
+func (r *Runner) Stop() {
+	close(r.stop)
+	<-r.done
 }
@@ -0,0 +1,220 @@
+package strategy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"trading-engine/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextTracksOrdersByID(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+
+	id, ok := ctx.Submit(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(2)})
+	require.True(t, ok)
+	sent := <-signals
+	require.Equal(t, id, sent.OrderID)
+
+	order, exists := ctx.Order(sent.OrderID)
+	require.True(t, exists)
+	assert.Equal(t, types.StatusNew, order.Status)
+
+	ctx.trackReport(types.Execution{OrderID: sent.OrderID, Status: types.StatusAcked, Leaves: types.NewDecimal(2)})
+	ctx.trackReport(types.Execution{OrderID: sent.OrderID, Status: types.StatusPartiallyFilled, Quantity: types.NewDecimal(0.5)})
+	assert.Len(t, ctx.OpenOrders(), 1)
+	assert.True(t, ctx.HasOpenOrder(types.SideBuy))
+	assert.False(t, ctx.HasOpenOrder(types.SideSell))
+
+	// Cancel goes out over the signals channel
+	require.True(t, ctx.Cancel(sent.OrderID))
+	cancel := <-signals
+	assert.Equal(t, types.ActionCancel, cancel.Action)
+	assert.Equal(t, sent.OrderID, cancel.OrderID)
+
+	ctx.trackReport(types.Execution{OrderID: sent.OrderID, Status: types.StatusCancelled, Cancelled: types.NewDecimal(1.5)})
+	order, _ = ctx.Order(sent.OrderID)
+	assert.Equal(t, types.StatusCancelled, order.Status)
+	assert.Equal(t, types.NewDecimal(0.5), order.Filled)
+	assert.Empty(t, ctx.OpenOrders())
+
+	// Late reports for a finished order are ignored
+	ctx.trackReport(types.Execution{OrderID: sent.OrderID, Status: types.StatusRejected, Reason: "order S1 is not working"})
+	order, _ = ctx.Order(sent.OrderID)
+	assert.Equal(t, types.StatusCancelled, order.Status)
+	assert.False(t, ctx.Cancel(sent.OrderID))
+}
+
+func TestContextSeesRejects(t *testing.T) {
+	signals := make(chan types.TradeSignal, 1)
+	ctx := newContext(signals)
+
+	_, ok := ctx.Submit(types.TradeSignal{Symbol: "XYZ", Side: types.SideBuy, Quantity: types.NewDecimal(1)})
+	require.True(t, ok)
+	sent := <-signals
+
+	ctx.trackReport(types.Execution{OrderID: sent.OrderID, Status: types.StatusRejected, Reason: "no order book for symbol XYZ"})
+	order, _ := ctx.Order(sent.OrderID)
+	assert.Equal(t, types.StatusRejected, order.Status)
+	assert.Equal(t, "no order book for symbol XYZ", order.Reason)
+}
+
+func TestContextTracksBracketExits(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+
+	id, ok := ctx.Submit(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(2),
+		StopLossPrice: types.NewDecimal(49000), TakeProfitPrice: types.NewDecimal(51000)})
+	require.True(t, ok)
+	<-signals
+	stopID, targetID := types.BracketExitIDs(id)
+
+	ctx.trackReport(types.Execution{OrderID: id, Status: types.StatusFilled, Side: types.SideBuy, Quantity: types.NewDecimal(2)})
+	ctx.trackReport(types.Execution{OrderID: stopID, Status: types.StatusAcked, Side: types.SideSell, Leaves: types.NewDecimal(2)})
+	ctx.trackReport(types.Execution{OrderID: targetID, Status: types.StatusAcked, Side: types.SideSell, Leaves: types.NewDecimal(2)})
+
+	stop, exists := ctx.Order(stopID)
+	require.True(t, exists)
+	assert.Equal(t, id, stop.Parent)
+	assert.Equal(t, types.StatusAcked, stop.Status)
+	assert.Equal(t, types.OrderTypeStop, stop.Signal.Type)
+	assert.Equal(t, types.NewDecimal(49000), stop.Signal.StopPrice)
+	assert.Equal(t, types.SideSell, stop.Signal.Side)
+	assert.Equal(t, types.NewDecimal(2), stop.Signal.Quantity)
+	assert.Len(t, ctx.BracketExits(id), 2)
+	assert.True(t, ctx.HasOpenOrder(types.SideSell))
+
+	// A partial target fill resizes the stop to the remaining position
+	ctx.trackReport(types.Execution{OrderID: targetID, Status: types.StatusPartiallyFilled, Side: types.SideSell,
+		Price: types.NewDecimal(51000), Quantity: types.NewDecimal(0.5), Leaves: types.NewDecimal(1.5)})
+	ctx.trackReport(types.Execution{OrderID: stopID, Status: types.StatusAcked, Side: types.SideSell, Leaves: types.NewDecimal(1.5)})
+	stop, _ = ctx.Order(stopID)
+	assert.Equal(t, types.NewDecimal(1.5), stop.Signal.Quantity)
+	target, _ := ctx.Order(targetID)
+	assert.Equal(t, types.NewDecimal(0.5), target.Filled)
+	assert.Equal(t, types.NewDecimal(2), target.Signal.Quantity)
+
+	// The strategy can cancel its own exits
+	require.True(t, ctx.Cancel(stopID))
+	cancel := <-signals
+	assert.Equal(t, types.ActionCancel, cancel.Action)
+	assert.Equal(t, stopID, cancel.OrderID)
+	assert.Equal(t, "BTCUSD", cancel.Symbol)
+
+	// Exit IDs of orders the strategy did not send are still ignored
+	ctx.trackReport(types.Execution{OrderID: "S99-SL", Status: types.StatusAcked, Leaves: types.NewDecimal(1)})
+	_, exists = ctx.Order("S99-SL")
+	assert.False(t, exists)
+}
+
+// recorder is a strategy that records the callbacks it receives
+type recorder struct {
+	events chan string
+}
+
+func (r *recorder) OnStart(ctx *Context) { r.events <- "start" }
+func (r *recorder) OnBook(ctx *Context, snapshot types.OrderBookSnapshot) {
+	book, _ := ctx.Indicators(snapshot.Symbol)
+	r.events <- fmt.Sprintf("book %s mid %.0f", snapshot.Symbol, book.Mid)
+}
+func (r *recorder) OnExecution(ctx *Context, execution types.Execution) {
+	order, _ := ctx.Order(execution.OrderID)
+	r.events <- "execution " + string(order.Status)
+}
+func (r *recorder) OnTimer(ctx *Context, now time.Time) {
+	select {
+	case r.events <- "timer":
+	default:
+	}
+}
+func (r *recorder) OnStop(ctx *Context) { r.events <- "stop" }
+
+func TestRunnerCallsStrategy(t *testing.T) {
+	signals := make(chan types.TradeSignal, 1)
+	executions := make(chan types.Execution)
+	updates := make(chan types.OrderBookSnapshot)
+	rec := &recorder{events: make(chan string, 100)}
+
+	runner := NewRunner(rec, signals, executions)
+	runner.SetBookUpdates(updates)
+	runner.SetTimerInterval(time.Millisecond)
+	go runner.Start()
+
+	require.Equal(t, "start", <-rec.events)
+	// Indicators include the update before the strategy sees it
+	updates <- types.OrderBookSnapshot{
+		Symbol: "BTCUSD",
+		Bids:   []types.OrderBookEntry{{Price: types.NewDecimal(49950), Quantity: types.NewDecimal(1)}},
+		Asks:   []types.OrderBookEntry{{Price: types.NewDecimal(50050), Quantity: types.NewDecimal(1)}},
+	}
+
+	id, ok := runner.Context().Submit(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Quantity: types.NewDecimal(1)})
+	require.True(t, ok)
+	// The report is tracked before the strategy sees it
+	executions <- types.Execution{OrderID: id, Status: types.StatusFilled, Quantity: types.NewDecimal(1)}
+	runner.Stop()
+	close(rec.events)
+
+	var events []string
+	for event := range rec.events {
+		if event != "timer" {
+			events = append(events, event)
+		}
+	}
+	assert.Equal(t, []string{"book BTCUSD mid 50000", "execution FILLED", "stop"}, events)
+}
+
+func TestRegistry(t *testing.T) {
+	assert.Contains(t, Names(), DefaultName)
+
+	s, err := NewByName(DefaultName, Config{OrderSize: 1})
+	require.NoError(t, err)
+	assert.IsType(t, &EntryExit{}, s)
+
+	_, err = NewByName("nope", Config{})
+	assert.ErrorContains(t, err, `unknown strategy "nope"`)
+
+	assert.Panics(t, func() { Register(DefaultName, nil) })
+}
+
+func TestContextReplace(t *testing.T) {
+	signals := make(chan types.TradeSignal, 10)
+	ctx := newContext(signals)
+
+	id, ok := ctx.Submit(types.TradeSignal{Symbol: "BTCUSD", Side: types.SideBuy, Price: types.NewDecimal(49000), Quantity: types.NewDecimal(2)})
+	require.True(t, ok)
+	<-signals
+	ctx.trackReport(types.Execution{OrderID: id, Status: types.StatusPartiallyFilled, Quantity: types.NewDecimal(0.5), Leaves: types.NewDecimal(1.5)})
+
+	assert.False(t, ctx.Replace("S9", types.NewDecimal(49500), types.Zero), "unknown order")
+
+	// The replace goes out with the order's symbol and side
+	require.True(t, ctx.Replace(id, types.NewDecimal(49500), types.NewDecimal(1)))
+	replace := <-signals
+	assert.Equal(t, types.ActionReplace, replace.Action)
+	assert.Equal(t, id, replace.OrderID)
+	assert.Equal(t, "BTCUSD", replace.Symbol)
+	assert.Equal(t, types.SideBuy, replace.Side)
+	assert.False(t, ctx.Replace(id, types.NewDecimal(49600), types.Zero), "a replace is already pending")
+
+	// A refused replace leaves the order as it was
+	ctx.trackReport(types.Execution{OrderID: id, Status: types.StatusRequestRejected, Reason: "price not on tick"})
+	order, _ := ctx.Order(id)
+	assert.Equal(t, types.StatusPartiallyFilled, order.Status)
+	assert.Equal(t, types.NewDecimal(0.5), order.Filled)
+	assert.Equal(t, types.NewDecimal(49000), order.Signal.Price)
+	assert.Equal(t, types.NewDecimal(2), order.Signal.Quantity)
+
+	// An accepted replace takes the new terms
+	require.True(t, ctx.Replace(id, types.NewDecimal(49500), types.NewDecimal(1)))
+	<-signals
+	ctx.trackReport(types.Execution{OrderID: id, Status: types.StatusAcked, Leaves: types.NewDecimal(1), Replaced: true})
+	order, _ = ctx.Order(id)
+	assert.Equal(t, types.StatusAcked, order.Status)
+	assert.Equal(t, types.NewDecimal(49500), order.Signal.Price)
+	assert.Equal(t, types.NewDecimal(1.5), order.Signal.Quantity)
+	assert.Equal(t, types.NewDecimal(0.5), order.Filled)
+}
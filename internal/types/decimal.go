@@ -0,0 +1,356 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecimalPlaces is the number of fractional digits a Decimal can hold
+const DecimalPlaces = 8
+
+// decimalScale is the number of units in 1.0
+const decimalScale = 100000000
+
+// Decimal is a fixed-point number with DecimalPlaces fractional digits.
+// Prices and quantities use it so that sums and comparisons are exact.
+// Values are limited to about ±9.2e10; arithmetic that leaves that range
+// panics rather than wrapping around.
+type Decimal struct {
+	units int64
+}
+
+// Zero is the zero Decimal
+var Zero = Decimal{}
+
+// NewDecimal converts a float64 to the nearest Decimal. It panics if f is
+// not a number or out of range.
+func NewDecimal(f float64) Decimal {
+	units := math.Round(f * decimalScale)
+	// float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+	if math.IsNaN(units) || units >= math.MaxInt64 || units < math.MinInt64 {
+		panic(fmt.Sprintf("types: decimal overflow converting %g", f))
+	}
+	return Decimal{units: int64(units)}
+}
+
+// NewDecimalFromInt converts an integer to a Decimal. It panics if i is out
+// of range.
+func NewDecimalFromInt(i int64) Decimal {
+	if i > math.MaxInt64/decimalScale || i < math.MinInt64/decimalScale {
+		panic(fmt.Sprintf("types: decimal overflow converting %d", i))
+	}
+	return Decimal{units: i * decimalScale}
+}
+
+// ParseDecimal parses a decimal string such as "50000.25" or "-0.001".
+// Digits beyond DecimalPlaces are rejected rather than rounded.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	// Accept exponent notation via big.Rat, but require an exact result
+	if strings.ContainsAny(s, "eE") {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return Zero, fmt.Errorf("invalid decimal %q", s)
+		}
+		r.Mul(r, new(big.Rat).SetInt64(decimalScale))
+		if !r.IsInt() || !r.Num().IsInt64() {
+			return Zero, fmt.Errorf("decimal %q out of range or too precise", s)
+		}
+		return Decimal{units: r.Num().Int64()}, nil
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		intPart, fracPart = s[:dot], s[dot+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > DecimalPlaces {
+		return Zero, fmt.Errorf("decimal %q has more than %d fractional digits", s, DecimalPlaces)
+	}
+	fracPart += strings.Repeat("0", DecimalPlaces-len(fracPart))
+
+	if intPart == "" {
+		intPart = "0"
+	}
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil || strings.ContainsAny(intPart+fracPart, "+-") {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+	if negative {
+		units = -units
+	}
+	return Decimal{units: units}, nil
+}
+
+// MustDecimal parses a decimal string like ParseDecimal and panics if it is
+// invalid. It is meant for constants and tests.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Float64 returns the nearest float64 value
+func (d Decimal) Float64() float64 {
+	return float64(d.units) / decimalScale
+}
+
+// Add returns d + o. It panics if the sum is out of range.
+func (d Decimal) Add(o Decimal) Decimal {
+	sum := d.units + o.units
+	if (o.units > 0 && sum < d.units) || (o.units < 0 && sum > d.units) {
+		panic(fmt.Sprintf("types: decimal overflow in %s + %s", d, o))
+	}
+	return Decimal{units: sum}
+}
+
+// Sub returns d - o. It panics if the difference is out of range.
+func (d Decimal) Sub(o Decimal) Decimal {
+	difference := d.units - o.units
+	if (o.units > 0 && difference > d.units) || (o.units < 0 && difference < d.units) {
+		panic(fmt.Sprintf("types: decimal overflow in %s - %s", d, o))
+	}
+	return Decimal{units: difference}
+}
+
+// Neg returns -d. It panics for the one negative value without a positive
+// counterpart.
+func (d Decimal) Neg() Decimal {
+	if d.units == math.MinInt64 {
+		panic(fmt.Sprintf("types: decimal overflow negating %s", d))
+	}
+	return Decimal{units: -d.units}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	if d.units < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Mul returns d * o rounded half away from zero. It panics if the product
+// is out of range.
+func (d Decimal) Mul(o Decimal) Decimal {
+	product := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(o.units))
+	units, ok := roundQuo(product, big.NewInt(decimalScale))
+	if !ok {
+		panic(fmt.Sprintf("types: decimal overflow in %s * %s", d, o))
+	}
+	return Decimal{units: units}
+}
+
+// Div returns d / o rounded half away from zero. It panics if o is zero or
+// the quotient is out of range.
+func (d Decimal) Div(o Decimal) Decimal {
+	if o.units == 0 {
+		panic("types: decimal division by zero")
+	}
+	scaled := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(decimalScale))
+	units, ok := roundQuo(scaled, big.NewInt(o.units))
+	if !ok {
+		panic(fmt.Sprintf("types: decimal overflow in %s / %s", d, o))
+	}
+	return Decimal{units: units}
+}
+
+// roundQuo divides a by b rounding half away from zero. It returns false if
+// the quotient does not fit in an int64.
+func roundQuo(a, b *big.Int) (int64, bool) {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() != 0 {
+		twice := new(big.Int).Abs(r)
+		twice.Lsh(twice, 1)
+		if twice.Cmp(new(big.Int).Abs(b)) >= 0 {
+			if a.Sign()*b.Sign() < 0 {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+	}
+	if !q.IsInt64() {
+		return 0, false
+	}
+	return q.Int64(), true
+}
+
+// Cmp returns -1, 0 or +1 depending on whether d is less than, equal to or greater than o
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.units < o.units:
+		return -1
+	case d.units > o.units:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether d == o
+func (d Decimal) Equal(o Decimal) bool { return d.units == o.units }
+
+// LessThan reports whether d < o
+func (d Decimal) LessThan(o Decimal) bool { return d.units < o.units }
+
+// LessThanOrEqual reports whether d <= o
+func (d Decimal) LessThanOrEqual(o Decimal) bool { return d.units <= o.units }
+
+// GreaterThan reports whether d > o
+func (d Decimal) GreaterThan(o Decimal) bool { return d.units > o.units }
+
+// GreaterThanOrEqual reports whether d >= o
+func (d Decimal) GreaterThanOrEqual(o Decimal) bool { return d.units >= o.units }
+
+// IsZero reports whether d == 0
+func (d Decimal) IsZero() bool { return d.units == 0 }
+
+// IsPositive reports whether d > 0
+func (d Decimal) IsPositive() bool { return d.units > 0 }
+
+// IsNegative reports whether d < 0
+func (d Decimal) IsNegative() bool { return d.units < 0 }
+
+// Sign returns -1, 0 or +1 according to the sign of d
+func (d Decimal) Sign() int {
+	return d.Cmp(Zero)
+}
+
+// MinDecimal returns the smaller of a and b
+func MinDecimal(a, b Decimal) Decimal {
+	if a.units < b.units {
+		return a
+	}
+	return b
+}
+
+// MaxDecimal returns the larger of a and b
+func MaxDecimal(a, b Decimal) Decimal {
+	if a.units > b.units {
+		return a
+	}
+	return b
+}
+
+// IsMultipleOf reports whether d is an exact multiple of step.
+// Every value is a multiple of a zero step.
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	if step.units == 0 {
+		return true
+	}
+	return d.units%step.units == 0
+}
+
+// Truncate rounds d toward zero to a multiple of step
+func (d Decimal) Truncate(step Decimal) Decimal {
+	if step.units == 0 {
+		return d
+	}
+	return Decimal{units: d.units - d.units%step.units}
+}
+
+// String returns the shortest exact decimal representation
+func (d Decimal) String() string {
+	s := d.StringFixed(DecimalPlaces)
+	if strings.IndexByte(s, '.') >= 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// StringFixed returns the value rounded half away from zero to the given
+// number of fractional digits
+func (d Decimal) StringFixed(places int) string {
+	if places < 0 {
+		places = 0
+	}
+	if places > DecimalPlaces {
+		return d.StringFixed(DecimalPlaces) + strings.Repeat("0", places-DecimalPlaces)
+	}
+
+	units := new(big.Int).SetInt64(d.units)
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(DecimalPlaces-places)), nil)
+	rounded, _ := roundQuo(units, divisor) // dividing an int64 cannot overflow
+
+	sign := ""
+	if rounded < 0 {
+		sign = "-"
+	}
+	digits := new(big.Int).Abs(big.NewInt(rounded)).String()
+	if places == 0 {
+		return sign + digits
+	}
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-places] + "." + digits[len(digits)-places:]
+}
+
+// Format implements fmt.Formatter so Decimals print exactly with %v, %s and %.Nf
+func (d Decimal) Format(f fmt.State, verb rune) {
+	var s string
+	switch verb {
+	case 'f', 'F':
+		places, ok := f.Precision()
+		if !ok {
+			places = 6
+		}
+		s = d.StringFixed(places)
+	case 'v', 's':
+		s = d.String()
+	default:
+		fmt.Fprintf(f, "%"+string(verb), d.Float64())
+		return
+	}
+
+	if width, ok := f.Width(); ok && len(s) < width {
+		padding := strings.Repeat(" ", width-len(s))
+		if f.Flag('-') {
+			s += padding
+		} else {
+			s = padding + s
+		}
+	}
+	fmt.Fprint(f, s)
+}
+
+// MarshalJSON encodes the Decimal as a JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or numeric string without passing
+// through float64
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
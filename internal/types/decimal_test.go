@@ -0,0 +1,108 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := map[string]string{
+		"50000":       "50000",
+		"50000.00":    "50000",
+		"0.1":         "0.1",
+		"-0.001":      "-0.001",
+		".5":          "0.5",
+		"1e-8":        "0.00000001",
+		"12.34567891": "12.34567891",
+	}
+	for input, expected := range cases {
+		d, err := ParseDecimal(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, d.String(), input)
+	}
+
+	for _, input := range []string{"", "abc", "1.2.3", "--1", "0.123456789", "1e-9"} {
+		_, err := ParseDecimal(input)
+		assert.Error(t, err, input)
+	}
+
+	assert.Equal(t, NewDecimal(50000.25), MustDecimal("50000.25"))
+	assert.Panics(t, func() { MustDecimal("abc") })
+}
+
+func TestDecimalOverflowPanics(t *testing.T) {
+	max := MustDecimal("92233720368.54775807")
+	min := max.Neg().Sub(MustDecimal("0.00000001"))
+	one := NewDecimal(1)
+
+	assert.Panics(t, func() { max.Add(one) })
+	assert.Panics(t, func() { min.Sub(one) })
+	assert.Panics(t, func() { min.Neg() })
+	assert.Panics(t, func() { NewDecimal(1e6).Mul(NewDecimal(1e6)) })
+	assert.Panics(t, func() { max.Div(NewDecimal(0.5)) })
+	assert.Panics(t, func() { NewDecimal(1e11) })
+	assert.Panics(t, func() { NewDecimalFromInt(1e11) })
+
+	// Results at the edge of the range are exact
+	assert.Equal(t, max, max.Sub(one).Add(one))
+	assert.Equal(t, "92233720368.54775807", max.Mul(one).String())
+	assert.Equal(t, NewDecimal(5e10), NewDecimal(1e10).Mul(NewDecimal(5)))
+}
+
+func TestDecimalArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 is not 0.3 in float64
+	sum := NewDecimal(0.1).Add(NewDecimal(0.2))
+	assert.True(t, sum.Equal(NewDecimal(0.3)))
+
+	price := NewDecimal(50150)
+	qty := NewDecimal(2.5)
+	assert.Equal(t, "125375", price.Mul(qty).String())
+
+	// Large notionals do not overflow the intermediate product
+	assert.Equal(t, "5000000000", NewDecimal(50000).Mul(NewDecimal(100000)).String())
+
+	// Division rounds half away from zero at the last place
+	assert.Equal(t, "0.33333333", NewDecimal(1).Div(NewDecimal(3)).String())
+	assert.Equal(t, "0.66666667", NewDecimal(2).Div(NewDecimal(3)).String())
+	assert.Equal(t, "-0.66666667", NewDecimal(-2).Div(NewDecimal(3)).String())
+
+	assert.Panics(t, func() { NewDecimal(1).Div(Zero) })
+}
+
+func TestDecimalFormatting(t *testing.T) {
+	d := NewDecimal(50116.66666667)
+	assert.Equal(t, "50116.67", fmt.Sprintf("%.2f", d))
+	assert.Equal(t, "50116.66666667", fmt.Sprintf("%v", d))
+	assert.Equal(t, "50117", d.StringFixed(0))
+	assert.Equal(t, "-0.05", NewDecimal(-0.05).StringFixed(2))
+	assert.Equal(t, "0.00000001", NewDecimal(0.00000001).String())
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var entry OrderBookEntry
+	require.NoError(t, json.Unmarshal([]byte(`{"price": 0.451, "quantity": "900"}`), &entry))
+	assert.Equal(t, "0.451", entry.Price.String())
+	assert.Equal(t, "900", entry.Quantity.String())
+
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price": 0.451, "quantity": 900}`, string(data))
+}
+
+func TestInstrumentValidation(t *testing.T) {
+	instrument := Instrument{Symbol: "BTCUSD", TickSize: NewDecimal(0.01), LotSize: NewDecimal(0.001)}
+
+	assert.NoError(t, instrument.ValidatePrice(NewDecimal(50000.25)))
+	assert.Error(t, instrument.ValidatePrice(NewDecimal(50000.255)))
+	assert.Error(t, instrument.ValidatePrice(Zero))
+
+	assert.NoError(t, instrument.ValidateQuantity(NewDecimal(1.5)))
+	assert.Error(t, instrument.ValidateQuantity(NewDecimal(1.5005)))
+	assert.Error(t, instrument.ValidateQuantity(NewDecimal(-1)))
+
+	assert.Equal(t, NewDecimal(1.5), NewDecimal(1.5009).Truncate(instrument.LotSize))
+}
@@ -0,0 +1,90 @@
+package types
+
+import "time"
+
+// Position is a signed holding in one symbol: a positive Quantity is long
+// and a negative one short. EntryPrice is the average price of the open
+// quantity and EntryTime when the current position was opened.
+type Position struct {
+	Symbol        string
+	Quantity      Decimal
+	EntryPrice    Decimal
+	EntryTime     time.Time
+	CurrentPrice  Decimal
+	UnrealizedPnL Decimal
+	RealizedPnL   Decimal // P&L locked in by reductions, accumulated over the position's life
+}
+
+// Side returns SideBuy for a long position, SideSell for a short one and an
+// empty side when flat
+func (p Position) Side() Side {
+	switch {
+	case p.Quantity.IsPositive():
+		return SideBuy
+	case p.Quantity.IsNegative():
+		return SideSell
+	}
+	return ""
+}
+
+// IsFlat reports whether nothing is held
+func (p Position) IsFlat() bool {
+	return p.Quantity.IsZero()
+}
+
+// Apply updates the position with a fill and returns the P&L the fill
+// realised. Fills on the side of the position, or from flat, scale in at
+// the average entry price. Fills against it reduce or close it, realising
+// the difference to the entry price; any quantity beyond the position
+// opens a new one on the other side at the fill price.
+func (p *Position) Apply(side Side, quantity, price Decimal, timestamp time.Time) Decimal {
+	signed := quantity
+	if side == SideSell {
+		signed = quantity.Neg()
+	}
+
+	var realized Decimal
+	switch {
+	case p.Quantity.IsZero():
+		p.Quantity = signed
+		p.EntryPrice = price
+		p.EntryTime = timestamp
+
+	case p.Quantity.Sign() == signed.Sign():
+		// Scale in at the average price of the open quantity
+		cost := p.EntryPrice.Mul(p.Quantity).Add(price.Mul(signed))
+		p.Quantity = p.Quantity.Add(signed)
+		p.EntryPrice = cost.Div(p.Quantity)
+
+	default:
+		closing := MinDecimal(quantity, p.Quantity.Abs())
+		realized = price.Sub(p.EntryPrice).Mul(closing)
+		if p.Quantity.IsNegative() {
+			realized = realized.Neg()
+		}
+
+		remaining := p.Quantity.Add(signed)
+		switch {
+		case remaining.IsZero():
+			p.EntryPrice = Zero
+		case remaining.Sign() != p.Quantity.Sign():
+			// Flipped: the excess opens a position on the other side
+			p.EntryPrice = price
+			p.EntryTime = timestamp
+		}
+		p.Quantity = remaining
+	}
+
+	p.RealizedPnL = p.RealizedPnL.Add(realized)
+	p.Mark(price)
+	return realized
+}
+
+// Mark values the open quantity at a price
+func (p *Position) Mark(price Decimal) {
+	p.CurrentPrice = price
+	p.UnrealizedPnL = price.Sub(p.EntryPrice).Mul(p.Quantity)
+	if p.Quantity.IsZero() {
+		p.UnrealizedPnL = Zero
+	}
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionOpensLongAndShort(t *testing.T) {
+	now := time.Now()
+
+	var long Position
+	assert.True(t, long.IsFlat())
+	assert.True(t, long.Apply(SideBuy, MustDecimal("1"), MustDecimal("100"), now).IsZero())
+	assert.Equal(t, SideBuy, long.Side())
+	assert.Equal(t, "1", long.Quantity.String())
+	assert.Equal(t, "100", long.EntryPrice.String())
+	assert.Equal(t, now, long.EntryTime)
+
+	var short Position
+	short.Apply(SideSell, MustDecimal("2"), MustDecimal("100"), now)
+	assert.Equal(t, SideSell, short.Side())
+	assert.Equal(t, "-2", short.Quantity.String())
+
+	short.Mark(MustDecimal("90"))
+	assert.Equal(t, "20", short.UnrealizedPnL.String(), "a short gains as the price falls")
+}
+
+func TestPositionScalesInAtAveragePrice(t *testing.T) {
+	var p Position
+	start := time.Now()
+	p.Apply(SideSell, MustDecimal("1"), MustDecimal("100"), start)
+	p.Apply(SideSell, MustDecimal("3"), MustDecimal("104"), start.Add(time.Second))
+
+	assert.Equal(t, "-4", p.Quantity.String())
+	assert.Equal(t, "103", p.EntryPrice.String())
+	assert.Equal(t, start, p.EntryTime, "scaling in keeps the opening time")
+}
+
+func TestPositionReducesAndCloses(t *testing.T) {
+	var p Position
+	p.Apply(SideBuy, MustDecimal("2"), MustDecimal("100"), time.Now())
+
+	assert.Equal(t, "10", p.Apply(SideSell, MustDecimal("0.5"), MustDecimal("120"), time.Now()).String())
+	assert.Equal(t, "1.5", p.Quantity.String())
+	assert.Equal(t, "100", p.EntryPrice.String(), "a reduction leaves the entry price")
+	assert.Equal(t, "30", p.UnrealizedPnL.String())
+
+	assert.Equal(t, "-15", p.Apply(SideSell, MustDecimal("1.5"), MustDecimal("90"), time.Now()).String())
+	assert.True(t, p.IsFlat())
+	assert.Equal(t, Side(""), p.Side())
+	assert.True(t, p.UnrealizedPnL.IsZero())
+	assert.Equal(t, "-5", p.RealizedPnL.String())
+
+	var short Position
+	short.Apply(SideSell, MustDecimal("1"), MustDecimal("100"), time.Now())
+	assert.Equal(t, "5", short.Apply(SideBuy, MustDecimal("1"), MustDecimal("95"), time.Now()).String())
+	assert.True(t, short.IsFlat())
+}
+
+func TestPositionFlips(t *testing.T) {
+	var p Position
+	p.Apply(SideBuy, MustDecimal("1"), MustDecimal("100"), time.Now())
+
+	flipTime := time.Now().Add(time.Minute)
+	realized := p.Apply(SideSell, MustDecimal("3"), MustDecimal("110"), flipTime)
+
+	assert.Equal(t, "10", realized.String(), "only the closed long realises P&L")
+	assert.Equal(t, "-2", p.Quantity.String())
+	assert.Equal(t, "110", p.EntryPrice.String(), "the new short is entered at the fill price")
+	assert.Equal(t, flipTime, p.EntryTime)
+	assert.True(t, p.UnrealizedPnL.IsZero())
+}
@@ -1,6 +1,10 @@
 package types
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Side represents order side
 type Side string
@@ -10,44 +14,318 @@ const (
 	SideSell Side = "SELL"
 )
 
+// Opposite returns the other side of the book
+func (s Side) Opposite() Side {
+	if s == SideBuy {
+		return SideSell
+	}
+	return SideBuy
+}
+
 // OrderBookEntry represents a single order book entry
 type OrderBookEntry struct {
-	Price    float64 `json:"price"`
-	Quantity float64 `json:"quantity"`
+	Price    Decimal `json:"price"`
+	Quantity Decimal `json:"quantity"`
 }
 
 // OrderBookSnapshot represents a complete L2 order book snapshot
 type OrderBookSnapshot struct {
 	Symbol    string           `json:"symbol"`
+	Sequence  uint64           `json:"sequence"`
 	Timestamp time.Time        `json:"timestamp"`
 	Bids      []OrderBookEntry `json:"bids"`
 	Asks      []OrderBookEntry `json:"asks"`
 }
 
-// TradeSignal represents a trading signal from strategy to broker
+// PriceLevelUpdate sets the quantity at a single price level.
+// Side is SideBuy for bids and SideSell for asks; a zero quantity removes the level.
+type PriceLevelUpdate struct {
+	Side     Side    `json:"side"`
+	Price    Decimal `json:"price"`
+	Quantity Decimal `json:"quantity"`
+}
+
+// OrderBookDelta represents an incremental L2 update applied on top of a snapshot
+type OrderBookDelta struct {
+	Symbol    string             `json:"symbol"`
+	Sequence  uint64             `json:"sequence"`
+	Timestamp time.Time          `json:"timestamp"`
+	Updates   []PriceLevelUpdate `json:"updates"`
+}
+
+// OrderEventType identifies an order-by-order (L3) book event
+type OrderEventType string
+
+const (
+	OrderEventAdd     OrderEventType = "add"
+	OrderEventModify  OrderEventType = "modify"
+	OrderEventCancel  OrderEventType = "cancel"
+	OrderEventExecute OrderEventType = "execute"
+)
+
+// BookOrder represents an individual resting order in an L3 book
+type BookOrder struct {
+	ID        string    `json:"order_id"`
+	Side      Side      `json:"side"`
+	Price     Decimal   `json:"price"`
+	Quantity  Decimal   `json:"quantity"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// OrderEvent represents a single order-by-order feed message.
+// For modify events Price and Quantity are the new values; for execute
+// events Quantity is the traded amount.
+type OrderEvent struct {
+	Type      OrderEventType `json:"type"`
+	Symbol    string         `json:"symbol"`
+	Sequence  uint64         `json:"sequence"`
+	Timestamp time.Time      `json:"timestamp"`
+	OrderID   string         `json:"order_id"`
+	Side      Side           `json:"side"`
+	Price     Decimal        `json:"price"`
+	Quantity  Decimal        `json:"quantity"`
+}
+
+// MarketData is a single market data message published by the feed.
+// Exactly one of Snapshot or Delta is set. Sequence numbers are assigned
+// per symbol; zero means the message is unsequenced.
+type MarketData struct {
+	Snapshot *OrderBookSnapshot
+	Delta    *OrderBookDelta
+}
+
+// Symbol returns the symbol of the carried message
+func (m MarketData) Symbol() string {
+	if m.Snapshot != nil {
+		return m.Snapshot.Symbol
+	}
+	if m.Delta != nil {
+		return m.Delta.Symbol
+	}
+	return ""
+}
+
+// Sequence returns the per-symbol sequence number of the carried message
+func (m MarketData) Sequence() uint64 {
+	if m.Snapshot != nil {
+		return m.Snapshot.Sequence
+	}
+	if m.Delta != nil {
+		return m.Delta.Sequence
+	}
+	return 0
+}
+
+// OrderAction is the kind of request a TradeSignal carries
+type OrderAction string
+
+const (
+	ActionNew     OrderAction = "NEW"
+	ActionCancel  OrderAction = "CANCEL"
+	ActionReplace OrderAction = "REPLACE"
+)
+
+// OrderType distinguishes orders that take any price from orders with a limit
+type OrderType string
+
+const (
+	OrderTypeMarket       OrderType = "MARKET"
+	OrderTypeLimit        OrderType = "LIMIT"
+	OrderTypeStop         OrderType = "STOP"          // market order once StopPrice trades
+	OrderTypeStopLimit    OrderType = "STOP_LIMIT"    // limit order at Price once StopPrice trades
+	OrderTypeTrailingStop OrderType = "TRAILING_STOP" // stop that follows the market by a trail
+)
+
+// IsConditional reports whether orders of this type are held until triggered
+func (t OrderType) IsConditional() bool {
+	switch t {
+	case OrderTypeStop, OrderTypeStopLimit, OrderTypeTrailingStop:
+		return true
+	}
+	return false
+}
+
+// TimeInForce controls how long the unfilled part of an order stays working
+type TimeInForce string
+
+const (
+	TimeInForceGTC TimeInForce = "GTC" // good till cancelled
+	TimeInForceIOC TimeInForce = "IOC" // immediate or cancel
+	TimeInForceFOK TimeInForce = "FOK" // fill in full immediately or cancel
+	TimeInForceGTD TimeInForce = "GTD" // good till ExpireTime on the feed clock
+)
+
+// TradeSignal represents a trading signal from strategy to broker.
+// An empty Action is a new order. Cancel and replace requests refer to a
+// working order by OrderID; a replace changes its price and quantity, where
+// zero keeps the current value.
+//
+// An empty Type is a market order when Price is zero and a limit order
+// otherwise. An empty TimeInForce is IOC for market orders and GTC for
+// limit and conditional orders.
+//
+// Stop orders trigger when the touch on the opposite side reaches StopPrice:
+// the best bid for sells and the best ask for buys. A trailing stop keeps its
+// stop TrailAmount, or TrailPercent of the price, away from the best price
+// seen since it was placed.
+//
+// Orders sharing an OCOGroup are one-cancels-other: once one of them fills
+// completely, the others are cancelled and new orders in the group are
+// rejected, while a partial fill reduces the others by the filled quantity.
+// An order with TakeProfitPrice and/or StopLossPrice is a bracket parent:
+// each fill attaches an OCO pair of exits on the opposite side, a limit
+// order at TakeProfitPrice and a stop at StopLossPrice.
 type TradeSignal struct {
-	Symbol    string
-	Side      Side
-	Price     float64
-	Quantity  float64
-	Timestamp time.Time
+	OrderID         string // client order ID; assigned by the broker when empty
+	Action          OrderAction
+	Symbol          string
+	Side            Side
+	Type            OrderType
+	TimeInForce     TimeInForce
+	Price           Decimal
+	Quantity        Decimal
+	StopPrice       Decimal   // trigger price for stop and stop-limit orders
+	TrailAmount     Decimal   // absolute trail for trailing stops
+	TrailPercent    Decimal   // fractional trail for trailing stops, 0.01 = 1%
+	ExpireTime      time.Time // required for GTD orders
+	OCOGroup        string    // one-cancels-other group
+	TakeProfitPrice Decimal   // bracket target price
+	StopLossPrice   Decimal   // bracket protective stop price
+	PostOnly        bool      // reject instead of taking liquidity
+	Timestamp       time.Time
+}
+
+// Suffixes of the order IDs of a bracket parent's exits
+const (
+	bracketStopSuffix   = "-SL"
+	bracketTargetSuffix = "-TP"
+)
+
+// BracketExitIDs returns the order IDs of the stop and target exits that the
+// broker attaches to a bracket parent
+func BracketExitIDs(parentID string) (stopID, targetID string) {
+	return parentID + bracketStopSuffix, parentID + bracketTargetSuffix
+}
+
+// BracketGroup returns the OCO group of the exits of a bracket parent
+func BracketGroup(parentID string) string {
+	return "bracket-" + parentID
+}
+
+// BracketParentID returns the parent order ID of a bracket exit order ID, or
+// false if the ID does not name a bracket exit
+func BracketParentID(id string) (string, bool) {
+	for _, suffix := range []string{bracketStopSuffix, bracketTargetSuffix} {
+		if parent, found := strings.CutSuffix(id, suffix); found && parent != "" {
+			return parent, true
+		}
+	}
+	return "", false
+}
+
+// OrderStatus is the state of an order in its lifecycle
+type OrderStatus string
+
+const (
+	StatusNew             OrderStatus = "NEW"              // sent, not yet acknowledged
+	StatusAcked           OrderStatus = "ACKED"            // accepted and working
+	StatusTriggered       OrderStatus = "TRIGGERED"        // stop condition met, order released
+	StatusPartiallyFilled OrderStatus = "PARTIALLY_FILLED" // filled in part, rest working
+	StatusFilled          OrderStatus = "FILLED"
+	StatusCancelled       OrderStatus = "CANCELLED"
+	StatusRejected        OrderStatus = "REJECTED"
+	StatusExpired         OrderStatus = "EXPIRED"
+
+	// StatusRequestRejected answers a cancel or replace that was refused.
+	// It is not a state of the order, which carries on unchanged.
+	StatusRequestRejected OrderStatus = "REQUEST_REJECTED"
+)
+
+// IsTerminal reports whether no further reports will follow for the order
+func (s OrderStatus) IsTerminal() bool {
+	switch s {
+	case StatusFilled, StatusCancelled, StatusRejected, StatusExpired:
+		return true
+	}
+	return false
+}
+
+// Liquidity tells whether a fill added liquidity to the book or took it
+type Liquidity string
+
+const (
+	LiquidityMaker Liquidity = "MAKER" // a resting order was filled
+	LiquidityTaker Liquidity = "TAKER" // an incoming order crossed the book
+)
+
+// Fill represents quantity traded at a single price level
+type Fill struct {
+	Price    Decimal
+	Quantity Decimal
 }
 
-// Execution represents a completed trade
+// Execution is an execution report for an order. Reports with a positive
+// Quantity carry fills: Price is the volume-weighted average of Fills and
+// Quantity their total. Reports without fills acknowledge a state change
+// such as an ack, cancel or reject.
 type Execution struct {
+	OrderID   string
+	Status    OrderStatus
+	Reason    string // why the order or request was rejected or cancelled
 	Symbol    string
 	Side      Side
-	Price     float64
-	Quantity  float64
+	Price     Decimal
+	Quantity  Decimal
 	Timestamp time.Time
+
+	Fills     []Fill  // per-level fills in the order they were matched
+	Cancelled Decimal // unfilled order quantity that was cancelled
+	Leaves    Decimal // unfilled limit order quantity still working
+	Replaced  bool    // answers a replace the broker accepted; the order works with the new terms
+
+	Fee       Decimal   // fee charged for the fills, in the quote currency; negative for a rebate
+	Liquidity Liquidity // whether the fills made or took liquidity; empty without fills
+}
+
+// Notional returns the quote amount of the report's fills, summed per level
+// when the levels are known so that no rounding of the average price is
+// carried into it
+func (e Execution) Notional() Decimal {
+	if len(e.Fills) == 0 {
+		return e.Price.Mul(e.Quantity)
+	}
+	var total Decimal
+	for _, fill := range e.Fills {
+		total = total.Add(fill.Price.Mul(fill.Quantity))
+	}
+	return total
+}
+
+// Instrument holds per-symbol trading metadata
+type Instrument struct {
+	Symbol   string
+	TickSize Decimal // minimum price increment; zero disables the check
+	LotSize  Decimal // minimum quantity increment; zero disables the check
+}
+
+// ValidatePrice returns an error if price is not a positive multiple of the tick size
+func (i Instrument) ValidatePrice(price Decimal) error {
+	if !price.IsPositive() {
+		return fmt.Errorf("%s price %s must be positive", i.Symbol, price)
+	}
+	if !price.IsMultipleOf(i.TickSize) {
+		return fmt.Errorf("%s price %s is not a multiple of tick size %s", i.Symbol, price, i.TickSize)
+	}
+	return nil
 }
 
-// Position represents a current position
-type Position struct {
-	Symbol        string
-	Quantity      float64
-	EntryPrice    float64
-	EntryTime     time.Time
-	CurrentPrice  float64
-	UnrealizedPnL float64
+// ValidateQuantity returns an error if quantity is not a positive multiple of the lot size
+func (i Instrument) ValidateQuantity(quantity Decimal) error {
+	if !quantity.IsPositive() {
+		return fmt.Errorf("%s quantity %s must be positive", i.Symbol, quantity)
+	}
+	if !quantity.IsMultipleOf(i.LotSize) {
+		return fmt.Errorf("%s quantity %s is not a multiple of lot size %s", i.Symbol, quantity, i.LotSize)
+	}
+	return nil
 }
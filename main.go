@@ -9,14 +9,25 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"trading-engine/internal/account"
 	"trading-engine/internal/broker"
 	"trading-engine/internal/engine"
 	"trading-engine/internal/feed"
+	"trading-engine/internal/fees"
 	"trading-engine/internal/orderbook"
+	"trading-engine/internal/portfolio"
+	"trading-engine/internal/risk"
 	"trading-engine/internal/strategy"
 	"trading-engine/internal/types"
 )
 
+// instruments holds tick and lot sizes for the symbols in the sample data
+var instruments = []types.Instrument{
+	{Symbol: "BTCUSD", TickSize: types.NewDecimal(0.01), LotSize: types.NewDecimal(0.0001)},
+	{Symbol: "ETHUSD", TickSize: types.NewDecimal(0.01), LotSize: types.NewDecimal(0.001)},
+	{Symbol: "ADAUSD", TickSize: types.NewDecimal(0.0001), LotSize: types.NewDecimal(1)},
+}
+
 type TradingSession struct {
 	ID            string
 	OrderbookFile string
@@ -25,22 +36,62 @@ type TradingSession struct {
 }
 
 type SessionConfig struct {
+	Strategy        string // registered strategy name; empty selects the default
+	Symbol          string // symbol to trade; empty uses the feed's first snapshot
 	EntryPrice      float64
 	OrderSize       float64
 	StopLoss        float64
 	TakeProfit      float64
-	LiquidityThresh float64
+	LiquidityThresh float64 // minimum quantity near mid before entering; zero disables the gate
+	LiquidityBand   float64 // fraction of mid counted by the liquidity gate
 	MaxHoldTime     time.Duration
+	// Imbalance strategy settings; zero values use the strategy defaults
+	ImbalanceEntry   float64
+	ImbalanceExit    float64
+	ImbalanceConfirm int
+	DepthFraction    float64
+	// Market-making settings; zero values use the strategy defaults
+	QuoteHalfSpread float64
+	QuoteSize       float64
+	MaxInventory    float64
+	InventorySkew   float64
+	IndicatorPeriod int     // book updates the strategy's indicators look back over; zero uses the default
+	LotMethod       string  // lot matching for realized P&L (fifo, lifo, average); empty uses fifo
+	Balances        string  // starting balances such as "USD=100000,BTC=1"; empty disables buying-power checks
+	Fees            string  // fee schedule such as "bps=10" or "maker-taker=-1/5"; empty charges nothing
+	FeeVolume       float64 // quote volume traded in the 30 days before the session, for tiered fees
 	OutputFile      string
+	MarketImpact    bool          // executions consume book liquidity
+	ImpactHalfLife  time.Duration // replenishment half-life; zero restores on next snapshot
+	LimitFallback   bool          // unfillable limit orders execute at the best price instead of resting
+	Latency         string        // order-entry latency such as "fixed=50ms"; empty acts on orders at once
+	Slippage        string        // extra slippage such as "bps=2" or "depth=50"; empty adds none
+	Seed            int64         // seeds the latency samples
+	// Pre-trade risk limits; zero disables a check
+	MaxOrderSize float64
+	MaxNotional  float64
+	MaxPosition  float64
+	PriceBand    float64 // fraction of mid a limit or stop price may be away from it
+	MaxOrderRate int     // orders per second
+	MaxDailyLoss float64
 }
 
 type SessionResults struct {
-	TradeLog    []types.Execution
-	TotalPnL    float64
-	TotalTrades int
-	Duration    time.Duration
-	Success     bool
-	Error       error
+	TradeLog      []types.Execution
+	TotalPnL      types.Decimal // realized plus unrealized, net of fees
+	RealizedPnL   types.Decimal
+	UnrealizedPnL types.Decimal     // open positions marked at the final mid
+	Fees          types.Decimal     // fees paid, net of rebates, already taken off TotalPnL
+	Portfolio     portfolio.Report  // P&L per symbol
+	Balances      []account.Balance // ending balances; nil without an account
+	TotalTrades   int
+	SequenceGaps  int
+	Risk          risk.Stats               // pre-trade checks and rejects by check
+	LiquidityGate *strategy.LiquidityGate  // nil when the strategy has no gate
+	Attribution   *strategy.PnLAttribution // nil when the strategy does not attribute P&L
+	Duration      time.Duration
+	Success       bool
+	Error         error
 }
 
 func main() {
@@ -48,14 +99,42 @@ func main() {
 	var (
 		concurrent      = flag.Bool("concurrent", false, "Run all 3 samples concurrently")
 		sessionID       = flag.String("session", "", "Specific session ID to run (btc, eth, ada)")
+		symbol          = flag.String("symbol", "", "Symbol to trade (empty = symbol of the feed's first snapshot)")
+		strategyName    = flag.String("strategy", strategy.DefaultName, "Strategy to run ("+strings.Join(strategy.Names(), ", ")+")")
 		orderbookFile   = flag.String("orderbook", "data/sample1.json", "Path to orderbook JSON file")
 		entryPrice      = flag.Float64("entry", 0, "Entry price (0 for auto)")
 		orderSize       = flag.Float64("size", 100, "Order size")
 		stopLoss        = flag.Float64("stop", 0.02, "Stop loss percentage (0.02 = 2%)")
 		takeProfit      = flag.Float64("profit", 0.05, "Take profit percentage (0.05 = 5%)")
-		liquidityThresh = flag.Float64("liquidity", 1000, "Minimum liquidity threshold")
+		liquidityThresh = flag.Float64("liquidity", 5, "Minimum quantity within the liquidity band before entering (0 disables the gate)")
+		liquidityBand   = flag.Float64("liquidity-band", 0.01, "Band around mid counted by the liquidity gate (0.01 = 1%)")
 		maxHoldTime     = flag.Duration("hold", 30*time.Second, "Maximum hold time")
+		imbalanceEntry  = flag.Float64("imbalance-entry", 0.05, "Imbalance strategy: |imbalance| that signals an entry")
+		imbalanceExit   = flag.Float64("imbalance-exit", 0, "Imbalance strategy: imbalance against the position that signals an exit")
+		imbalanceN      = flag.Int("imbalance-confirm", 2, "Imbalance strategy: consecutive updates beyond the entry threshold")
+		quoteHalfSpread = flag.Float64("quote-spread", 0.0005, "Market maker: distance of each quote from mid (0.0005 = 5bp)")
+		quoteSize       = flag.Float64("quote-size", 0, "Market maker: quantity quoted on each side (0 = -size)")
+		maxInventory    = flag.Float64("max-inventory", 0, "Market maker: absolute inventory cap (0 = 5 quotes)")
+		inventorySkew   = flag.Float64("inventory-skew", 0.001, "Market maker: quote shift as a fraction of mid at the inventory cap")
+		depthFraction   = flag.Float64("depth-fraction", 0.5, "Imbalance strategy: share of the depth within the liquidity band an entry may take")
+		indicatorPeriod = flag.Int("indicator-period", 20, "Book updates the strategy's indicators look back over")
+		lotMethod       = flag.String("lot-method", "fifo", "Lot matching for realized P&L (fifo, lifo, average)")
+		balances        = flag.String("balances", "", "Starting balances, e.g. USD=100000,BTC=1 (empty = unlimited)")
+		feeSchedule     = flag.String("fees", "none", "Fee schedule: none, flat=AMOUNT, bps=RATE, maker-taker=MAKER/TAKER or tiered=VOLUME:MAKER/TAKER,...")
+		feeVolume       = flag.Float64("fee-volume", 0, "Quote volume traded in the 30 days before the session, for tiered fees")
 		outputFile      = flag.String("output", "trades.csv", "Output CSV file for trades")
+		marketImpact    = flag.Bool("impact", false, "Executions consume order book liquidity")
+		impactHalfLife  = flag.Duration("impact-halflife", 0, "Half-life for consumed liquidity to replenish (0 = restore on next snapshot)")
+		limitFallback   = flag.Bool("limit-fallback", false, "Execute unfillable limit orders at the best available price instead of resting them")
+		latency         = flag.String("latency", "none", "Order-entry latency on the feed clock: none, fixed=D, uniform=MIN/MAX or sampled=D1,D2,...")
+		slippage        = flag.String("slippage", "none", "Extra slippage on orders that take liquidity: none, bps=RATE or depth=RATE")
+		seed            = flag.Int64("seed", 1, "Seed for the latency samples")
+		maxOrderSize    = flag.Float64("max-order-size", 0, "Risk: largest quantity of a single order (0 = no limit)")
+		maxNotional     = flag.Float64("max-notional", 0, "Risk: largest quantity times price of a single order (0 = no limit)")
+		maxPosition     = flag.Float64("max-position", 0, "Risk: largest absolute position per symbol, counting working orders (0 = no limit)")
+		priceBand       = flag.Float64("price-band", 0.05, "Risk: furthest a limit or stop price may be from mid (0.05 = 5%, 0 = no check)")
+		maxOrderRate    = flag.Int("max-order-rate", 0, "Risk: most orders and replaces per second (0 = no limit)")
+		maxDailyLoss    = flag.Float64("max-daily-loss", 0, "Risk: loss over the day that stops all but reducing orders (0 = no limit)")
 	)
 	flag.Parse()
 
@@ -71,8 +150,43 @@ func main() {
 	}
 
 	// Single session mode (original functionality)
-	runSingleSession(*orderbookFile, *entryPrice, *orderSize, *stopLoss,
-		*takeProfit, *liquidityThresh, *maxHoldTime, *outputFile)
+	runSingleSession(*orderbookFile, SessionConfig{
+		Strategy:         *strategyName,
+		Symbol:           *symbol,
+		EntryPrice:       *entryPrice,
+		OrderSize:        *orderSize,
+		StopLoss:         *stopLoss,
+		TakeProfit:       *takeProfit,
+		LiquidityThresh:  *liquidityThresh,
+		LiquidityBand:    *liquidityBand,
+		MaxHoldTime:      *maxHoldTime,
+		ImbalanceEntry:   *imbalanceEntry,
+		ImbalanceExit:    *imbalanceExit,
+		ImbalanceConfirm: *imbalanceN,
+		DepthFraction:    *depthFraction,
+		QuoteHalfSpread:  *quoteHalfSpread,
+		QuoteSize:        *quoteSize,
+		MaxInventory:     *maxInventory,
+		InventorySkew:    *inventorySkew,
+		IndicatorPeriod:  *indicatorPeriod,
+		LotMethod:        *lotMethod,
+		Balances:         *balances,
+		Fees:             *feeSchedule,
+		FeeVolume:        *feeVolume,
+		OutputFile:       *outputFile,
+		MarketImpact:     *marketImpact,
+		ImpactHalfLife:   *impactHalfLife,
+		LimitFallback:    *limitFallback,
+		Latency:          *latency,
+		Slippage:         *slippage,
+		Seed:             *seed,
+		MaxOrderSize:     *maxOrderSize,
+		MaxNotional:      *maxNotional,
+		MaxPosition:      *maxPosition,
+		PriceBand:        *priceBand,
+		MaxOrderRate:     *maxOrderRate,
+		MaxDailyLoss:     *maxDailyLoss,
+	})
 }
 
 func runConcurrentSessions() {
@@ -89,8 +203,10 @@ func runConcurrentSessions() {
 				OrderSize:       2.5,
 				StopLoss:        0.015, // 1.5%
 				TakeProfit:      0.04,  // 4%
-				LiquidityThresh: 800,
+				LiquidityThresh: 5,
 				MaxHoldTime:     8 * time.Second,
+				Balances:        "USD=150000",
+				PriceBand:       0.05,
 				OutputFile:      "concurrent_btc_trades.csv",
 			},
 		},
@@ -102,8 +218,10 @@ func runConcurrentSessions() {
 				OrderSize:       5.0,
 				StopLoss:        0.01,  // 1%
 				TakeProfit:      0.025, // 2.5%
-				LiquidityThresh: 1200,
+				LiquidityThresh: 20,
 				MaxHoldTime:     12 * time.Second,
+				Balances:        "USD=20000",
+				PriceBand:       0.05,
 				OutputFile:      "concurrent_eth_trades.csv",
 			},
 		},
@@ -115,8 +233,10 @@ func runConcurrentSessions() {
 				OrderSize:       8000,
 				StopLoss:        0.005, // 0.5%
 				TakeProfit:      0.015, // 1.5%
-				LiquidityThresh: 2000,
+				LiquidityThresh: 5000,
 				MaxHoldTime:     6 * time.Second,
+				Balances:        "USD=5000",
+				PriceBand:       0.05,
 				OutputFile:      "concurrent_ada_trades.csv",
 			},
 		},
@@ -190,19 +310,34 @@ func runConcurrentSessions() {
 	fmt.Println(strings.Repeat("=", 70))
 
 	totalTrades := 0
-	totalPnL := 0.0
+	var totalPnL types.Decimal
 	successfulSessions := 0
 
 	for _, result := range allResults {
 		if result.Results.Success {
 			successfulSessions++
 			totalTrades += result.Results.TotalTrades
-			totalPnL += result.Results.TotalPnL
+			totalPnL = totalPnL.Add(result.Results.TotalPnL)
 
 			fmt.Printf("\n📈 %s:\n", result.ID)
 			fmt.Printf("   📁 Data Source: %s\n", result.OrderbookFile)
 			fmt.Printf("   💹 Executed Trades: %d\n", result.Results.TotalTrades)
-			fmt.Printf("   💰 Session P&L: $%.2f\n", result.Results.TotalPnL)
+			fmt.Printf("   💰 Session P&L: $%.2f (realized %.2f, unrealized %.2f, fees %.2f)\n",
+				result.Results.TotalPnL, result.Results.RealizedPnL, result.Results.UnrealizedPnL, result.Results.Fees)
+			for _, symbol := range result.Results.Portfolio.Symbols {
+				fmt.Printf("   📒 %v\n", symbol)
+			}
+			if result.Results.Balances != nil {
+				fmt.Printf("   🏦 Ending Balances: %s\n", formatBalances(result.Results.Balances))
+			}
+			fmt.Printf("   🧩 Sequence Gaps: %d\n", result.Results.SequenceGaps)
+			fmt.Printf("   🛡️  Risk Checks: %v\n", result.Results.Risk)
+			if result.Results.LiquidityGate != nil {
+				fmt.Printf("   💧 Liquidity Gate: %v\n", *result.Results.LiquidityGate)
+			}
+			if result.Results.Attribution != nil {
+				fmt.Printf("   🧮 P&L Attribution: %v\n", *result.Results.Attribution)
+			}
 			fmt.Printf("   ⏱️  Execution Time: %v\n", result.Results.Duration)
 			fmt.Printf("   📊 Strategy: Entry=%.0f, Size=%.1f, Stop=%.1f%%, Profit=%.1f%%\n",
 				result.Config.EntryPrice, result.Config.OrderSize,
@@ -225,24 +360,25 @@ func runConcurrentSessions() {
 	fmt.Printf("   🚄 Total Wall-Clock Time: %v\n", totalDuration)
 	fmt.Printf("   ⚡ Estimated Speedup: %.1fx faster than sequential\n", speedup)
 	fmt.Printf("   🔧 Goroutines Used: %d main sessions + internal goroutines per session\n", len(sessions))
-	fmt.Printf("   📡 Channels Used: Results, Progress, + 4 channels per session\n")
+	fmt.Printf("   📡 Channels Used: Results, Progress, + 5 channels per session\n")
 
 	fmt.Println("\n🧠 GOROUTINES & CHANNELS ARCHITECTURE:")
 	fmt.Println("   • Main goroutine: Orchestrates and collects results")
 	fmt.Println("   • Progress goroutine: Real-time status updates via channel")
 	fmt.Println("   • Session goroutines: One per trading session (3 total)")
-	fmt.Println("   • Per-session goroutines: Feed, Engine, Strategy, Broker (4 each)")
+	fmt.Println("   • Per-session goroutines: Feed, Engine, Strategy, Risk, Broker, Portfolio (6 each)")
 	fmt.Println("   • Channel communication: orderbook updates, trade signals, executions")
-	fmt.Println("   • Total concurrent goroutines: ~17 running simultaneously!")
+	fmt.Println("   • Total concurrent goroutines: ~20 running simultaneously!")
 }
 
 func runTradingSession(session TradingSession, progressChan chan<- string) TradingSession {
-	// Initialize orderbook for this session
-	ob := orderbook.New()
+	// Initialize the per-symbol order books for this session
+	books := orderbook.NewRegistry()
 
 	// CHANNELS for inter-component communication (core of the architecture)
-	orderbookUpdates := make(chan types.OrderBookSnapshot, 100)
+	orderbookUpdates := make(chan types.MarketData, 100)
 	tradeSignals := make(chan types.TradeSignal, 10)
+	approvedSignals := make(chan types.TradeSignal, 10)
 	executions := make(chan types.Execution, 10)
 	strategyExecutions := make(chan types.Execution, 10)
 	done := make(chan bool)
@@ -255,26 +391,115 @@ func runTradingSession(session TradingSession, progressChan chan<- string) Tradi
 	feedInstance := feed.New(session.OrderbookFile, orderbookUpdates)
 
 	strategyConfig := strategy.Config{
+		Symbol:          session.Config.Symbol,
 		EntryPrice:      session.Config.EntryPrice,
 		OrderSize:       session.Config.OrderSize,
 		StopLoss:        session.Config.StopLoss,
 		TakeProfit:      session.Config.TakeProfit,
 		LiquidityThresh: session.Config.LiquidityThresh,
+		LiquidityBand:   session.Config.LiquidityBand,
 		MaxHoldTime:     session.Config.MaxHoldTime,
+
+		ImbalanceEntry:   session.Config.ImbalanceEntry,
+		ImbalanceExit:    session.Config.ImbalanceExit,
+		ImbalanceConfirm: session.Config.ImbalanceConfirm,
+		DepthFraction:    session.Config.DepthFraction,
+
+		QuoteHalfSpread: session.Config.QuoteHalfSpread,
+		QuoteSize:       session.Config.QuoteSize,
+		MaxInventory:    session.Config.MaxInventory,
+		InventorySkew:   session.Config.InventorySkew,
+	}
+	strategyName := session.Config.Strategy
+	if strategyName == "" {
+		strategyName = strategy.DefaultName
 	}
-	strategyInstance := strategy.New(strategyConfig, tradeSignals, strategyExecutions)
-	brokerInstance := broker.New(ob, tradeSignals, executions)
-	engineInstance := engine.New(ob, orderbookUpdates, done)
+	selected, err := strategy.NewByName(strategyName, strategyConfig)
+	if err != nil {
+		session.Results = SessionResults{Error: err}
+		return session
+	}
+	lotMethod, err := portfolio.ParseMethod(session.Config.LotMethod)
+	if err != nil {
+		session.Results = SessionResults{Error: err}
+		return session
+	}
+	feeSchedule, err := fees.Parse(session.Config.Fees)
+	if err != nil {
+		session.Results = SessionResults{Error: err}
+		return session
+	}
+	if tiered, ok := feeSchedule.(*fees.Tiered); ok {
+		tiered.PriorVolume = types.NewDecimal(session.Config.FeeVolume)
+	}
+	latencyModel, err := broker.ParseLatency(session.Config.Latency)
+	if err != nil {
+		session.Results = SessionResults{Error: err}
+		return session
+	}
+	slippageModel, err := broker.ParseSlippage(session.Config.Slippage)
+	if err != nil {
+		session.Results = SessionResults{Error: err}
+		return session
+	}
+	var accountInstance *account.Account
+	if session.Config.Balances != "" {
+		startingBalances, err := account.ParseBalances(session.Config.Balances)
+		if err != nil {
+			session.Results = SessionResults{Error: err}
+			return session
+		}
+		accountInstance = account.New(startingBalances)
+	}
+
+	engineInstance := engine.New(books, orderbookUpdates, done)
+	strategyInstance := strategy.NewRunner(selected, tradeSignals, strategyExecutions)
+	strategyInstance.SetBookUpdates(engineInstance.Subscribe(100))
+	if session.Config.IndicatorPeriod > 0 {
+		strategyInstance.SetIndicatorPeriod(session.Config.IndicatorPeriod)
+	}
+	riskInstance := risk.New(books, risk.Limits{
+		MaxOrderSize: types.NewDecimal(session.Config.MaxOrderSize),
+		MaxNotional:  types.NewDecimal(session.Config.MaxNotional),
+		MaxPosition:  types.NewDecimal(session.Config.MaxPosition),
+		PriceBand:    session.Config.PriceBand,
+		MaxOrderRate: session.Config.MaxOrderRate,
+		MaxDailyLoss: types.NewDecimal(session.Config.MaxDailyLoss),
+	}, tradeSignals, approvedSignals, executions)
+	brokerInstance := broker.New(books, approvedSignals, executions)
+	brokerInstance.SetBookUpdates(engineInstance.Subscribe(100))
+	brokerInstance.SetLimitFallback(session.Config.LimitFallback)
+	brokerInstance.SetFeeSchedule(feeSchedule)
+	if accountInstance != nil {
+		brokerInstance.SetAccount(accountInstance)
+	}
+	for _, instrument := range instruments {
+		brokerInstance.SetInstrument(instrument)
+		strategyInstance.SetInstrument(instrument)
+	}
+	brokerInstance.SetImpactModel(broker.ImpactModel{
+		Enabled:  session.Config.MarketImpact,
+		HalfLife: session.Config.ImpactHalfLife,
+	})
+	brokerInstance.SetExecutionModel(broker.ExecutionModel{
+		Latency:  latencyModel,
+		Slippage: slippageModel,
+		Seed:     session.Config.Seed,
+	})
+	portfolioInstance := portfolio.New(lotMethod)
+	portfolioInstance.SetBookUpdates(engineInstance.Subscribe(100))
 
 	if progressChan != nil {
-		progressChan <- fmt.Sprintf("⚙️  [%s] Starting 4 component goroutines", session.ID)
+		progressChan <- fmt.Sprintf("⚙️  [%s] Starting 6 component goroutines", session.ID)
 	}
 
 	// Start all components in separate GOROUTINES
-	go feedInstance.Start()     // GOROUTINE: Feed data from JSON
-	go engineInstance.Start()   // GOROUTINE: Process orderbook updates
-	go strategyInstance.Start() // GOROUTINE: Generate trade signals
-	go brokerInstance.Start()   // GOROUTINE: Execute trades
+	go feedInstance.Start()      // GOROUTINE: Feed data from JSON
+	go engineInstance.Start()    // GOROUTINE: Process orderbook updates
+	go strategyInstance.Start()  // GOROUTINE: Generate trade signals
+	go riskInstance.Start()      // GOROUTINE: Check signals before the broker
+	go brokerInstance.Start()    // GOROUTINE: Execute trades
+	go portfolioInstance.Start() // GOROUTINE: Mark positions to mid
 
 	// Track results through CHANNEL communication
 	var tradeLog []types.Execution
@@ -284,21 +509,35 @@ func runTradingSession(session TradingSession, progressChan chan<- string) Tradi
 	go func() {
 		tradeCount := 0
 		for execution := range executions {
+			riskInstance.Apply(execution)
+
+			// Send every report to strategy via CHANNEL. A lost report would
+			// leave its order open in the strategy, so wait for room until the
+			// strategy stops. Later reports, such as expiries at shutdown, still
+			// reach the risk manager, trade log and portfolio.
+			select {
+			case strategyExecutions <- execution:
+			case <-strategyInstance.Done():
+			}
+
+			if (execution.Status == types.StatusRejected || execution.Status == types.StatusRequestRejected) && progressChan != nil {
+				progressChan <- fmt.Sprintf("⛔ [%s] Order %s rejected: %s",
+					session.ID, execution.OrderID, execution.Reason)
+			}
+			if !execution.Quantity.IsPositive() {
+				// Acks, cancels and rejects carry no fills
+				continue
+			}
+
 			tradeCount++
 			if progressChan != nil {
 				progressChan <- fmt.Sprintf("💱 [%s] Trade #%d: %s %.2f @ $%.2f",
 					session.ID, tradeCount, execution.Side, execution.Quantity, execution.Price)
 			}
 
-			// Send to strategy via CHANNEL
-			select {
-			case strategyExecutions <- execution:
-			default:
-				log.Printf("[%s] Strategy executions channel full", session.ID)
-			}
-
 			// Collect for results
 			tradeLog = append(tradeLog, execution)
+			portfolioInstance.Apply(execution)
 		}
 		close(strategyExecutions)
 		executionsDone <- true
@@ -312,6 +551,7 @@ func runTradingSession(session TradingSession, progressChan chan<- string) Tradi
 
 	// Allow strategy to finish processing
 	time.Sleep(session.Config.MaxHoldTime + 2*time.Second)
+	strategyInstance.Stop()
 	close(tradeSignals)
 
 	// Wait for executions to finish via CHANNEL
@@ -320,22 +560,14 @@ func runTradingSession(session TradingSession, progressChan chan<- string) Tradi
 		progressChan <- fmt.Sprintf("🎯 [%s] All executions completed", session.ID)
 	}
 
-	// Calculate P&L
-	var totalPnL float64
-	buyTotal := 0.0
-	sellTotal := 0.0
-
-	for _, trade := range tradeLog {
-		if trade.Side == types.SideBuy {
-			buyTotal += trade.Price * trade.Quantity
-		} else {
-			sellTotal += trade.Price * trade.Quantity
-		}
+	// Calculate P&L with open positions marked at the final mid
+	portfolioInstance.Wait()
+	report := portfolioInstance.Report()
+	for _, symbol := range report.Symbols {
+		log.Printf("Portfolio %v", symbol)
 	}
-	totalPnL = sellTotal - buyTotal
 
 	// Write trade log to CSV
-	var err error
 	if len(tradeLog) > 0 {
 		err = writeTradeLog(session.Config.OutputFile, tradeLog)
 		if err == nil && progressChan != nil {
@@ -346,11 +578,28 @@ func runTradingSession(session TradingSession, progressChan chan<- string) Tradi
 
 	// Return results
 	session.Results = SessionResults{
-		TradeLog:    tradeLog,
-		TotalPnL:    totalPnL,
-		TotalTrades: len(tradeLog),
-		Success:     err == nil,
-		Error:       err,
+		TradeLog:      tradeLog,
+		TotalPnL:      report.Total,
+		RealizedPnL:   report.Realized,
+		UnrealizedPnL: report.Unrealized,
+		Fees:          report.Fees,
+		Portfolio:     report,
+		TotalTrades:   len(tradeLog),
+		SequenceGaps:  engineInstance.GapCount(),
+		Risk:          riskInstance.Stats(),
+		Success:       err == nil,
+		Error:         err,
+	}
+	if accountInstance != nil {
+		session.Results.Balances = accountInstance.Balances()
+	}
+	if gated, ok := selected.(strategy.Gated); ok {
+		gate := gated.LiquidityGate()
+		session.Results.LiquidityGate = &gate
+	}
+	if attributed, ok := selected.(strategy.Attributed); ok {
+		attribution := attributed.PnLAttribution()
+		session.Results.Attribution = &attribution
 	}
 
 	return session
@@ -365,8 +614,8 @@ func runSpecificSession(sessionID string) {
 			OrderbookFile: "data/sample1.json",
 			Config: SessionConfig{
 				EntryPrice: 0, OrderSize: 1.5, StopLoss: 0.02, TakeProfit: 0.05,
-				LiquidityThresh: 1000, MaxHoldTime: 6 * time.Second,
-				OutputFile: "btc_test_trades.csv",
+				LiquidityThresh: 5, MaxHoldTime: 6 * time.Second,
+				Balances: "USD=100000", PriceBand: 0.05, OutputFile: "btc_test_trades.csv",
 			},
 		},
 		"eth": {
@@ -374,8 +623,8 @@ func runSpecificSession(sessionID string) {
 			OrderbookFile: "data/sample2.json",
 			Config: SessionConfig{
 				EntryPrice: 3000, OrderSize: 3.0, StopLoss: 0.015, TakeProfit: 0.03,
-				LiquidityThresh: 1000, MaxHoldTime: 8 * time.Second,
-				OutputFile: "eth_test_trades.csv",
+				LiquidityThresh: 20, MaxHoldTime: 8 * time.Second,
+				Balances: "USD=10000", PriceBand: 0.05, OutputFile: "eth_test_trades.csv",
 			},
 		},
 		"ada": {
@@ -383,8 +632,8 @@ func runSpecificSession(sessionID string) {
 			OrderbookFile: "data/sample3.json",
 			Config: SessionConfig{
 				EntryPrice: 0, OrderSize: 2000, StopLoss: 0.01, TakeProfit: 0.02,
-				LiquidityThresh: 1000, MaxHoldTime: 5 * time.Second,
-				OutputFile: "ada_test_trades.csv",
+				LiquidityThresh: 5000, MaxHoldTime: 5 * time.Second,
+				Balances: "USD=1000", PriceBand: 0.05, OutputFile: "ada_test_trades.csv",
 			},
 		},
 	}
@@ -401,38 +650,62 @@ func runSpecificSession(sessionID string) {
 	if result.Results.Success {
 		fmt.Printf("✅ Session completed successfully!\n")
 		fmt.Printf("   💹 Trades: %d\n", result.Results.TotalTrades)
-		fmt.Printf("   💰 P&L: %.2f\n", result.Results.TotalPnL)
+		fmt.Printf("   💰 P&L: %.2f (realized %.2f, unrealized %.2f, fees %.2f)\n",
+			result.Results.TotalPnL, result.Results.RealizedPnL, result.Results.UnrealizedPnL, result.Results.Fees)
+		for _, symbol := range result.Results.Portfolio.Symbols {
+			fmt.Printf("   📒 %v\n", symbol)
+		}
+		if result.Results.Balances != nil {
+			fmt.Printf("   🏦 Ending balances: %s\n", formatBalances(result.Results.Balances))
+		}
+		fmt.Printf("   🧩 Sequence gaps: %d\n", result.Results.SequenceGaps)
+		fmt.Printf("   🛡️  Risk checks: %v\n", result.Results.Risk)
+		if result.Results.LiquidityGate != nil {
+			fmt.Printf("   💧 Liquidity gate: %v\n", *result.Results.LiquidityGate)
+		}
+		if result.Results.Attribution != nil {
+			fmt.Printf("   🧮 P&L attribution: %v\n", *result.Results.Attribution)
+		}
 		fmt.Printf("   📄 Output: %s\n", result.Config.OutputFile)
 	} else {
 		fmt.Printf("❌ Session failed: %v\n", result.Results.Error)
 	}
 }
 
-func runSingleSession(orderbookFile string, entryPrice, orderSize, stopLoss,
-	takeProfit, liquidityThresh float64, maxHoldTime time.Duration, outputFile string) {
-
+func runSingleSession(orderbookFile string, config SessionConfig) {
 	session := TradingSession{
 		ID:            "Single",
 		OrderbookFile: orderbookFile,
-		Config: SessionConfig{
-			EntryPrice:      entryPrice,
-			OrderSize:       orderSize,
-			StopLoss:        stopLoss,
-			TakeProfit:      takeProfit,
-			LiquidityThresh: liquidityThresh,
-			MaxHoldTime:     maxHoldTime,
-			OutputFile:      outputFile,
-		},
+		Config:        config,
 	}
 
 	fmt.Printf("🔧 Starting single trading session with:\n")
 	fmt.Printf("  📁 Orderbook file: %s\n", session.OrderbookFile)
+	fmt.Printf("  🧠 Strategy: %s\n", session.Config.Strategy)
+	if session.Config.Symbol != "" {
+		fmt.Printf("  🪙 Symbol: %s\n", session.Config.Symbol)
+	} else {
+		fmt.Printf("  🪙 Symbol: from first snapshot\n")
+	}
 	fmt.Printf("  💰 Entry price: %.2f\n", session.Config.EntryPrice)
 	fmt.Printf("  📊 Order size: %.2f\n", session.Config.OrderSize)
 	fmt.Printf("  � Stop loss: %.1f%%\n", session.Config.StopLoss*100)
 	fmt.Printf("  🎯 Take profit: %.1f%%\n", session.Config.TakeProfit*100)
-	fmt.Printf("  💧 Liquidity threshold: %.0f\n", session.Config.LiquidityThresh)
+	fmt.Printf("  💧 Liquidity threshold: %.4f within %.2f%% of mid\n", session.Config.LiquidityThresh, session.Config.LiquidityBand*100)
 	fmt.Printf("  ⏰ Max hold time: %v\n", session.Config.MaxHoldTime)
+	fmt.Printf("  📒 Lot matching: %s\n", session.Config.LotMethod)
+	fmt.Printf("  🧾 Fees: %s\n", session.Config.Fees)
+	if session.Config.Balances != "" {
+		fmt.Printf("  🏦 Starting balances: %s\n", session.Config.Balances)
+	} else {
+		fmt.Printf("  🏦 Starting balances: unlimited\n")
+	}
+	fmt.Printf("  🌊 Market impact: %v (half-life %v)\n", session.Config.MarketImpact, session.Config.ImpactHalfLife)
+	fmt.Printf("  🪝 Limit fallback: %v\n", session.Config.LimitFallback)
+	fmt.Printf("  🐢 Latency: %s, slippage: %s (seed %d)\n", session.Config.Latency, session.Config.Slippage, session.Config.Seed)
+	fmt.Printf("  🛡️  Risk limits: size %.4f, notional %.2f, position %.4f, price band %.2f%%, %d orders/s, daily loss %.2f (0 = none)\n",
+		session.Config.MaxOrderSize, session.Config.MaxNotional, session.Config.MaxPosition,
+		session.Config.PriceBand*100, session.Config.MaxOrderRate, session.Config.MaxDailyLoss)
 	fmt.Printf("  �📄 Output file: %s\n", session.Config.OutputFile)
 	fmt.Println()
 
@@ -442,8 +715,27 @@ func runSingleSession(orderbookFile string, entryPrice, orderSize, stopLoss,
 	fmt.Printf("\n=== TRADING SUMMARY ===\n")
 	fmt.Printf("Total trades: %d\n", result.Results.TotalTrades)
 	fmt.Printf("Total P&L: %.2f\n", result.Results.TotalPnL)
+	fmt.Printf("Realized P&L: %.2f\n", result.Results.RealizedPnL)
+	fmt.Printf("Unrealized P&L: %.2f\n", result.Results.UnrealizedPnL)
+	fmt.Printf("Fees: %.2f\n", result.Results.Fees)
+	for _, symbol := range result.Results.Portfolio.Symbols {
+		fmt.Printf("  %v\n", symbol)
+	}
+	if result.Results.Balances != nil {
+		fmt.Printf("Ending balances: %s\n", formatBalances(result.Results.Balances))
+	}
+	fmt.Printf("Sequence gaps: %d\n", result.Results.SequenceGaps)
+	fmt.Printf("Risk checks: %v\n", result.Results.Risk)
+	if result.Results.LiquidityGate != nil {
+		fmt.Printf("Liquidity gate: %v\n", *result.Results.LiquidityGate)
+	}
+	if result.Results.Attribution != nil {
+		fmt.Printf("P&L attribution: %v\n", *result.Results.Attribution)
+	}
 	if result.Results.Success {
 		fmt.Printf("Trade log written to: %s\n", session.Config.OutputFile)
+	} else {
+		fmt.Printf("Session failed: %v\n", result.Results.Error)
 	}
 }
 
@@ -458,7 +750,7 @@ func writeTradeLog(filename string, trades []types.Execution) error {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"Timestamp", "Side", "Price", "Quantity", "Symbol"}
+	header := []string{"Timestamp", "OrderID", "Side", "Price", "Quantity", "Symbol", "Fills", "Fee", "Liquidity"}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
@@ -467,10 +759,14 @@ func writeTradeLog(filename string, trades []types.Execution) error {
 	for _, trade := range trades {
 		record := []string{
 			trade.Timestamp.Format(time.RFC3339),
+			trade.OrderID,
 			string(trade.Side),
-			fmt.Sprintf("%.8f", trade.Price),
-			fmt.Sprintf("%.8f", trade.Quantity),
+			trade.Price.String(),
+			trade.Quantity.String(),
 			trade.Symbol,
+			formatFills(trade.Fills),
+			trade.Fee.String(),
+			string(trade.Liquidity),
 		}
 		if err := writer.Write(record); err != nil {
 			return err
@@ -479,3 +775,22 @@ func writeTradeLog(filename string, trades []types.Execution) error {
 
 	return nil
 }
+
+// formatFills renders per-level fills as "quantity@price" pairs separated by
+// semicolons, in the order the book was walked
+func formatFills(fills []types.Fill) string {
+	parts := make([]string, len(fills))
+	for i, fill := range fills {
+		parts[i] = fill.Quantity.String() + "@" + fill.Price.String()
+	}
+	return strings.Join(parts, ";")
+}
+
+// formatBalances renders balances as "1.5 BTC, 25000 USD"
+func formatBalances(balances []account.Balance) string {
+	parts := make([]string, len(balances))
+	for i, balance := range balances {
+		parts[i] = balance.String()
+	}
+	return strings.Join(parts, ", ")
+}